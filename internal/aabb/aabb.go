@@ -0,0 +1,115 @@
+package aabb
+
+import (
+	"github.com/sendelivery/go-trace-rays/internal/interval"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// AABB is an axis-aligned bounding box, described by one interval per axis.
+type AABB struct {
+	X, Y, Z interval.Interval
+}
+
+// New returns an AABB from the three given axis intervals.
+func New(x, y, z interval.Interval) AABB {
+	return AABB{X: x, Y: y, Z: z}
+}
+
+// NewFromPoints returns the AABB spanning the two points a and b. The points may be given in
+// any order, they are treated as opposite corners of the box.
+func NewFromPoints(a, b vec3.Vector3) AABB {
+	return AABB{
+		X: interval.New(min(a.X(), b.X()), max(a.X(), b.X())),
+		Y: interval.New(min(a.Y(), b.Y()), max(a.Y(), b.Y())),
+		Z: interval.New(min(a.Z(), b.Z()), max(a.Z(), b.Z())),
+	}
+}
+
+// NewFromBoxes returns the smallest AABB enclosing both a and b.
+func NewFromBoxes(a, b AABB) AABB {
+	return AABB{
+		X: interval.NewFromIntervals(a.X, b.X),
+		Y: interval.NewFromIntervals(a.Y, b.Y),
+		Z: interval.NewFromIntervals(a.Z, b.Z),
+	}
+}
+
+// Pad returns a copy of the box where any axis thinner than delta has been widened to delta.
+// Flat objects such as triangles lying in an axis plane would otherwise have a box that no ray
+// can pass through.
+func (bb AABB) Pad(delta float64) AABB {
+	if bb.X.Size() < delta {
+		bb.X = bb.X.Expand(delta)
+	}
+	if bb.Y.Size() < delta {
+		bb.Y = bb.Y.Expand(delta)
+	}
+	if bb.Z.Size() < delta {
+		bb.Z = bb.Z.Expand(delta)
+	}
+	return bb
+}
+
+// AxisInterval returns the interval for axis n, where 0 is x, 1 is y and 2 is z.
+func (bb AABB) AxisInterval(n int) interval.Interval {
+	switch n {
+	case 1:
+		return bb.Y
+	case 2:
+		return bb.Z
+	default:
+		return bb.X
+	}
+}
+
+// Hit reports whether the ray r passes through the box anywhere within the range rt.
+func (bb AABB) Hit(r ray.Ray, rt interval.Interval) bool {
+	origin := r.Origin()
+	direction := r.Direction()
+	o := [3]float64{origin.X(), origin.Y(), origin.Z()}
+	d := [3]float64{direction.X(), direction.Y(), direction.Z()}
+
+	for axis := range 3 {
+		ax := bb.AxisInterval(axis)
+		adinv := 1.0 / d[axis]
+
+		t0 := (ax.Min - o[axis]) * adinv
+		t1 := (ax.Max - o[axis]) * adinv
+
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		if t0 > rt.Min {
+			rt.Min = t0
+		}
+		if t1 < rt.Max {
+			rt.Max = t1
+		}
+
+		if rt.Max <= rt.Min {
+			return false
+		}
+	}
+	return true
+}
+
+// LongestAxis returns the index of the longest axis of the box, 0 for x, 1 for y, 2 for z.
+func (bb AABB) LongestAxis() int {
+	if bb.X.Size() > bb.Y.Size() {
+		if bb.X.Size() > bb.Z.Size() {
+			return 0
+		}
+		return 2
+	}
+	if bb.Y.Size() > bb.Z.Size() {
+		return 1
+	}
+	return 2
+}
+
+// Empty is a bounding box containing nothing, it is the identity for NewFromBoxes
+var Empty = New(interval.EmptyInterval, interval.EmptyInterval, interval.EmptyInterval)
+
+// Universe is a bounding box containing everything
+var Universe = New(interval.UniverseInterval, interval.UniverseInterval, interval.UniverseInterval)
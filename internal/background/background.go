@@ -0,0 +1,51 @@
+package background
+
+import (
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// Backgrounder provides the light arriving along rays that leave the scene without hitting
+// anything.
+type Backgrounder interface {
+	Value(r ray.Ray) color.Color
+}
+
+// Gradient blends vertically between two colours, from bottom straight down to top straight up.
+type Gradient struct {
+	bottom, top color.Color
+}
+
+func NewGradient(bottom, top color.Color) Gradient {
+	return Gradient{bottom, top}
+}
+
+// NewSky returns the default white to light blue sky gradient.
+func NewSky() Gradient {
+	return NewGradient(color.White, color.New(0.5, 0.7, 1))
+}
+
+func (g Gradient) Value(r ray.Ray) color.Color {
+	unitDirection := vec3.UnitVector(r.Direction())
+	a := 0.5 * (unitDirection.Y() + 1)
+
+	return vec3.Add(
+		vec3.Mulf(g.bottom, 1.0-a),
+		vec3.Mulf(g.top, a),
+	)
+}
+
+// Constant is the same colour in every direction. A black Constant background leaves the scene
+// lit only by its emissive objects.
+type Constant struct {
+	col color.Color
+}
+
+func NewConstant(col color.Color) Constant {
+	return Constant{col}
+}
+
+func (c Constant) Value(r ray.Ray) color.Color {
+	return c.col
+}
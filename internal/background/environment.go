@@ -0,0 +1,185 @@
+package background
+
+import (
+	"math"
+	"sort"
+
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/hdr"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// Sampler is implemented by backgrounds that the renderer can aim shadow rays at directly,
+// like a light, rather than waiting for bounced rays to escape towards their bright parts.
+type Sampler interface {
+	Backgrounder
+
+	// Sample returns a random direction, chosen in proportion to the light arriving from it.
+	// rng is owned by the calling goroutine.
+	Sample(rng *utility.Rand) vec3.Vector3
+
+	// PDF returns the probability density, per unit solid angle, of Sample choosing dir
+	PDF(dir vec3.Vector3) float64
+}
+
+// Environment lights the scene with an equirectangular image of its surroundings, such as an
+// HDR photograph of the sky. The top row of the image is straight up, the bottom straight down,
+// and the image wraps once around the Y axis with its centre facing +X.
+//
+// Directions are sampled in proportion to the image's luminance, so small bright features
+// like the sun are found as quickly as area lights.
+type Environment struct {
+	equirect
+	rotation  float64 // Turn about the Y axis, in radians
+	intensity float64
+}
+
+// NewEnvironment returns an Environment showing img turned anticlockwise about the Y axis by
+// rotation radians, as seen from above, with its radiance scaled by intensity.
+func NewEnvironment(img *hdr.Image, rotation, intensity float64) *Environment {
+	return &Environment{newEquirect(img), rotation, intensity}
+}
+
+// LoadEnvironment reads an .hdr or .pfm file into an Environment, see NewEnvironment
+func LoadEnvironment(path string, rotation, intensity float64) (*Environment, error) {
+	img, err := hdr.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewEnvironment(img, rotation, intensity), nil
+}
+
+func (e *Environment) Value(r ray.Ray) color.Color {
+	x, y, _ := e.pixel(rotateY(r.Direction(), -e.rotation))
+	return vec3.Mulf(e.img.At(x, y), e.intensity)
+}
+
+func (e *Environment) Sample(rng *utility.Rand) vec3.Vector3 {
+	return rotateY(e.sample(rng), e.rotation)
+}
+
+func (e *Environment) PDF(dir vec3.Vector3) float64 {
+	return e.pdf(rotateY(dir, -e.rotation))
+}
+
+// equirect picks directions over the sphere in proportion to the luminance of an
+// equirectangular image, with its top row straight up and its centre facing +X
+type equirect struct {
+	img *hdr.Image
+
+	rowCDF []float64 // Cumulative probability of sampling each row
+	colCDF []float64 // Cumulative probability of sampling each column, given the row
+}
+
+func newEquirect(img *hdr.Image) equirect {
+	e := equirect{
+		img:    img,
+		rowCDF: make([]float64, img.Height+1),
+		colCDF: make([]float64, img.Height*(img.Width+1)),
+	}
+
+	// Each pixel is weighted by its luminance and by the solid angle it covers, which shrinks
+	// towards the poles. A black image falls back to sampling directions uniformly.
+	total := 0.0
+	for y := range img.Height {
+		sinTheta := math.Sin(math.Pi * (float64(y) + 0.5) / float64(img.Height))
+		for x := range img.Width {
+			total += luminance(img.At(x, y)) * sinTheta
+		}
+	}
+	weight := func(x, y int) float64 {
+		sinTheta := math.Sin(math.Pi * (float64(y) + 0.5) / float64(img.Height))
+		if total <= 0 {
+			return sinTheta
+		}
+		return luminance(img.At(x, y)) * sinTheta
+	}
+
+	for y := range img.Height {
+		cdf := e.colCDF[y*(img.Width+1) : (y+1)*(img.Width+1)]
+		for x := range img.Width {
+			cdf[x+1] = cdf[x] + weight(x, y)
+		}
+		rowWeight := cdf[img.Width]
+		e.rowCDF[y+1] = e.rowCDF[y] + rowWeight
+		normalise(cdf, img.Width)
+	}
+	normalise(e.rowCDF, img.Height)
+
+	return e
+}
+
+func (e equirect) sample(rng *utility.Rand) vec3.Vector3 {
+	y := pick(e.rowCDF, rng.Float64())
+	x := pick(e.colCDF[y*(e.img.Width+1):(y+1)*(e.img.Width+1)], rng.Float64())
+
+	u := (float64(x) + rng.Float64()) / float64(e.img.Width)
+	v := (float64(y) + rng.Float64()) / float64(e.img.Height)
+	return direction(u, v)
+}
+
+func (e equirect) pdf(dir vec3.Vector3) float64 {
+	x, y, sinTheta := e.pixel(dir)
+	if sinTheta <= 0 {
+		return 0
+	}
+
+	w := e.img.Width + 1
+	p := (e.rowCDF[y+1] - e.rowCDF[y]) * (e.colCDF[y*w+x+1] - e.colCDF[y*w+x])
+
+	// Pixels are sampled uniformly over their area of the image, which is stretched over the
+	// sphere by 2pi^2 sin(theta)
+	return p * float64(e.img.Width*e.img.Height) / (2 * math.Pi * math.Pi * sinTheta)
+}
+
+// pixel returns the pixel of the image seen in direction dir, along with the sine of dir's
+// angle from straight up
+func (e equirect) pixel(dir vec3.Vector3) (x, y int, sinTheta float64) {
+	d := vec3.UnitVector(dir)
+	cosTheta := max(-1, min(1, d.Y()))
+	u := (math.Atan2(-d.Z(), d.X()) + math.Pi) / (2 * math.Pi)
+	v := math.Acos(cosTheta) / math.Pi
+
+	x = max(0, min(int(u*float64(e.img.Width)), e.img.Width-1))
+	y = max(0, min(int(v*float64(e.img.Height)), e.img.Height-1))
+	return x, y, math.Sqrt(1 - cosTheta*cosTheta)
+}
+
+// direction returns the unit direction at u, v on an equirectangular image, both running from
+// 0 to 1, u from left to right and v from top to bottom
+func direction(u, v float64) vec3.Vector3 {
+	theta, phi := v*math.Pi, u*2*math.Pi-math.Pi
+	return vec3.New(math.Sin(theta)*math.Cos(phi), math.Cos(theta), -math.Sin(theta)*math.Sin(phi))
+}
+
+// rotateY turns v anticlockwise about the Y axis, as seen from above, by angle radians
+func rotateY(v vec3.Vector3, angle float64) vec3.Vector3 {
+	sin, cos := math.Sincos(angle)
+	return vec3.New(cos*v.X()+sin*v.Z(), v.Y(), -sin*v.X()+cos*v.Z())
+}
+
+// luminance returns the brightness of col as perceived by the eye, ignoring negative values
+func luminance(col color.Color) float64 {
+	return max(0, 0.2126*col.X()+0.7152*col.Y()+0.0722*col.Z())
+}
+
+// normalise scales the cumulative sums cdf[0..n] to end at 1, leaving an all zero cdf alone
+func normalise(cdf []float64, n int) {
+	total := cdf[n]
+	if total <= 0 {
+		return
+	}
+	for i := range cdf[:n+1] {
+		cdf[i] /= total
+	}
+}
+
+// pick returns the bin i of the cumulative distribution cdf with cdf[i] <= xi < cdf[i+1],
+// never choosing an empty bin
+func pick(cdf []float64, xi float64) int {
+	n := len(cdf) - 1
+	i := sort.Search(n, func(i int) bool { return cdf[i+1] > xi })
+	return min(i, n-1)
+}
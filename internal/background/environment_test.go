@@ -0,0 +1,106 @@
+package background_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sendelivery/go-trace-rays/internal/background"
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/hdr"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// newSunny returns a dim 16x8 sky with a single very bright pixel, like the sun
+func newSunny() *hdr.Image {
+	img := hdr.New(16, 8)
+	for i := range img.Pix {
+		img.Pix[i] = color.New(0.1, 0.1, 0.2)
+	}
+	img.Set(5, 2, color.New(1000, 900, 800))
+	return img
+}
+
+func brightness(env *background.Environment, dir vec3.Vector3) float64 {
+	col := env.Value(ray.New(vec3.New(0, 0, 0), dir))
+	return (col.X() + col.Y() + col.Z()) / 3
+}
+
+func TestEnvironmentSampling(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]*hdr.Image{
+		"sunny": newSunny(),
+		"black": hdr.New(4, 2),
+	}
+
+	for name, img := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			env := background.NewEnvironment(img, 1, 2)
+			rng := utility.NewRand(3, 0)
+
+			// Both estimates of the light arriving from every direction must agree, and the
+			// density must integrate to 1 over the sphere
+			const n = 200000
+			var sampled, uniform, integral float64
+			for range n {
+				dir := env.Sample(rng)
+				sampled += brightness(env, dir) / env.PDF(dir)
+
+				dir = vec3.NewRandomUnitVector(rng)
+				uniform += brightness(env, dir) * 4 * math.Pi
+				integral += env.PDF(dir) * 4 * math.Pi
+			}
+			sampled, uniform, integral = sampled/n, uniform/n, integral/n
+
+			if math.Abs(integral-1) > 0.02 {
+				t.Errorf("unexpected integral of the density, got=%v. want=%v.", integral, 1)
+			}
+			if math.Abs(sampled-uniform) > 0.05*uniform {
+				t.Errorf("unexpected total light, got=%v. want=%v.", sampled, uniform)
+			}
+		})
+	}
+}
+
+func TestEnvironmentFindsSun(t *testing.T) {
+	t.Parallel()
+
+	env := background.NewEnvironment(newSunny(), 0, 1)
+	rng := utility.NewRand(4, 0)
+
+	const n = 1000
+	hits := 0
+	for range n {
+		if brightness(env, env.Sample(rng)) > 100 {
+			hits++
+		}
+	}
+	if hits < 0.95*n {
+		t.Errorf("unexpected share of samples on the sun, got=%d. want at least %d.", hits, int(0.95*n))
+	}
+}
+
+func TestEnvironmentRotation(t *testing.T) {
+	t.Parallel()
+
+	still := background.NewEnvironment(newSunny(), 0, 1)
+	turned := background.NewEnvironment(newSunny(), math.Pi/2, 3)
+
+	tests := []vec3.Vector3{
+		vec3.New(1, 0, 0),
+		vec3.New(0.3, 0.5, -0.2),
+		vec3.New(-1, 2, 1),
+	}
+	for _, dir := range tests {
+		// A quarter turn anticlockwise from above takes +X to -Z and +Z to +X
+		turnedDir := vec3.New(dir.Z(), dir.Y(), -dir.X())
+		got, want := brightness(turned, turnedDir), 3*brightness(still, dir)
+		if math.Abs(got-want) > 1e-9 {
+			t.Errorf("unexpected brightness towards %q, got=%v. want=%v.", &turnedDir, got, want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package background
+
+import (
+	"math"
+
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/hdr"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+const (
+	// sunAngularRadius is the angle between the centre and the edge of the sun's disk, in radians
+	sunAngularRadius = 0.00465
+
+	// sunRadiance is the sun's radiance above the atmosphere, and skyUnit the luminance in kcd/m²
+	// given a radiance of 1, so the sky and the sun keep their real proportions
+	sunRadiance = 80000
+	skyUnit     = 20
+
+	// sunShare is the chance of Sample aiming at the sun rather than the sky
+	sunShare = 0.5
+)
+
+// SunSky is a clear daytime sky, following A. J. Preetham, P. Shirley and B. Smits, "A
+// Practical Analytic Model for Daylight", with the sun's disk in it. Turbidity sets how hazy the
+// air is, from 2 for a very clear day to 10 for a hazy one. Below the horizon it is black, so
+// scenes need a ground of their own.
+//
+// Both the sun and the bright sky around it are sampled directly, so outdoor scenes are lit
+// without the noise of waiting for bounced rays to find the sun.
+type SunSky struct {
+	sun       vec3.Vector3 // Unit direction towards the sun
+	sunColor  color.Color  // Radiance of the sun's disk, dimmed by the air in front of it
+	cosRadius float64      // Cosine of the sun's angular radius
+	intensity float64
+
+	// Perez coefficients and zenith values for the luminance Y and the chromaticities x and y.
+	// The zenith values are divided by the Perez function at the zenith to save doing it for
+	// every ray.
+	coeffs [3][5]float64
+	zenith [3]float64
+
+	table equirect // The sky without the sun, for sampling
+}
+
+// NewSunSky returns a SunSky with the sun elevation radians above the horizon, turned
+// anticlockwise from +X about the Y axis by azimuth radians as seen from above. The sky's
+// radiance is scaled by intensity. Elevations are clamped to between 0 and straight up, and
+// turbidity to between 1.7 and 10, the range the model was fitted to.
+func NewSunSky(elevation, azimuth, turbidity, intensity float64) *SunSky {
+	elevation = max(0, min(elevation, math.Pi/2))
+	t := max(1.7, min(turbidity, 10))
+	theta := math.Pi/2 - elevation
+
+	s := SunSky{
+		sun:       vec3.New(math.Cos(elevation)*math.Cos(azimuth), math.Sin(elevation), -math.Cos(elevation)*math.Sin(azimuth)),
+		sunColor:  sunColor(theta, t),
+		cosRadius: math.Cos(sunAngularRadius),
+		intensity: intensity,
+		coeffs: [3][5]float64{
+			{0.1787*t - 1.4630, -0.3554*t + 0.4275, -0.0227*t + 5.3251, 0.1206*t - 2.5771, -0.0670*t + 0.3703},
+			{-0.0193*t - 0.2592, -0.0665*t + 0.0008, -0.0004*t + 0.2125, -0.0641*t - 0.8989, -0.0033*t + 0.0452},
+			{-0.0167*t - 0.2608, -0.0950*t + 0.0092, -0.0079*t + 0.2102, -0.0441*t - 1.6537, -0.0109*t + 0.0529},
+		},
+	}
+
+	chi := (4.0/9 - t/120) * (math.Pi - 2*theta)
+	theta2, theta3 := theta*theta, theta*theta*theta
+	s.zenith = [3]float64{
+		(4.0453*t-4.9710)*math.Tan(chi) - 0.2155*t + 2.4192,
+		t*t*(0.00166*theta3-0.00375*theta2+0.00209*theta) +
+			t*(-0.02903*theta3+0.06377*theta2-0.03202*theta+0.00394) +
+			(0.11693*theta3 - 0.21196*theta2 + 0.06052*theta + 0.25886),
+		t*t*(0.00275*theta3-0.00610*theta2+0.00317*theta) +
+			t*(-0.04214*theta3+0.08970*theta2-0.04153*theta+0.00516) +
+			(0.15346*theta3 - 0.26756*theta2 + 0.06670*theta + 0.26688),
+	}
+	for i := range s.zenith {
+		s.zenith[i] /= perez(s.coeffs[i], 1, theta)
+	}
+
+	// The sky changes smoothly enough to sample from a coarse table of it
+	img := hdr.New(256, 128)
+	for y := range img.Height {
+		for x := range img.Width {
+			u := (float64(x) + 0.5) / float64(img.Width)
+			v := (float64(y) + 0.5) / float64(img.Height)
+			img.Set(x, y, s.sky(direction(u, v)))
+		}
+	}
+	s.table = newEquirect(img)
+
+	return &s
+}
+
+func (s *SunSky) Value(r ray.Ray) color.Color {
+	d := vec3.UnitVector(r.Direction())
+	col := s.sky(d)
+	if s.onSun(d) {
+		col.Add(s.sunColor)
+	}
+	return vec3.Mulf(col, s.intensity)
+}
+
+// Sample picks either a direction towards the sun or one across the sky, in proportion to the
+// sky's luminance
+func (s *SunSky) Sample(rng *utility.Rand) vec3.Vector3 {
+	if rng.Float64() >= sunShare {
+		return s.table.sample(rng)
+	}
+
+	z := 1 + rng.Float64()*(s.cosRadius-1)
+	sinTheta := math.Sqrt(max(0, 1-z*z))
+	phi := 2 * math.Pi * rng.Float64()
+
+	u, v := vec3.Basis(s.sun)
+	dir := vec3.Mulf(s.sun, z)
+	dir.Add(vec3.Mulf(u, sinTheta*math.Cos(phi)))
+	dir.Add(vec3.Mulf(v, sinTheta*math.Sin(phi)))
+	return dir
+}
+
+func (s *SunSky) PDF(dir vec3.Vector3) float64 {
+	pdf := (1 - sunShare) * s.table.pdf(dir)
+	if s.onSun(vec3.UnitVector(dir)) {
+		pdf += sunShare / (2 * math.Pi * (1 - s.cosRadius))
+	}
+	return pdf
+}
+
+// onSun reports whether the unit direction d looks at the part of the sun's disk above the
+// horizon, which like the sky hides everything below it
+func (s *SunSky) onSun(d vec3.Vector3) bool {
+	return d.Y() > 0 && vec3.Dot(d, s.sun) >= s.cosRadius
+}
+
+// sky returns the radiance of the sky, without the sun, in the unit direction d
+func (s *SunSky) sky(d vec3.Vector3) color.Color {
+	if d.Y() <= 0 {
+		return color.Black
+	}
+	gamma := math.Acos(max(-1, min(1, vec3.Dot(d, s.sun))))
+
+	var v [3]float64
+	for i := range v {
+		v[i] = s.zenith[i] * perez(s.coeffs[i], d.Y(), gamma)
+	}
+	lum, x, y := v[0]/skyUnit, v[1], v[2]
+	return xyYToRGB(x, y, lum)
+}
+
+// perez returns the Perez sky function for a direction cosTheta above the horizon and gamma
+// radians from the sun
+func perez(c [5]float64, cosTheta, gamma float64) float64 {
+	cosGamma := math.Cos(gamma)
+	return (1 + c[0]*math.Exp(c[1]/cosTheta)) * (1 + c[2]*math.Exp(c[3]*gamma) + c[4]*cosGamma*cosGamma)
+}
+
+// sunColor returns the sun's radiance seen through the atmosphere with the sun theta radians
+// from the zenith. Light is scattered out of the beam by air molecules, most strongly at the
+// blue end, and by haze, which grows with turbidity.
+func sunColor(theta, turbidity float64) color.Color {
+	// Relative air mass, following Kasten and Young, which stays finite at the horizon
+	degrees := theta * 180 / math.Pi
+	mass := 1 / (math.Cos(theta) + 0.50572*math.Pow(96.07995-degrees, -1.6364))
+
+	beta := 0.04608*turbidity - 0.04586
+	var channels [3]float64
+	for i, lambda := range [3]float64{0.68, 0.55, 0.44} { // Red, green and blue, in micrometres
+		rayleigh := 0.008735 * math.Pow(lambda, -4.08)
+		aerosol := beta * math.Pow(lambda, -1.3)
+		channels[i] = sunRadiance * math.Exp(-mass*(rayleigh+aerosol))
+	}
+	return color.New(channels[0], channels[1], channels[2])
+}
+
+// xyYToRGB converts a colour given by its CIE chromaticity x, y and luminance lum to linear
+// sRGB, clamping colours outside of the sRGB gamut to it
+func xyYToRGB(x, y, lum float64) color.Color {
+	if y <= 0 {
+		return color.Black
+	}
+	cx, cz := x/y*lum, (1-x-y)/y*lum
+	return color.New(
+		max(0, 3.2406*cx-1.5372*lum-0.4986*cz),
+		max(0, -0.9689*cx+1.8758*lum+0.0415*cz),
+		max(0, 0.0557*cx-0.2040*lum+1.0570*cz),
+	)
+}
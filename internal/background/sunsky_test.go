@@ -0,0 +1,123 @@
+package background_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sendelivery/go-trace-rays/internal/background"
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+func value(bg background.Backgrounder, dir vec3.Vector3) color.Color {
+	return bg.Value(ray.New(vec3.New(0, 0, 0), dir))
+}
+
+// sunDirection returns the direction of the sun given to NewSunSky, in degrees
+func sunDirection(elevation, azimuth float64) vec3.Vector3 {
+	e, a := utility.Deg2Rad(elevation), utility.Deg2Rad(azimuth)
+	return vec3.New(math.Cos(e)*math.Cos(a), math.Sin(e), -math.Cos(e)*math.Sin(a))
+}
+
+func TestSunSkySampling(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct{ elevation, azimuth, turbidity float64 }{
+		"noon":   {elevation: 70, azimuth: 30, turbidity: 2},
+		"sunset": {elevation: 2, azimuth: 200, turbidity: 6},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			sky := background.NewSunSky(utility.Deg2Rad(tt.elevation), utility.Deg2Rad(tt.azimuth), tt.turbidity, 1)
+			sun := sunDirection(tt.elevation, tt.azimuth)
+			cosRadius := math.Cos(0.00465)
+			rng := utility.NewRand(5, 0)
+
+			// Samples must cover the whole sky above the horizon, with a density that measures
+			// out its solid angle, and land on the sun as often as on the rest of the sky
+			const n = 200000
+			var solidAngle float64
+			onSun := 0
+			for range n {
+				dir := sky.Sample(rng)
+				if dir.Y() > 0 {
+					solidAngle += 1 / sky.PDF(dir)
+				}
+				if vec3.Dot(vec3.UnitVector(dir), sun) >= cosRadius {
+					onSun++
+				}
+			}
+			solidAngle /= n
+
+			if math.Abs(solidAngle-2*math.Pi) > 0.02*2*math.Pi {
+				t.Errorf("unexpected solid angle of the sky, got=%v. want=%v.", solidAngle, 2*math.Pi)
+			}
+			if share := float64(onSun) / n; math.Abs(share-0.5) > 0.01 {
+				t.Errorf("unexpected share of samples on the sun, got=%v. want=%v.", share, 0.5)
+			}
+		})
+	}
+}
+
+func TestSunSkyColours(t *testing.T) {
+	t.Parallel()
+
+	noon := background.NewSunSky(utility.Deg2Rad(60), 0, 3, 1)
+	sunset := background.NewSunSky(utility.Deg2Rad(3), 0, 3, 1)
+	up := vec3.New(0, 1, 0)
+
+	if col := value(noon, up); col.Z() <= col.X() {
+		t.Errorf("expected a blue sky overhead at noon, got=%q.", &col)
+	}
+	if col := value(noon, vec3.New(0.3, -0.5, 0.1)); !vec3.Equal(col, color.Black) {
+		t.Errorf("unexpected colour below the horizon, got=%q. want=%q.", &col, &color.Black)
+	}
+
+	// The sun outshines the sky and reddens as it sets through more air
+	high, low := value(noon, sunDirection(60, 0)), value(sunset, sunDirection(3, 0))
+	if sky := value(noon, up); high.Y() < 1000*sky.Y() {
+		t.Errorf("expected the sun to outshine the sky, got=%q. sky=%q.", &high, &sky)
+	}
+	if high.X()/high.Z() >= low.X()/low.Z() {
+		t.Errorf("expected the setting sun to be redder, got=%q at noon and %q at sunset.", &high, &low)
+	}
+	if zenith, overhead := value(sunset, up), value(noon, up); zenith.Y() >= overhead.Y() {
+		t.Errorf("expected a darker sky at sunset, got=%q. noon=%q.", &zenith, &overhead)
+	}
+}
+
+// A sun on the horizon is cut in half by it, its lower half doesn't light the ground
+func TestSunSkyHorizon(t *testing.T) {
+	t.Parallel()
+
+	sky := background.NewSunSky(0, 0, 3, 1)
+	above, below := vec3.New(1, 0.002, 0), vec3.New(1, -0.002, 0)
+
+	if col := value(sky, above); col.Y() < 1 {
+		t.Errorf("expected the sun just above the horizon, got=%q.", &col)
+	}
+	if col := value(sky, below); !vec3.Equal(col, color.Black) {
+		t.Errorf("unexpected colour just below the horizon, got=%q. want=%q.", &col, &color.Black)
+	}
+	if pdf := sky.PDF(below); pdf != 0 {
+		t.Errorf("unexpected density just below the horizon, got=%v. want=0.", pdf)
+	}
+}
+
+func TestSunSkyIntensity(t *testing.T) {
+	t.Parallel()
+
+	dim := background.NewSunSky(utility.Deg2Rad(50), utility.Deg2Rad(120), 4, 1)
+	bright := background.NewSunSky(utility.Deg2Rad(50), utility.Deg2Rad(120), 4, 2.5)
+	for _, dir := range []vec3.Vector3{vec3.New(0, 1, 0), vec3.New(1, 0.2, -1), sunDirection(50, 120)} {
+		got, want := value(bright, dir), vec3.Mulf(value(dim, dir), 2.5)
+		if vec3.Sub(got, want).Length() > 1e-9*want.Length() {
+			t.Errorf("unexpected colour towards %q, got=%q. want=%q.", &dir, &got, &want)
+		}
+	}
+}
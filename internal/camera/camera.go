@@ -1,19 +1,22 @@
 package camera
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"math"
-	"os"
 	"runtime"
 	"sync"
-	"sync/atomic"
-	"time"
 
+	"github.com/sendelivery/go-trace-rays/internal/background"
 	"github.com/sendelivery/go-trace-rays/internal/color"
 	"github.com/sendelivery/go-trace-rays/internal/image"
 	"github.com/sendelivery/go-trace-rays/internal/interval"
+	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
 	"github.com/sendelivery/go-trace-rays/internal/object/hittable"
+	"github.com/sendelivery/go-trace-rays/internal/progress"
 	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/scheduler"
 	"github.com/sendelivery/go-trace-rays/internal/utility"
 	"github.com/sendelivery/go-trace-rays/internal/vec3"
 )
@@ -32,23 +35,44 @@ type Camera struct {
 	DefocusAngle  float64 // Variation angle of rays through each pixel
 	FocusDistance float64 // Distance from the camera look from point to the plane of perfect focus
 
-	imageHeight      int          // Rendered image height
-	centre           vec3.Vector3 // Camera center
-	pixel00Loc       vec3.Vector3 // Location of pixel 0, 0
-	pixelDeltaU      vec3.Vector3 // Offset to pixel to the right
-	pixelDeltaV      vec3.Vector3 // Offset to pixel below
-	pixelSampleScale float64      // Color scale factor for a sum of pixel samples
-	u, v, w          vec3.Vector3 // Camera frame basis vectors
-	defocusDiskU     vec3.Vector3 // Defocus disk horizontal radius
-	defocusDiskV     vec3.Vector3 // Defocus disk vertical radius
+	ShutterOpen  float64 // Time the shutter opens, rays are cast at times between open and close
+	ShutterClose float64 // Time the shutter closes, equal to ShutterOpen for no motion blur
+
+	Background background.Backgrounder // Light arriving from rays that hit nothing
+
+	// SampleLights aims a shadow ray at a random light from every hit as well as bouncing,
+	// which finds small lights far more often than bouncing alone
+	SampleLights bool
+
+	Seed uint64 // Master seed, the same seed always renders the same image
+
+	TileSize  int             // Width and height of the tiles used by the parallel workflow
+	TileOrder scheduler.Order // Order in which tiles are rendered by the parallel workflow
+	Workers   int             // Goroutines used by the parallel workflow, 0 for all but two CPUs
+
+	Progress progress.Observer // Receives progress events while rendering, nil for none
+
+	imageHeight  int          // Rendered image height
+	centre       vec3.Vector3 // Camera center
+	pixel00Loc   vec3.Vector3 // Location of pixel 0, 0
+	pixelDeltaU  vec3.Vector3 // Offset to pixel to the right
+	pixelDeltaV  vec3.Vector3 // Offset to pixel below
+	u, v, w      vec3.Vector3 // Camera frame basis vectors
+	defocusDiskU vec3.Vector3 // Defocus disk horizontal radius
+	defocusDiskV vec3.Vector3 // Defocus disk vertical radius
+
+	lights lightList      // Lights sampled directly, if SampleLights is set
+	img    image.Image    // The framebuffer rendered pixels are written to
+	stats  progress.Stats // Statistics of the most recent render
 
 	// Below fields are used by the parallel workflow
-	parallel  bool        // Whether to render the image using the parallel workflow
-	workers   int         // Number of goroutines rendering the image
-	img       image.Image // An Image struct used by the parallel workflow
-	numChunks int         // Number of chunks to break the image up in
+	parallel bool // Whether to render the image using the parallel workflow
+	workers  int  // Number of goroutines rendering the image
 }
 
+// defaultTileSize is the tile width and height used when TileSize is left unset
+const defaultTileSize = 32
+
 func New() *Camera {
 	c := Camera{
 		AspectRatio:     1.0,
@@ -61,88 +85,182 @@ func New() *Camera {
 		VUp:             vec3.New(0, 1, 0),
 		DefocusAngle:    0,
 		FocusDistance:   10,
+		Background:      background.NewSky(),
+		SampleLights:    true,
+		TileSize:        defaultTileSize,
+		TileOrder:       scheduler.Scanline,
 	}
 	return &c
 }
 
-func (c *Camera) Render(world hittable.Hittabler) {
-	c.initialise()
-
-	// Timer
-	start := time.Now()
-	defer func() {
-		elapsed := time.Since(start).Seconds()
-		c.statusf("\rDone in %.2fs.         \n", elapsed)
-	}()
+// Render renders the world one scanline at a time and returns the finished image. If ctx is
+// cancelled the render stops after the current scanline, returning the partially rendered
+// image along with the context's error. An invalid camera returns a nil image and the
+// validation error.
+func (c *Camera) Render(ctx context.Context, world hittable.Hittabler) (*image.Image, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	c.initialise(world)
 
-	fmt.Printf("P3\n%d %d\n255\n", c.ImageWidth, c.imageHeight)
+	tracker := c.newTracker(0)
+	tracker.Start(c.startEvent(1))
 
 	for j := range c.imageHeight {
-		c.statusf("\rScanlines remaining: %d ", c.imageHeight-j)
+		if err := ctx.Err(); err != nil {
+			c.stats = tracker.Finish(err)
+			return &c.img, err
+		}
+
+		var rays uint64
 		for i := range c.ImageWidth {
-			col := c.calculatePixel(i, j, world)
-			color.WriteColor(os.Stdout, col)
+			sum, n := c.calculatePixel(i, j, world)
+			if err := c.img.Accumulate(image.NewPixelCoord(i, j), sum, c.SamplesPerPixel); err != nil {
+				c.stats = tracker.Finish(err)
+				return &c.img, err
+			}
+			rays += n
 		}
+		tracker.Done(uint64(c.ImageWidth*c.SamplesPerPixel), rays)
 	}
+
+	c.stats = tracker.Finish(nil)
+	return &c.img, nil
 }
 
-func (c *Camera) RenderParallel(world hittable.Hittabler) {
+// RenderParallel renders the world in tiles spread across several goroutines and returns the
+// finished image. Tiles are handed out in TileOrder, idle workers steal tiles from busy ones.
+// If ctx is cancelled the workers stop after their current scanline, and the partially
+// rendered image is returned along with the context's error. If a worker fails the others are
+// stopped in the same way and its error is returned. An invalid camera returns a nil image and
+// the validation error.
+func (c *Camera) RenderParallel(ctx context.Context, world hittable.Hittabler) (*image.Image, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
 	c.parallel = true
-	c.initialise()
+	c.initialise(world)
 
-	c.statusf("%d workers\n", c.workers)
-	c.statusf("%d chunks\n", c.numChunks)
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
 
-	// Timer
-	start := time.Now()
-	defer func() {
-		elapsed := time.Since(start).Seconds()
-		c.statusf("\rDone in %.2fs.         \n", elapsed)
-	}()
+	tiles := scheduler.Tiles(c.ImageWidth, c.imageHeight, c.TileSize, c.TileOrder)
+	sched := scheduler.New(tiles, min(c.workers, len(tiles)))
 
-	chunks := make(chan image.Chunk, c.numChunks)
-	c.queueChunks(chunks)
-	close(chunks) // All the data that needs to be sent has been sent
+	tracker := c.newTracker(len(tiles))
+	tracker.Start(c.startEvent(sched.Workers()))
 
 	var wg sync.WaitGroup
-	wg.Add(c.numChunks)
 
-	chunksLeft := atomic.Int32{}
-	chunksLeft.Add(int32(c.numChunks))
-	c.statusf("\rChunks remaining: %d ", c.numChunks)
-
-	// Queue up the workers
-	for range c.workers {
+	for worker := range sched.Workers() {
+		wg.Add(1)
 		go func() {
-			for ch := range chunks {
-				c.processChunk(ch, world)
-				chunksLeft.Add(-1)
-				c.statusf("\rChunks remaining: %d ", chunksLeft.Load())
-				wg.Done()
+			defer wg.Done()
+			for ctx.Err() == nil {
+				tile, ok := sched.Next(worker)
+				if !ok {
+					return
+				}
+				rays, err := c.processChunk(ctx, tile, world)
+				if err != nil {
+					cancel(err)
+				}
+				if ctx.Err() != nil {
+					return
+				}
+				samples := uint64((tile.End().X() - tile.Start().X()) * (tile.End().Y() - tile.Start().Y()) * c.SamplesPerPixel)
+				tracker.TileDone(tile, worker, samples, rays)
 			}
 		}()
 	}
 
 	wg.Wait()
 
-	// Draw the image
-	fmt.Printf("P3\n%d %d\n255\n", c.ImageWidth, c.imageHeight)
-	for y := range c.imageHeight {
-		for x := range c.ImageWidth {
-			col, ok := c.img.Get(image.NewPixelCoord(x, y))
-			if !ok {
-				panic(fmt.Sprintf("issue when drawing image at coord %d, %d", x, y))
-			}
-			color.WriteColor(os.Stdout, col)
-		}
+	var err error
+	if ctx.Err() != nil {
+		err = context.Cause(ctx)
+	}
+	c.stats = tracker.Finish(err)
+	return &c.img, err
+}
+
+// Stats returns the statistics of the most recent render
+func (c *Camera) Stats() progress.Stats {
+	return c.stats
+}
+
+// newTracker returns a progress tracker reporting to c.Progress for a render of the whole image
+func (c *Camera) newTracker(tiles int) *progress.Tracker {
+	samples := uint64(c.ImageWidth * c.imageHeight * c.SamplesPerPixel)
+	return progress.NewTracker(c.Progress, samples, tiles)
+}
+
+func (c *Camera) startEvent(workers int) progress.Start {
+	return progress.Start{
+		Width:           c.ImageWidth,
+		Height:          c.imageHeight,
+		SamplesPerPixel: c.SamplesPerPixel,
+		Workers:         workers,
+	}
+}
+
+// Validate reports every setting that would stop the camera from rendering a sensible image
+func (c *Camera) Validate() error {
+	var errs []error
+	errorf := func(format string, a ...any) {
+		errs = append(errs, fmt.Errorf(format, a...))
+	}
+
+	if c.SamplesPerPixel < 1 {
+		errorf("samples per pixel must be at least 1, got %d", c.SamplesPerPixel)
+	}
+	if !(c.AspectRatio > 0) || math.IsInf(c.AspectRatio, 0) {
+		errorf("aspect ratio must be a positive number, got %v", c.AspectRatio)
+	}
+	if c.ImageWidth < 1 {
+		errorf("image width must be at least 1, got %d", c.ImageWidth)
+	}
+	if c.MaxDepth < 1 {
+		errorf("max depth must be at least 1, got %d", c.MaxDepth)
+	}
+
+	if !(c.VerticalFov > 0 && c.VerticalFov < 180) {
+		errorf("vertical field of view must be between 0 and 180 degrees, got %v", c.VerticalFov)
+	}
+	if view := vec3.Sub(c.LookAt, c.LookFrom); vec3.IsNearZero(view) {
+		errorf("look from and look at must be different points, both are %q", &c.LookFrom)
+	} else if vec3.IsNearZero(vec3.Cross(c.VUp, view)) {
+		errorf("up vector %q must not be zero or parallel to the view direction", &c.VUp)
+	}
+
+	if !(c.DefocusAngle >= 0 && c.DefocusAngle < 180) {
+		errorf("defocus angle must be at least 0 and less than 180 degrees, got %v", c.DefocusAngle)
+	}
+	if !(c.FocusDistance > 0) {
+		errorf("focus distance must be positive, got %v", c.FocusDistance)
+	}
+
+	if c.ShutterClose < c.ShutterOpen {
+		errorf("shutter must not close (%v) before it opens (%v)", c.ShutterClose, c.ShutterOpen)
+	}
+
+	if c.TileSize < 0 {
+		errorf("tile size must not be negative, got %d", c.TileSize)
+	}
+	if c.Workers < 0 {
+		errorf("workers must not be negative, got %d", c.Workers)
 	}
+
+	return errors.Join(errs...)
 }
 
-func (c *Camera) initialise() {
+func (c *Camera) initialise(world hittable.Hittabler) {
 	// Calculate image height, ensuring it's at least 1
 	c.imageHeight = max(int(float64(c.ImageWidth)/c.AspectRatio), 1)
 
-	c.pixelSampleScale = 1.0 / float64(c.SamplesPerPixel)
+	if c.Background == nil {
+		c.Background = background.NewSky()
+	}
 
 	c.centre = c.LookFrom
 
@@ -184,46 +302,48 @@ func (c *Camera) initialise() {
 	c.defocusDiskU = vec3.Mulf(c.u, defocusRadius)
 	c.defocusDiskV = vec3.Mulf(c.v, defocusRadius)
 
+	c.img = image.New(c.ImageWidth, c.imageHeight)
+
+	c.lights = lightList{}
+	if c.SampleLights {
+		c.lights = newLightList(world, c.Background)
+	}
+
 	if !c.parallel {
 		return
 	}
 
 	// If left unspecified, set concurrency to max CPU - 2 to leave headroom for the system
-	if c.workers == 0 {
+	c.workers = c.Workers
+	if c.workers <= 0 {
 		c.workers = max(runtime.NumCPU()-2, 1)
 	}
 
-	pixelCount := c.ImageWidth * c.imageHeight
-	c.numChunks = c.workers * c.workers
-
-	for c.workers > 1 {
-		if pixelCount/c.numChunks > 0 {
-			break
-		}
-
-		// Special case, our image is so small that evenly dividing it up across the number
-		// of workers would result in chunks less than 0 pixels in area. We'll reduce the
-		// workers by 20% until we reach an acceptable number of workers or 1.
-		c.workers = int(float64(c.workers) * 0.8)
-		c.numChunks = c.workers * c.workers
+	if c.TileSize <= 0 {
+		c.TileSize = defaultTileSize
 	}
-
-	c.img = image.New(c.ImageWidth, c.imageHeight)
 }
 
-func (c *Camera) calculatePixel(x, y int, world hittable.Hittabler) color.Color {
+// calculatePixel returns the sum of SamplesPerPixel samples of pixel x, y and the number of
+// rays traced to take them. Each pixel draws from its own random stream of the master seed,
+// picked by the pixel's index, so the result doesn't depend on which worker renders it or in
+// which order. Work stealing between workers relies on this.
+func (c *Camera) calculatePixel(x, y int, world hittable.Hittabler) (color.Color, uint64) {
+	rng := utility.NewRand(c.Seed, uint64(y*c.ImageWidth+x))
+
+	var rays uint64
 	col := color.New(0, 0, 0)
 	for range c.SamplesPerPixel {
-		r := c.getRay(x, y)
-		col.Add(c.rayColor(r, c.MaxDepth, world))
+		r := c.getRay(x, y, rng)
+		col.Add(c.rayColor(r, c.MaxDepth, 0, world, rng, &rays))
 	}
-	return *col.Mulf(c.pixelSampleScale)
+	return col, rays
 }
 
 // getRay construct a camera ray originating from the defocus disk and directed at a randomly
-// sampled point around the pixel location i, j
-func (c *Camera) getRay(i, j int) ray.Ray {
-	offset := c.sampleSquare()
+// sampled point around the pixel location i, j, cast at a random moment while the shutter is open
+func (c *Camera) getRay(i, j int, rng *utility.Rand) ray.Ray {
+	offset := c.sampleSquare(rng)
 	pixelSample := vec3.Add(
 		c.pixel00Loc,
 		vec3.Add(
@@ -233,95 +353,93 @@ func (c *Camera) getRay(i, j int) ray.Ray {
 	)
 	rayOrigin := c.centre
 	if c.DefocusAngle > 0 {
-		rayOrigin = c.defocusDiskSample()
+		rayOrigin = c.defocusDiskSample(rng)
 	}
 	rayDirection := vec3.Sub(pixelSample, rayOrigin)
-	return ray.New(rayOrigin, rayDirection)
+
+	// Only draw a time when the shutter is open for a while, so still images are unchanged
+	rayTime := c.ShutterOpen
+	if c.ShutterClose > c.ShutterOpen {
+		rayTime = rng.Range(c.ShutterOpen, c.ShutterClose)
+	}
+	return ray.NewWithTime(rayOrigin, rayDirection, rayTime)
 }
 
 // sampleSquare returns the vector to a random point in the [-.5,-.5]-[+.5,+.5] unit square
-func (c *Camera) sampleSquare() vec3.Vector3 {
-	return vec3.New(utility.Random()-0.5, utility.Random()-0.5, 0)
+func (c *Camera) sampleSquare(rng *utility.Rand) vec3.Vector3 {
+	return vec3.New(rng.Float64()-0.5, rng.Float64()-0.5, 0)
 }
 
 // defocusDiskSample returns a random point in the camera defocus disk
-func (c *Camera) defocusDiskSample() vec3.Vector3 {
-	p := vec3.RandomInUnitDisk()
+func (c *Camera) defocusDiskSample(rng *utility.Rand) vec3.Vector3 {
+	p := vec3.RandomInUnitDisk(rng)
 	x := vec3.Duplicate(c.centre)
 	x.Add(vec3.Mulf(c.defocusDiskU, p.X()))
 	x.Add(vec3.Mulf(c.defocusDiskV, p.Y()))
 	return x
 }
 
-const dampen = 0.5
-
-func (c *Camera) rayColor(r ray.Ray, depth int, world hittable.Hittabler) color.Color {
+// rayColor returns the light arriving along r, adding every ray it traces to rays. r was
+// scattered from the previous hit with density bsdfPDF, or 0 for camera rays and discrete
+// directions, which lights could not have been sampled along.
+func (c *Camera) rayColor(r ray.Ray, depth int, bsdfPDF float64, world hittable.Hittabler, rng *utility.Rand, rays *uint64) color.Color {
 	if depth <= 0 {
 		return color.Black
 	}
 
-	if hr, ok := world.Hit(r, interval.New(1e-3, math.Inf(1))); ok {
-		if attenuation, scattered, ok := hr.Material().Scatter(r, hr); ok {
-			return vec3.Mulv(attenuation, c.rayColor(scattered, depth-1, world))
+	*rays++
+	hr, ok := world.Hit(r, interval.New(1e-3, math.Inf(1)))
+	if !ok {
+		bg := c.Background.Value(r)
+		if bsdfPDF > 0 && c.lights.background != nil {
+			bg.Mulf(powerHeuristic(bsdfPDF, c.lights.pdf(r, math.Inf(1))))
 		}
-		return color.Black
+		return bg
 	}
 
-	unitDirection := vec3.UnitVector(r.Direction())
-	a := dampen * (unitDirection.Y() + 1)
-	blue := color.New(0.5, 0.7, 1)
-
-	return vec3.Add(
-		vec3.Mulf(color.White, (1.0-a)),
-		vec3.Mulf(blue, a),
-	)
-}
-
-// queueChunks sends all the chunks to be computed to the ch channel
-func (c *Camera) queueChunks(ch chan<- image.Chunk) {
-	chunkDeltaU := c.ImageWidth / c.workers
-	chunkDeltaV := c.imageHeight / c.workers
-
-	startY := 0
+	emitted := color.Black
+	if e, ok := hr.Material().(hitrecord.Emitter); ok {
+		emitted = e.Emitted(r, hr)
 
-	for j := range c.workers {
-		endY := startY + chunkDeltaV
-		if j == c.workers-1 {
-			endY = c.imageHeight
+		// The previous hit may also have reached this light with a shadow ray, the two
+		// estimates are weighted so that together they count it once
+		if bsdfPDF > 0 && c.lights.len() > 0 {
+			emitted.Mulf(powerHeuristic(bsdfPDF, c.lights.pdf(r, hr.T())))
 		}
+	}
 
-		startX := 0
-
-		for i := range c.workers {
-			endX := startX + chunkDeltaU
-			if i == c.workers-1 {
-				endX = c.ImageWidth
-			}
+	attenuation, scattered, ok := hr.Material().Scatter(r, hr, rng)
+	if !ok {
+		return emitted
+	}
 
-			ch <- image.NewChunk(
-				image.NewPixelCoord(startX, startY),
-				image.NewPixelCoord(endX, endY),
-			)
+	_, pdf := hr.Material().Evaluate(r, hr, scattered.Direction())
 
-			startX = endX
-		}
-		startY = endY
+	// Shadow rays are only cast where the bounce could still reach a light, so the path
+	// lengths counted are the same with or without light sampling
+	if c.lights.len() > 0 && depth > 1 {
+		emitted.Add(c.directLight(r, hr, world, rng, rays))
 	}
+
+	return vec3.Add(emitted, vec3.Mulv(attenuation, c.rayColor(scattered, depth-1, pdf, world, rng, rays)))
 }
 
 // processChunk calculates all the pixel colours for the given chunk and writes them to our
-// camera's img
-func (c *Camera) processChunk(chunk image.Chunk, world hittable.Hittabler) {
-	for x := chunk.Start().X(); x < chunk.End().X(); x++ {
-		for y := chunk.Start().Y(); y < chunk.End().Y(); y++ {
-			col := c.calculatePixel(x, y, world)
-			if err := c.img.Add(image.NewPixelCoord(x, y), col); err != nil {
-				panic(err)
+// camera's img, returning the number of rays traced. It returns early, leaving the chunk
+// incomplete, if ctx is cancelled or a pixel can't be written.
+func (c *Camera) processChunk(ctx context.Context, chunk image.Chunk, world hittable.Hittabler) (uint64, error) {
+	var rays uint64
+	for y := chunk.Start().Y(); y < chunk.End().Y(); y++ {
+		if ctx.Err() != nil {
+			return rays, nil
+		}
+		for x := chunk.Start().X(); x < chunk.End().X(); x++ {
+			sum, n := c.calculatePixel(x, y, world)
+			if err := c.img.Accumulate(image.NewPixelCoord(x, y), sum, c.SamplesPerPixel); err != nil {
+				return rays, err
 			}
+			rays += n
 		}
 	}
-}
-
-func (c *Camera) statusf(format string, a ...any) {
-	fmt.Fprintf(os.Stderr, format, a...)
+	return rays, nil
 }
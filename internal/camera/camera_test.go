@@ -0,0 +1,242 @@
+package camera_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/sendelivery/go-trace-rays/internal/background"
+	"github.com/sendelivery/go-trace-rays/internal/camera"
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/hdr"
+	"github.com/sendelivery/go-trace-rays/internal/image"
+	"github.com/sendelivery/go-trace-rays/internal/object/hittable"
+	"github.com/sendelivery/go-trace-rays/internal/object/material"
+	"github.com/sendelivery/go-trace-rays/internal/object/planar"
+	"github.com/sendelivery/go-trace-rays/internal/object/sphere"
+	"github.com/sendelivery/go-trace-rays/internal/scenes"
+	"github.com/sendelivery/go-trace-rays/internal/scheduler"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+func newCamera(seed uint64) *camera.Camera {
+	cam := camera.New()
+	cam.ImageWidth = 24
+	cam.AspectRatio = 1.5
+	cam.SamplesPerPixel = 4
+	cam.MaxDepth = 8
+	cam.LookFrom = vec3.New(13, 2, 3)
+	cam.LookAt = vec3.New(0, 0, 0)
+	cam.VerticalFov = 20
+	cam.DefocusAngle = 0.6
+	cam.Seed = seed
+	return cam
+}
+
+// The same seed must give a bit-identical image whichever workflow renders it
+func TestDeterministic(t *testing.T) {
+	world := scenes.NewSimple()
+
+	serial, err := newCamera(7).Render(context.Background(), world)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parallel, err := newCamera(7).RenderParallel(context.Background(), world)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	other, err := newCamera(8).Render(context.Background(), world)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	differs := false
+	for y := range serial.Height() {
+		for x := range serial.Width() {
+			pc := image.NewPixelCoord(x, y)
+			s, _ := serial.Get(pc)
+			p, _ := parallel.Get(pc)
+			o, _ := other.Get(pc)
+
+			if !vec3.Equal(s, p) {
+				t.Fatalf("pixel %d, %d differs between workflows, got=%q. want=%q.", x, y, &p, &s)
+			}
+			differs = differs || !vec3.Equal(s, o)
+		}
+	}
+
+	if !differs {
+		t.Error("expected a different seed to give a different image")
+	}
+}
+
+func TestCancelled(t *testing.T) {
+	world := scenes.NewSimple()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for name, render := range map[string]func(*camera.Camera) (*image.Image, error){
+		"serial": func(c *camera.Camera) (*image.Image, error) { return c.Render(ctx, world) },
+		"parallel": func(c *camera.Camera) (*image.Image, error) {
+			c.TileOrder = scheduler.Hilbert
+			return c.RenderParallel(ctx, world)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			img, err := render(newCamera(1))
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("unexpected error, got=%v. want=%v.", err, context.Canceled)
+			}
+			if img == nil || img.Width() != 24 {
+				t.Fatal("expected the partial image to be returned")
+			}
+			if _, ok := img.Get(image.NewPixelCoord(0, 0)); ok {
+				t.Error("expected no pixels to have been rendered")
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		modify func(c *camera.Camera)
+		want   string
+	}{
+		"default":              {modify: func(c *camera.Camera) {}},
+		"negative samples":     {modify: func(c *camera.Camera) { c.SamplesPerPixel = -1 }, want: "samples per pixel"},
+		"zero aspect ratio":    {modify: func(c *camera.Camera) { c.AspectRatio = 0 }, want: "aspect ratio"},
+		"zero width":           {modify: func(c *camera.Camera) { c.ImageWidth = 0 }, want: "image width"},
+		"zero depth":           {modify: func(c *camera.Camera) { c.MaxDepth = 0 }, want: "max depth"},
+		"fov too wide":         {modify: func(c *camera.Camera) { c.VerticalFov = 180 }, want: "field of view"},
+		"look at look from":    {modify: func(c *camera.Camera) { c.LookAt = c.LookFrom }, want: "different points"},
+		"parallel up":          {modify: func(c *camera.Camera) { c.VUp = vec3.New(0, 0, 2) }, want: "up vector"},
+		"negative defocus":     {modify: func(c *camera.Camera) { c.DefocusAngle = -1 }, want: "defocus angle"},
+		"zero focus distance":  {modify: func(c *camera.Camera) { c.FocusDistance = 0 }, want: "focus distance"},
+		"shutter closes early": {modify: func(c *camera.Camera) { c.ShutterOpen = 1 }, want: "shutter"},
+		"negative workers":     {modify: func(c *camera.Camera) { c.Workers = -2 }, want: "workers"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cam := camera.New()
+			tt.modify(cam)
+			err := cam.Validate()
+
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("unexpected error, got=%v. want it to contain %q.", err, tt.want)
+			}
+		})
+	}
+}
+
+// Sampling lights directly must leave the image as bright as bouncing alone, only less noisy
+func TestSampleLights(t *testing.T) {
+	t.Parallel()
+
+	// A dim sky with a bright sun, lighting a ball on the ground
+	sky := hdr.New(16, 8)
+	for i := range sky.Pix {
+		sky.Pix[i] = color.New(0.1, 0.1, 0.2)
+	}
+	sky.Set(5, 2, color.New(1000, 900, 800))
+	var outdoors hittable.HittableList
+	outdoors.Add(
+		planar.NewPlane(vec3.New(0, 0, 0), vec3.New(0, 1, 0), material.NewLambertian(color.New(0.5, 0.5, 0.5))),
+		sphere.New(vec3.New(0, 1, 0), 1, material.NewLambertian(color.New(0.7, 0.3, 0.3))),
+	)
+	cornell, err := scenes.NewCornellBox()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := map[string]struct {
+		world hittable.Hittabler
+		setup func(cam *camera.Camera)
+	}{
+		"area light": {
+			world: cornell,
+			setup: func(cam *camera.Camera) {
+				cam.Background = background.NewConstant(color.Black)
+				cam.LookFrom = vec3.New(278, 278, -800)
+				cam.LookAt = vec3.New(278, 278, 0)
+				cam.VerticalFov = 40
+			},
+		},
+		"environment": {
+			world: outdoors,
+			setup: func(cam *camera.Camera) {
+				// Dimmed so that sunlit surfaces stay below the brightness of a visible light
+				cam.Background = background.NewEnvironment(sky, 0, 0.05)
+				cam.LookFrom = vec3.New(0, 2, 6)
+				cam.LookAt = vec3.New(0, 0.8, 0)
+				cam.VerticalFov = 40
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			render := func(sampleLights bool, seed uint64) *image.Image {
+				cam := camera.New()
+				cam.ImageWidth = 16
+				cam.SamplesPerPixel = 256
+				cam.MaxDepth = 4
+				cam.SampleLights = sampleLights
+				cam.Seed = seed
+				tt.setup(cam)
+
+				img, err := cam.RenderParallel(context.Background(), tt.world)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return img
+			}
+
+			// measure returns the mean brightness of two renders, and the mean squared
+			// difference between them as a measure of noise
+			measure := func(sampleLights bool) (mean, noise float64) {
+				a, b := render(sampleLights, 1), render(sampleLights, 2)
+				n := float64(a.Width() * a.Height())
+				for y := range a.Height() {
+					for x := range a.Width() {
+						pa, _ := a.Get(image.NewPixelCoord(x, y))
+						pb, _ := b.Get(image.NewPixelCoord(x, y))
+						la, lb := (pa.X()+pa.Y()+pa.Z())/3, (pb.X()+pb.Y()+pb.Z())/3
+
+						// Pixels on the edge of a visible light are noisy either way
+						if la > 1 && lb > 1 {
+							continue
+						}
+						mean += (la + lb) / (2 * n)
+						noise += (la - lb) * (la - lb) / n
+					}
+				}
+				return mean, noise
+			}
+
+			bounced, bouncedNoise := measure(false)
+			sampled, sampledNoise := measure(true)
+
+			if math.Abs(sampled-bounced) > 0.05*bounced {
+				t.Errorf("unexpected mean brightness, got=%v. want=%v.", sampled, bounced)
+			}
+			if sampledNoise > bouncedNoise/10 {
+				t.Errorf("expected light sampling to cut the noise tenfold, got=%v. want less than %v.", sampledNoise, bouncedNoise/10)
+			}
+		})
+	}
+}
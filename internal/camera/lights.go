@@ -0,0 +1,106 @@
+package camera
+
+import (
+	"math"
+
+	"github.com/sendelivery/go-trace-rays/internal/background"
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/interval"
+	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
+	"github.com/sendelivery/go-trace-rays/internal/object/hittable"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// lightList is the set of lights aimed at directly from each hit, the background counting as
+// one light if it can be sampled. One is picked at random with equal probability for every
+// shadow ray.
+type lightList struct {
+	shapes     []hittable.Sampler
+	background background.Sampler // nil unless the background is sampled
+}
+
+func newLightList(world hittable.Hittabler, bg background.Backgrounder) lightList {
+	l := lightList{shapes: hittable.Lights(world)}
+	l.background, _ = bg.(background.Sampler)
+	return l
+}
+
+// len returns the number of lights to choose from
+func (l lightList) len() int {
+	if l.background != nil {
+		return len(l.shapes) + 1
+	}
+	return len(l.shapes)
+}
+
+// sample returns the direction from origin towards a random point on a random light
+func (l lightList) sample(origin vec3.Vector3, time float64, rng *utility.Rand) vec3.Vector3 {
+	i := rng.IntN(l.len())
+	if i == len(l.shapes) {
+		return l.background.Sample(rng)
+	}
+	return l.shapes[i].Sample(origin, time, rng)
+}
+
+// pdf returns the probability density of sample choosing r's direction, given that r first
+// hits something at t, or escapes to the background if t is infinite. Only the lights hit
+// exactly there count, so lights hidden behind it, and emitters that aren't in the list, have
+// no effect on the density.
+func (l lightList) pdf(r ray.Ray, t float64) float64 {
+	if math.IsInf(t, 1) {
+		if l.background == nil {
+			return 0
+		}
+		return l.background.PDF(r.Direction()) / float64(l.len())
+	}
+
+	eps := 1e-9 * max(1, t)
+	rt := interval.New(t-eps, t+eps)
+
+	sum := 0.0
+	for _, light := range l.shapes {
+		sum += light.PDF(r, rt)
+	}
+	return sum / float64(l.len())
+}
+
+// powerHeuristic returns the weight of a sample drawn with density pdf when the same
+// direction could also have been drawn with density other
+func powerHeuristic(pdf, other float64) float64 {
+	return pdf * pdf / (pdf*pdf + other*other)
+}
+
+// directLight returns the light arriving at hr along a shadow ray aimed at a random light and
+// scattered back along r, weighted against the chance of the material having scattered the
+// same way. rays counts the shadow ray.
+func (c *Camera) directLight(r ray.Ray, hr hitrecord.HitRecord, world hittable.Hittabler, rng *utility.Rand, rays *uint64) color.Color {
+	dir := c.lights.sample(hr.Point(), r.Time(), rng)
+	f, bsdfPDF := hr.Material().Evaluate(r, hr, dir)
+	if bsdfPDF <= 0 {
+		return color.Black
+	}
+
+	*rays++
+	shadow := ray.NewWithTime(hr.Point(), dir, r.Time())
+	var emitted color.Color
+	lightPDF := 0.0
+	if lit, ok := world.Hit(shadow, interval.New(1e-3, math.Inf(1))); ok {
+		e, ok := lit.Material().(hitrecord.Emitter)
+		if !ok {
+			return color.Black
+		}
+		emitted = e.Emitted(shadow, lit)
+		lightPDF = c.lights.pdf(shadow, lit.T())
+	} else {
+		emitted = c.Background.Value(shadow)
+		lightPDF = c.lights.pdf(shadow, math.Inf(1))
+	}
+	if lightPDF <= 0 {
+		return color.Black
+	}
+
+	weight := powerHeuristic(lightPDF, bsdfPDF) / lightPDF
+	return vec3.Mulf(vec3.Mulv(emitted, f), weight)
+}
@@ -1,11 +1,6 @@
 package color
 
 import (
-	"fmt"
-	"io"
-	"math"
-
-	"github.com/sendelivery/go-trace-rays/internal/interval"
 	"github.com/sendelivery/go-trace-rays/internal/utility"
 	"github.com/sendelivery/go-trace-rays/internal/vec3"
 )
@@ -16,33 +11,8 @@ func New(r, g, b float64) Color {
 	return vec3.New(r, g, b)
 }
 
-func NewRandom(min, max float64) Color {
-	return vec3.New(
-		utility.RandomN(min, max),
-		utility.RandomN(min, max),
-		utility.RandomN(min, max),
-	)
-}
-
-func WriteColor(w io.Writer, pixelColor Color) {
-	r := linearToGamma(pixelColor.X())
-	g := linearToGamma(pixelColor.Y())
-	b := linearToGamma(pixelColor.Z())
-
-	// Translate [0,1] component values to the byte range [0,255]
-	intensity := interval.New(0, 0.999)
-	rByte := int(256 * intensity.Clamp(r))
-	gByte := int(256 * intensity.Clamp(g))
-	bByte := int(256 * intensity.Clamp(b))
-
-	fmt.Fprintf(w, "%d %d %d\n", rByte, gByte, bByte)
-}
-
-func linearToGamma(lc float64) float64 {
-	if lc <= 0 {
-		return 0
-	}
-	return math.Sqrt(lc)
+func NewRandom(rng *utility.Rand, min, max float64) Color {
+	return vec3.NewRandomN(rng, min, max)
 }
 
 var Black = vec3.New(0, 0, 0)
@@ -0,0 +1,166 @@
+package color
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/sendelivery/go-trace-rays/internal/interval"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// ToneMapper compresses linear scene radiance, which may be arbitrarily bright, into the
+// displayable range [0,1].
+type ToneMapper interface {
+	ToneMap(c Color) Color
+}
+
+// Clamp leaves colours untouched, anything brighter than 1 is clipped when quantized.
+type Clamp struct{}
+
+func (Clamp) ToneMap(c Color) Color {
+	return c
+}
+
+// Reinhard applies the simple Reinhard operator L/(1+L) to the luminance of the colour,
+// preserving its hue. Highlights approach but never reach white.
+type Reinhard struct{}
+
+func (Reinhard) ToneMap(c Color) Color {
+	return scaleLuminance(c, func(l float64) float64 { return l / (1 + l) })
+}
+
+// ExtendedReinhard is the Reinhard operator with a white point, the luminance that maps to
+// pure white. Anything brighter than WhitePoint burns out.
+type ExtendedReinhard struct {
+	WhitePoint float64
+}
+
+func (er ExtendedReinhard) ToneMap(c Color) Color {
+	w2 := er.WhitePoint * er.WhitePoint
+	return scaleLuminance(c, func(l float64) float64 { return l * (1 + l/w2) / (1 + l) })
+}
+
+// ACES applies Krzysztof Narkowicz's curve fit of the ACES filmic reference rendering
+// transform to each channel.
+type ACES struct{}
+
+func (ACES) ToneMap(c Color) Color {
+	const a, b, cc, d, e = 2.51, 0.03, 2.43, 0.59, 0.14
+	f := func(x float64) float64 {
+		x = max(x, 0)
+		return x * (a*x + b) / (x*(cc*x+d) + e)
+	}
+	return New(f(c.X()), f(c.Y()), f(c.Z()))
+}
+
+// Hable applies John Hable's filmic curve from Uncharted 2 to each channel, normalised so
+// that WhitePoint maps to white. The curve doubles its input first, as in the original, so
+// the original's white point of 11.2 is a WhitePoint of 5.6 here.
+type Hable struct {
+	WhitePoint float64
+}
+
+func (h Hable) ToneMap(c Color) Color {
+	const exposureBias = 2.0
+	whiteScale := 1 / hableCurve(h.WhitePoint*exposureBias)
+	f := func(x float64) float64 {
+		return hableCurve(max(x, 0)*exposureBias) * whiteScale
+	}
+	return New(f(c.X()), f(c.Y()), f(c.Z()))
+}
+
+func hableCurve(x float64) float64 {
+	const a, b, c, d, e, f = 0.15, 0.50, 0.10, 0.20, 0.02, 0.30
+	return (x*(a*x+c*b)+d*e)/(x*(a*x+b)+d*f) - e/f
+}
+
+// Luminance returns the relative luminance of a linear Rec. 709 colour
+func Luminance(c Color) float64 {
+	return 0.2126*c.X() + 0.7152*c.Y() + 0.0722*c.Z()
+}
+
+// scaleLuminance scales c so that its luminance becomes fn(luminance)
+func scaleLuminance(c Color, fn func(l float64) float64) Color {
+	l := Luminance(c)
+	if l <= 0 {
+		return Black
+	}
+	return vec3.Mulf(c, fn(l)/l)
+}
+
+// toneMappers maps tone mapping operator names to their default configuration
+var toneMappers = map[string]ToneMapper{
+	"clamp":    Clamp{},
+	"reinhard": Reinhard{},
+	"extended": ExtendedReinhard{WhitePoint: 4},
+	"aces":     ACES{},
+	"hable":    Hable{WhitePoint: 5.6},
+}
+
+// ToneMappers returns the names accepted by ToneMapperByName, sorted.
+func ToneMappers() []string {
+	names := make([]string, 0, len(toneMappers))
+	for name := range toneMappers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ToneMapperByName returns the named tone mapping operator, one of those listed by ToneMappers.
+func ToneMapperByName(name string) (ToneMapper, error) {
+	tm, ok := toneMappers[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown tone mapper %q, expected one of %s", name, strings.Join(ToneMappers(), ", "))
+	}
+	return tm, nil
+}
+
+// Display describes how linear radiance is turned into display values: the colour is first
+// scaled by the exposure, then tone mapped, and finally encoded with the sRGB transfer
+// function. The zero value applies no exposure change and clamps.
+type Display struct {
+	Exposure float64    // Exposure adjustment in stops, each stop doubles the brightness
+	ToneMap  ToneMapper // Tone mapping operator, Clamp is used if nil
+}
+
+// Apply returns c ready for display, each channel within [0,1].
+func (d Display) Apply(c Color) Color {
+	if d.Exposure != 0 {
+		c = vec3.Mulf(c, math.Exp2(d.Exposure))
+	}
+	if d.ToneMap != nil {
+		c = d.ToneMap.ToneMap(c)
+	}
+
+	unit := interval.New(0, 1)
+	return New(
+		unit.Clamp(linearToSRGB(c.X())),
+		unit.Clamp(linearToSRGB(c.Y())),
+		unit.Clamp(linearToSRGB(c.Z())),
+	)
+}
+
+// Encode8 translates a linear colour to display values in the byte range [0,255]
+func (d Display) Encode8(c Color) (r, g, b uint8) {
+	c = d.Apply(c)
+	q := func(v float64) uint8 { return uint8(math.Round(255 * v)) }
+	return q(c.X()), q(c.Y()), q(c.Z())
+}
+
+// Encode16 translates a linear colour to display values in the range [0,65535]
+func (d Display) Encode16(c Color) (r, g, b uint16) {
+	c = d.Apply(c)
+	q := func(v float64) uint16 { return uint16(math.Round(65535 * v)) }
+	return q(c.X()), q(c.Y()), q(c.Z())
+}
+
+// linearToSRGB applies the piecewise sRGB transfer function
+func linearToSRGB(lc float64) float64 {
+	if lc <= 0.0031308 {
+		return 12.92 * lc
+	}
+	return 1.055*math.Pow(lc, 1/2.4) - 0.055
+}
@@ -0,0 +1,87 @@
+package color_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sendelivery/go-trace-rays/internal/color"
+)
+
+func TestDisplayApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		display  color.Display
+		in       float64
+		expected float64
+	}{
+		{name: "black", display: color.Display{}, in: 0, expected: 0},
+		{name: "white", display: color.Display{}, in: 1, expected: 1},
+		{name: "clamped", display: color.Display{}, in: 5, expected: 1},
+		{name: "srgb linear segment", display: color.Display{}, in: 0.002, expected: 0.02584},
+		{name: "srgb mid grey", display: color.Display{}, in: 0.18, expected: 0.46135},
+		{name: "exposure", display: color.Display{Exposure: 1}, in: 0.09, expected: 0.46135},
+		{name: "reinhard", display: color.Display{ToneMap: color.Reinhard{}}, in: 1, expected: 0.73536},
+		{
+			name:     "extended reinhard white point",
+			display:  color.Display{ToneMap: color.ExtendedReinhard{WhitePoint: 4}},
+			in:       4,
+			expected: 1,
+		},
+		{
+			name:     "hable white point",
+			display:  color.Display{ToneMap: color.Hable{WhitePoint: 5.6}},
+			in:       5.6,
+			expected: 1,
+		},
+		{name: "aces", display: color.Display{ToneMap: color.ACES{}}, in: 100, expected: 1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tc.display.Apply(color.New(tc.in, tc.in, tc.in))
+			if math.Abs(got.X()-tc.expected) > 1e-4 {
+				t.Errorf("unexpected result, got=%f. want=%f.", got.X(), tc.expected)
+			}
+		})
+	}
+}
+
+func TestToneMapperByName(t *testing.T) {
+	for _, name := range color.ToneMappers() {
+		if _, err := color.ToneMapperByName(name); err != nil {
+			t.Errorf("unexpected error for %s: %v", name, err)
+		}
+	}
+	if _, err := color.ToneMapperByName("unknown"); err == nil {
+		t.Error("expected an error for an unknown tone mapper")
+	}
+}
+
+// Every operator with a white point maps it to exactly 1, before the display encoding
+func TestWhitePoint(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		tm    color.ToneMapper
+		white float64
+	}{
+		"extended reinhard": {tm: color.ExtendedReinhard{WhitePoint: 4}, white: 4},
+		"hable":             {tm: color.Hable{WhitePoint: 11.2}, white: 11.2},
+		"hable dim":         {tm: color.Hable{WhitePoint: 1.5}, white: 1.5},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tt.tm.ToneMap(color.New(tt.white, tt.white, tt.white))
+			for _, c := range []float64{got.X(), got.Y(), got.Z()} {
+				if math.Abs(c-1) > 1e-9 {
+					t.Errorf("unexpected white, got=%v. want=%v.", c, 1.0)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package encode
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/image"
+)
+
+// Encoder writes a rendered image to w in a particular file format.
+type Encoder interface {
+	Encode(w io.Writer, img *image.Image) error
+}
+
+// encoders maps format names to a function returning their Encoder. Low dynamic range
+// formats convert colours for display with d, high dynamic range formats store linear
+// radiance and ignore it.
+var encoders = map[string]func(d color.Display) Encoder{
+	"png":     func(d color.Display) Encoder { return PNG{Display: d} },
+	"png16":   func(d color.Display) Encoder { return PNG{Depth16: true, Display: d} },
+	"ppm":     func(d color.Display) Encoder { return PPM{Display: d} },
+	"p3":      func(d color.Display) Encoder { return PPM{ASCII: true, Display: d} },
+	"hdr":     func(color.Display) Encoder { return HDR{} },
+	"pfm":     func(color.Display) Encoder { return PFM{} },
+	"exr":     func(color.Display) Encoder { return EXR{Compression: EXRZIPCompression} },
+	"exr-raw": func(color.Display) Encoder { return EXR{Compression: EXRNoCompression} },
+}
+
+// extensions maps file extensions to the default format name for them
+var extensions = map[string]string{
+	".png": "png",
+	".ppm": "ppm",
+	".hdr": "hdr",
+	".pfm": "pfm",
+	".exr": "exr",
+}
+
+// Formats returns the names accepted by ByName, sorted.
+func Formats() []string {
+	names := make([]string, 0, len(encoders))
+	for name := range encoders {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ByName returns the Encoder for the named format, one of those listed by Formats. Low
+// dynamic range formats prepare colours for display with d.
+func ByName(name string, d color.Display) (Encoder, error) {
+	newEncoder, ok := encoders[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown image format %q, expected one of %s", name, strings.Join(Formats(), ", "))
+	}
+	return newEncoder(d), nil
+}
+
+// ForPath returns the Encoder matching the file extension of path. Low dynamic range formats
+// prepare colours for display with d.
+func ForPath(path string, d color.Display) (Encoder, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	name, ok := extensions[ext]
+	if !ok {
+		return nil, fmt.Errorf("cannot infer image format from extension %q", ext)
+	}
+	return ByName(name, d)
+}
+
+// forEachPixel calls fn with every pixel of img in scanline order, stopping at the first
+// missing pixel.
+func forEachPixel(img *image.Image, fn func(x, y int, col color.Color)) error {
+	for y := range img.Height() {
+		for x := range img.Width() {
+			col, ok := img.Get(image.NewPixelCoord(x, y))
+			if !ok {
+				return fmt.Errorf("missing pixel at coord %d, %d", x, y)
+			}
+			fn(x, y, col)
+		}
+	}
+	return nil
+}
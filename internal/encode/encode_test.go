@@ -0,0 +1,239 @@
+package encode_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"image/png"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/encode"
+	"github.com/sendelivery/go-trace-rays/internal/image"
+)
+
+// newImage returns a 2x1 image with a white pixel followed by a black one
+func newImage(t *testing.T) *image.Image {
+	t.Helper()
+
+	img := image.New(2, 1)
+	if err := img.Add(image.NewPixelCoord(0, 0), color.White); err != nil {
+		t.Fatal(err)
+	}
+	if err := img.Add(image.NewPixelCoord(1, 0), color.Black); err != nil {
+		t.Fatal(err)
+	}
+	return &img
+}
+
+func TestPPM(t *testing.T) {
+	tests := []struct {
+		name     string
+		enc      encode.PPM
+		expected []byte
+	}{
+		{
+			name:     "binary",
+			enc:      encode.PPM{},
+			expected: append([]byte("P6\n2 1\n255\n"), 255, 255, 255, 0, 0, 0),
+		},
+		{
+			name:     "ascii",
+			enc:      encode.PPM{ASCII: true},
+			expected: []byte("P3\n2 1\n255\n255 255 255\n0 0 0\n"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			if err := tc.enc.Encode(&buf, newImage(t)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tc.expected, buf.Bytes()); diff != "" {
+				t.Errorf("%s", diff)
+			}
+		})
+	}
+}
+
+func TestPNG(t *testing.T) {
+	for _, depth16 := range []bool{false, true} {
+		var buf bytes.Buffer
+		if err := (encode.PNG{Depth16: depth16}).Encode(&buf, newImage(t)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		decoded, err := png.Decode(&buf)
+		if err != nil {
+			t.Fatalf("unexpected decode error: %v", err)
+		}
+
+		if r, _, _, _ := decoded.At(0, 0).RGBA(); r != 0xffff {
+			t.Errorf("unexpected red in white pixel, got=%d. want=%d.", r, 0xffff)
+		}
+		if r, _, _, _ := decoded.At(1, 0).RGBA(); r != 0 {
+			t.Errorf("unexpected red in black pixel, got=%d. want=0.", r)
+		}
+	}
+}
+
+func TestForPath(t *testing.T) {
+	if _, err := encode.ForPath("out.PNG", color.Display{}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := encode.ForPath("out.bmp", color.Display{}); err == nil {
+		t.Error("expected an error for an unsupported extension")
+	}
+}
+
+func TestMissingPixel(t *testing.T) {
+	img := image.New(1, 1)
+	if err := (encode.PPM{}).Encode(&bytes.Buffer{}, &img); err == nil {
+		t.Error("expected an error for an incomplete image")
+	}
+}
+
+func TestHDR(t *testing.T) {
+	img := image.New(1, 1)
+	if err := img.Add(image.NewPixelCoord(0, 0), color.New(1, 0.5, 0.25)); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := (encode.HDR{}).Encode(&buf, &img); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := append([]byte("#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y 1 +X 1\n"), 128, 64, 32, 129)
+	if diff := cmp.Diff(expected, buf.Bytes()); diff != "" {
+		t.Errorf("%s", diff)
+	}
+}
+
+func TestPFM(t *testing.T) {
+	img := image.New(1, 2)
+	if err := img.Add(image.NewPixelCoord(0, 0), color.New(2, 0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := img.Add(image.NewPixelCoord(0, 1), color.New(0, 0, 4)); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := (encode.PFM{}).Encode(&buf, &img); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	header := []byte("PF\n1 2\n-1.0\n")
+	data := buf.Bytes()[len(header):]
+
+	// The bottom row comes first
+	want := []float32{0, 0, 4, 2, 0, 0}
+	for i, w := range want {
+		got := math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+		if got != w {
+			t.Errorf("unexpected value at %d, got=%f. want=%f.", i, got, w)
+		}
+	}
+}
+
+// Decode the pixel data back out of an EXR file and check it survives the round trip
+func TestWriteEXR(t *testing.T) {
+	const width, height = 3, 20
+
+	channels := []encode.EXRChannel{
+		{Name: "R", Data: make([]float32, width*height)},
+		{Name: "A", Data: make([]float32, width*height)},
+	}
+	for i := range width * height {
+		channels[0].Data[i] = float32(i) * 0.5
+		channels[1].Data[i] = 1
+	}
+
+	for _, compression := range []encode.EXRCompression{
+		encode.EXRNoCompression, encode.EXRZIPSCompression, encode.EXRZIPCompression,
+	} {
+		var buf bytes.Buffer
+		if err := encode.WriteEXR(&buf, width, height, channels, compression); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		file := buf.Bytes()
+
+		if magic := binary.LittleEndian.Uint32(file); magic != 20000630 {
+			t.Fatalf("unexpected magic number, got=%d.", magic)
+		}
+
+		lines := 1
+		if compression == encode.EXRZIPCompression {
+			lines = 16
+		}
+
+		// The final attribute is 32 bytes long and followed by the null ending the header
+		end := bytes.Index(file, []byte("screenWindowWidth\x00float\x00")) + 33
+		numChunks := (height + lines - 1) / lines
+
+		for c := range numChunks {
+			offset := binary.LittleEndian.Uint64(file[end+8*c:])
+			y0 := int(binary.LittleEndian.Uint32(file[offset:]))
+			size := int(binary.LittleEndian.Uint32(file[offset+4:]))
+			data := file[int(offset)+8 : int(offset)+8+size]
+
+			rows := min(lines, height-y0)
+			rawSize := rows * width * 2 * 4
+			if size < rawSize {
+				data = unzipEXR(t, data, rawSize)
+			}
+
+			for row := range rows {
+				y := y0 + row
+				line := data[row*width*8:]
+				for x := range width {
+					// Channels are sorted, so A comes before R
+					a := math.Float32frombits(binary.LittleEndian.Uint32(line[x*4:]))
+					r := math.Float32frombits(binary.LittleEndian.Uint32(line[width*4+x*4:]))
+					if a != 1 || r != channels[0].Data[y*width+x] {
+						t.Fatalf("unexpected pixel %d, %d with compression %d, got=%f,%f.", x, y, compression, a, r)
+					}
+				}
+			}
+		}
+	}
+}
+
+func unzipEXR(t *testing.T, data []byte, rawSize int) []byte {
+	t.Helper()
+
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmp, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tmp) != rawSize {
+		t.Fatalf("unexpected decompressed size, got=%d. want=%d.", len(tmp), rawSize)
+	}
+
+	for i := 1; i < len(tmp); i++ {
+		tmp[i] = byte(int(tmp[i-1]) + int(tmp[i]) - 128)
+	}
+
+	raw := make([]byte, len(tmp))
+	half := (len(tmp) + 1) / 2
+	for i := range raw {
+		if i%2 == 0 {
+			raw[i] = tmp[i/2]
+		} else {
+			raw[i] = tmp[half+i/2]
+		}
+	}
+	return raw
+}
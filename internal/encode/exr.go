@@ -0,0 +1,219 @@
+package encode
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/sendelivery/go-trace-rays/internal/image"
+)
+
+// EXRCompression selects how the scanline blocks of an OpenEXR file are stored
+type EXRCompression uint8
+
+// The values match the compression attribute values defined by the OpenEXR file format
+const (
+	EXRNoCompression   EXRCompression = 0
+	EXRZIPSCompression EXRCompression = 2 // zlib, one scanline per block
+	EXRZIPCompression  EXRCompression = 3 // zlib, 16 scanlines per block
+)
+
+// linesPerBlock returns how many scanlines share a chunk for the compression method
+func (c EXRCompression) linesPerBlock() int {
+	if c == EXRZIPCompression {
+		return 16
+	}
+	return 1
+}
+
+// EXRChannel is one named channel of 32 bit float samples, stored in scanline order.
+type EXRChannel struct {
+	Name string
+	Data []float32
+}
+
+// EXR writes linear RGB OpenEXR images with 32 bit float channels.
+type EXR struct {
+	Compression EXRCompression
+}
+
+func (e EXR) Encode(w io.Writer, img *image.Image) error {
+	n := img.Width() * img.Height()
+	r := EXRChannel{Name: "R", Data: make([]float32, n)}
+	g := EXRChannel{Name: "G", Data: make([]float32, n)}
+	b := EXRChannel{Name: "B", Data: make([]float32, n)}
+
+	for y := range img.Height() {
+		for x := range img.Width() {
+			col, ok := img.Get(image.NewPixelCoord(x, y))
+			if !ok {
+				return fmt.Errorf("missing pixel at coord %d, %d", x, y)
+			}
+			i := y*img.Width() + x
+			r.Data[i] = float32(col.X())
+			g.Data[i] = float32(col.Y())
+			b.Data[i] = float32(col.Z())
+		}
+	}
+
+	return WriteEXR(w, img.Width(), img.Height(), []EXRChannel{r, g, b}, e.Compression)
+}
+
+// WriteEXR writes a single part, scanline OpenEXR file containing any number of channels,
+// for example extra render passes alongside R, G and B. Every channel must hold exactly
+// width*height samples.
+func WriteEXR(w io.Writer, width, height int, channels []EXRChannel, compression EXRCompression) error {
+	switch compression {
+	case EXRNoCompression, EXRZIPSCompression, EXRZIPCompression:
+	default:
+		return fmt.Errorf("unsupported EXR compression %d", compression)
+	}
+	if len(channels) == 0 {
+		return fmt.Errorf("an EXR file needs at least one channel")
+	}
+	for _, ch := range channels {
+		if len(ch.Data) != width*height {
+			return fmt.Errorf("channel %q has %d samples, want %d", ch.Name, len(ch.Data), width*height)
+		}
+	}
+
+	// Readers expect channels in alphabetical order, both in the header and the pixel data
+	channels = slices.Clone(channels)
+	slices.SortFunc(channels, func(a, b EXRChannel) int { return strings.Compare(a.Name, b.Name) })
+
+	header := exrHeader(width, height, channels, compression)
+
+	lines := compression.linesPerBlock()
+	numChunks := (height + lines - 1) / lines
+
+	chunks := make([][]byte, numChunks)
+	for i := range chunks {
+		y0 := i * lines
+		y1 := min(y0+lines, height)
+
+		raw := make([]byte, 0, (y1-y0)*width*len(channels)*4)
+		for y := y0; y < y1; y++ {
+			for _, ch := range channels {
+				for _, f := range ch.Data[y*width : (y+1)*width] {
+					raw = binary.LittleEndian.AppendUint32(raw, math.Float32bits(f))
+				}
+			}
+		}
+
+		data := raw
+		if compression != EXRNoCompression {
+			var err error
+			if data, err = exrZip(raw); err != nil {
+				return err
+			}
+		}
+
+		chunk := binary.LittleEndian.AppendUint32(nil, uint32(y0))
+		chunk = binary.LittleEndian.AppendUint32(chunk, uint32(len(data)))
+		chunks[i] = append(chunk, data...)
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.Write(header)
+
+	// The offset table gives the absolute file position of every chunk
+	offset := uint64(len(header) + 8*numChunks)
+	for _, c := range chunks {
+		binary.Write(bw, binary.LittleEndian, offset)
+		offset += uint64(len(c))
+	}
+	for _, c := range chunks {
+		bw.Write(c)
+	}
+
+	return bw.Flush()
+}
+
+// exrHeader returns the magic number, version and header attributes of an EXR file
+func exrHeader(width, height int, channels []EXRChannel, compression EXRCompression) []byte {
+	var h bytes.Buffer
+	le := binary.LittleEndian
+
+	binary.Write(&h, le, uint32(20000630)) // Magic number
+	binary.Write(&h, le, uint32(2))        // Version 2, single part scanline
+
+	attr := func(name, typ string, value []byte) {
+		h.WriteString(name + "\x00" + typ + "\x00")
+		binary.Write(&h, le, uint32(len(value)))
+		h.Write(value)
+	}
+
+	var chlist bytes.Buffer
+	for _, ch := range channels {
+		chlist.WriteString(ch.Name + "\x00")
+		binary.Write(&chlist, le, uint32(2))      // Pixel type FLOAT
+		chlist.Write([]byte{0, 0, 0, 0})          // pLinear and reserved
+		binary.Write(&chlist, le, [2]int32{1, 1}) // x and y sampling
+	}
+	chlist.WriteByte(0)
+
+	box := func(xMax, yMax int) []byte {
+		var b bytes.Buffer
+		binary.Write(&b, le, [4]int32{0, 0, int32(xMax), int32(yMax)})
+		return b.Bytes()
+	}
+	f32 := func(f float32) []byte {
+		return le.AppendUint32(nil, math.Float32bits(f))
+	}
+
+	attr("channels", "chlist", chlist.Bytes())
+	attr("compression", "compression", []byte{byte(compression)})
+	attr("dataWindow", "box2i", box(width-1, height-1))
+	attr("displayWindow", "box2i", box(width-1, height-1))
+	attr("lineOrder", "lineOrder", []byte{0}) // Increasing y
+	attr("pixelAspectRatio", "float", f32(1))
+	attr("screenWindowCenter", "v2f", append(f32(0), f32(0)...))
+	attr("screenWindowWidth", "float", f32(1))
+	h.WriteByte(0) // End of header
+
+	return h.Bytes()
+}
+
+// exrZip applies the byte reordering and delta predictor used by OpenEXR's ZIP compression
+// before deflating the data. If compression doesn't help, the raw data is returned and
+// readers recognise it by its unchanged size.
+func exrZip(raw []byte) ([]byte, error) {
+	// Split the bytes in two halves, even offsets first, to group similar bytes together
+	tmp := make([]byte, len(raw))
+	half := (len(raw) + 1) / 2
+	for i, b := range raw {
+		if i%2 == 0 {
+			tmp[i/2] = b
+		} else {
+			tmp[half+i/2] = b
+		}
+	}
+
+	// Replace each byte with its difference from the previous one
+	p := int(tmp[0])
+	for i := 1; i < len(tmp); i++ {
+		d := int(tmp[i]) - p + (128 + 256)
+		p = int(tmp[i])
+		tmp[i] = byte(d)
+	}
+
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(tmp); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	if buf.Len() >= len(raw) {
+		return raw, nil
+	}
+	return buf.Bytes(), nil
+}
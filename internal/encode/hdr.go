@@ -0,0 +1,32 @@
+package encode
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/hdr"
+	"github.com/sendelivery/go-trace-rays/internal/image"
+)
+
+// HDR writes Radiance RGBE images. Pixels are stored as linear radiance with a shared
+// exponent, no gamma correction or clamping is applied. Scanlines are written flat, without
+// run length encoding, which every Radiance reader accepts.
+type HDR struct{}
+
+func (HDR) Encode(w io.Writer, img *image.Image) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintf(bw, "#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y %d +X %d\n", img.Height(), img.Width())
+
+	err := forEachPixel(img, func(x, y int, col color.Color) {
+		rgbe := hdr.ToRGBE(col)
+		bw.Write(rgbe[:])
+	})
+	if err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
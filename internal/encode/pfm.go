@@ -0,0 +1,38 @@
+package encode
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/sendelivery/go-trace-rays/internal/image"
+)
+
+// PFM writes Portable Float Map images, three little-endian 32 bit floats of linear radiance
+// per pixel. As the format requires, rows are written from the bottom of the image up.
+type PFM struct{}
+
+func (PFM) Encode(w io.Writer, img *image.Image) error {
+	bw := bufio.NewWriter(w)
+
+	// A negative scale marks the data as little-endian
+	fmt.Fprintf(bw, "PF\n%d %d\n-1.0\n", img.Width(), img.Height())
+
+	var buf [12]byte
+	for y := img.Height() - 1; y >= 0; y-- {
+		for x := range img.Width() {
+			col, ok := img.Get(image.NewPixelCoord(x, y))
+			if !ok {
+				return fmt.Errorf("missing pixel at coord %d, %d", x, y)
+			}
+			binary.LittleEndian.PutUint32(buf[0:], math.Float32bits(float32(col.X())))
+			binary.LittleEndian.PutUint32(buf[4:], math.Float32bits(float32(col.Y())))
+			binary.LittleEndian.PutUint32(buf[8:], math.Float32bits(float32(col.Z())))
+			bw.Write(buf[:])
+		}
+	}
+
+	return bw.Flush()
+}
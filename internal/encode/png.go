@@ -0,0 +1,46 @@
+package encode
+
+import (
+	goimage "image"
+	gocolor "image/color"
+	"image/png"
+	"io"
+
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/image"
+)
+
+// PNG writes 8 bit per channel PNGs, or 16 bit per channel when Depth16 is set. Colours are
+// prepared for display with Display.
+type PNG struct {
+	Depth16 bool
+	Display color.Display
+}
+
+func (p PNG) Encode(w io.Writer, img *image.Image) error {
+	bounds := goimage.Rect(0, 0, img.Width(), img.Height())
+
+	var out goimage.Image
+	var err error
+
+	if p.Depth16 {
+		rgba := goimage.NewRGBA64(bounds)
+		err = forEachPixel(img, func(x, y int, col color.Color) {
+			r, g, b := p.Display.Encode16(col)
+			rgba.SetRGBA64(x, y, gocolor.RGBA64{R: r, G: g, B: b, A: 0xffff})
+		})
+		out = rgba
+	} else {
+		rgba := goimage.NewRGBA(bounds)
+		err = forEachPixel(img, func(x, y int, col color.Color) {
+			r, g, b := p.Display.Encode8(col)
+			rgba.SetRGBA(x, y, gocolor.RGBA{R: r, G: g, B: b, A: 0xff})
+		})
+		out = rgba
+	}
+	if err != nil {
+		return err
+	}
+
+	return png.Encode(w, out)
+}
@@ -0,0 +1,42 @@
+package encode
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/image"
+)
+
+// PPM writes Netpbm portable pixmaps with 8 bits per channel. The binary P6 variant is written
+// unless ASCII is set, in which case the plain text P3 variant is used. Colours are prepared
+// for display with Display.
+type PPM struct {
+	ASCII   bool
+	Display color.Display
+}
+
+func (p PPM) Encode(w io.Writer, img *image.Image) error {
+	bw := bufio.NewWriter(w)
+
+	magic := "P6"
+	if p.ASCII {
+		magic = "P3"
+	}
+	fmt.Fprintf(bw, "%s\n%d %d\n255\n", magic, img.Width(), img.Height())
+
+	err := forEachPixel(img, func(x, y int, col color.Color) {
+		r, g, b := p.Display.Encode8(col)
+		if p.ASCII {
+			fmt.Fprintf(bw, "%d %d %d\n", r, g, b)
+			return
+		}
+		bw.Write([]byte{r, g, b})
+	})
+	if err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
@@ -0,0 +1,220 @@
+package gltf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// accessor describes how to read a typed array of elements out of a buffer view
+type accessor struct {
+	BufferView    *int    `json:"bufferView"`
+	ByteOffset    int     `json:"byteOffset"`
+	ComponentType int     `json:"componentType"`
+	Normalized    bool    `json:"normalized"`
+	Count         int     `json:"count"`
+	Type          string  `json:"type"`
+	Sparse        *sparse `json:"sparse"`
+}
+
+// sparse replaces some elements of an accessor, listed by index, with other values
+type sparse struct {
+	Count   int `json:"count"`
+	Indices struct {
+		BufferView    int `json:"bufferView"`
+		ByteOffset    int `json:"byteOffset"`
+		ComponentType int `json:"componentType"`
+	} `json:"indices"`
+	Values struct {
+		BufferView int `json:"bufferView"`
+		ByteOffset int `json:"byteOffset"`
+	} `json:"values"`
+}
+
+// Component types
+const (
+	componentByte          = 5120
+	componentUnsignedByte  = 5121
+	componentShort         = 5122
+	componentUnsignedShort = 5123
+	componentUnsignedInt   = 5125
+	componentFloat         = 5126
+)
+
+const (
+	// maxByteStride is the largest byte stride a buffer view may have
+	maxByteStride = 252
+
+	// maxUnbackedCount is the most elements an accessor without a buffer view may have, as
+	// they aren't bounded by the size of the file
+	maxUnbackedCount = 1 << 24
+)
+
+var componentSizes = map[int]int{
+	componentByte:          1,
+	componentUnsignedByte:  1,
+	componentShort:         2,
+	componentUnsignedShort: 2,
+	componentUnsignedInt:   4,
+	componentFloat:         4,
+}
+
+var typeComponents = map[string]int{
+	"SCALAR": 1,
+	"VEC2":   2,
+	"VEC3":   3,
+	"VEC4":   4,
+}
+
+// read returns the elements of accessor i, flattened, along with the number of components in
+// each element. Normalized integers are mapped to 0 to 1, or -1 to 1 if signed.
+func (d *decoder) read(i int) ([]float64, int, error) {
+	if i < 0 || i >= len(d.doc.Accessors) {
+		return nil, 0, fmt.Errorf("accessor %d does not exist", i)
+	}
+	a := d.doc.Accessors[i]
+
+	n, ok := typeComponents[a.Type]
+	if !ok {
+		return nil, 0, fmt.Errorf("accessor %d has unsupported type %q", i, a.Type)
+	}
+	size, ok := componentSizes[a.ComponentType]
+	if !ok {
+		return nil, 0, fmt.Errorf("accessor %d has unknown component type %d", i, a.ComponentType)
+	}
+	if a.Count < 0 {
+		return nil, 0, fmt.Errorf("accessor %d has negative count %d", i, a.Count)
+	}
+	if a.ByteOffset < 0 {
+		return nil, 0, fmt.Errorf("accessor %d has negative byte offset %d", i, a.ByteOffset)
+	}
+
+	// Accessors without a buffer view are all zeros, unless sparse values are laid over them.
+	// The elements are checked to lie within the buffer view, or in number against a fixed
+	// limit without one, before anything is allocated for them.
+	var data []byte
+	stride := n * size
+	if a.BufferView != nil {
+		var bv bufferView
+		var err error
+		if data, bv, err = d.bufferView(*a.BufferView); err != nil {
+			return nil, 0, fmt.Errorf("accessor %d: %w", i, err)
+		}
+		if bv.ByteStride != 0 {
+			stride = bv.ByteStride
+		}
+		if stride < n*size || stride > maxByteStride {
+			return nil, 0, fmt.Errorf("accessor %d has %d byte elements, buffer view %d has byte stride %d", i, n*size, *a.BufferView, stride)
+		}
+		if !fits(a.ByteOffset, a.Count, stride, n*size, len(data)) {
+			return nil, 0, fmt.Errorf("accessor %d overruns buffer view %d", i, *a.BufferView)
+		}
+	} else if a.Count > maxUnbackedCount {
+		return nil, 0, fmt.Errorf("accessor %d has %d elements without a buffer view, at most %d are allowed", i, a.Count, maxUnbackedCount)
+	}
+
+	out := make([]float64, a.Count*n)
+	if data != nil {
+		for e := range a.Count {
+			readElement(out[e*n:(e+1)*n], data[a.ByteOffset+e*stride:], a.ComponentType, a.Normalized)
+		}
+	}
+
+	if s := a.Sparse; s != nil {
+		if err := d.readSparse(out, n, size, a, s); err != nil {
+			return nil, 0, fmt.Errorf("accessor %d: %w", i, err)
+		}
+	}
+	return out, n, nil
+}
+
+// readSparse lays the sparse values of a over out
+func (d *decoder) readSparse(out []float64, n, size int, a accessor, s *sparse) error {
+	var indexSize int
+	switch s.Indices.ComponentType {
+	case componentUnsignedByte, componentUnsignedShort, componentUnsignedInt:
+		indexSize = componentSizes[s.Indices.ComponentType]
+	default:
+		return fmt.Errorf("sparse indices have unsupported component type %d", s.Indices.ComponentType)
+	}
+	if s.Count < 0 {
+		return fmt.Errorf("sparse data has negative count %d", s.Count)
+	}
+	if s.Indices.ByteOffset < 0 || s.Values.ByteOffset < 0 {
+		return fmt.Errorf("sparse data has negative byte offset")
+	}
+	if s.Count > a.Count {
+		return fmt.Errorf("sparse data has %d elements, more than the accessor's %d", s.Count, a.Count)
+	}
+	indexData, _, err := d.bufferView(s.Indices.BufferView)
+	if err != nil {
+		return err
+	}
+	valueData, _, err := d.bufferView(s.Values.BufferView)
+	if err != nil {
+		return err
+	}
+	if !fits(s.Indices.ByteOffset, s.Count, indexSize, indexSize, len(indexData)) || !fits(s.Values.ByteOffset, s.Count, n*size, n*size, len(valueData)) {
+		return fmt.Errorf("sparse data overruns its buffer view")
+	}
+
+	var index [1]float64
+	for k := range s.Count {
+		readElement(index[:], indexData[s.Indices.ByteOffset+k*indexSize:], s.Indices.ComponentType, false)
+		e := int(index[0])
+		if e < 0 || e >= a.Count {
+			return fmt.Errorf("sparse index %d out of range, %d elements", e, a.Count)
+		}
+		readElement(out[e*n:(e+1)*n], valueData[s.Values.ByteOffset+k*n*size:], a.ComponentType, a.Normalized)
+	}
+	return nil
+}
+
+// fits reports whether count elements of size bytes, stride bytes apart and starting at
+// offset, lie within length bytes. It is worked out so that it can't overflow, whatever the
+// file claims.
+func fits(offset, count, stride, size, length int) bool {
+	if offset > length {
+		return false
+	}
+	if count == 0 {
+		return true
+	}
+	room := length - offset - size
+	return room >= 0 && count-1 <= room/stride
+}
+
+// readElement reads len(out) components of the given type from the start of data
+func readElement(out []float64, data []byte, componentType int, normalized bool) {
+	le := binary.LittleEndian
+	for c := range out {
+		var x float64
+		switch componentType {
+		case componentByte:
+			x = float64(int8(data[c]))
+			if normalized {
+				x = max(x/127, -1)
+			}
+		case componentUnsignedByte:
+			x = float64(data[c])
+			if normalized {
+				x /= 255
+			}
+		case componentShort:
+			x = float64(int16(le.Uint16(data[2*c:])))
+			if normalized {
+				x = max(x/32767, -1)
+			}
+		case componentUnsignedShort:
+			x = float64(le.Uint16(data[2*c:]))
+			if normalized {
+				x /= 65535
+			}
+		case componentUnsignedInt:
+			x = float64(le.Uint32(data[4*c:]))
+		case componentFloat:
+			x = float64(math.Float32frombits(le.Uint32(data[4*c:])))
+		}
+		out[c] = x
+	}
+}
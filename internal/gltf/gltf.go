@@ -0,0 +1,342 @@
+// Package gltf loads glTF 2.0 scenes, in either the JSON .gltf form with its buffers and images
+// alongside or embedded as data URIs, or the binary .glb form.
+//
+// The node hierarchy, triangle meshes with their normals and texture coordinates,
+// metallic-roughness materials with their textures, and perspective cameras are read. Anything
+// else the file uses, such as animations, skins or unsupported extensions, is left out and
+// reported as a warning.
+package gltf
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sendelivery/go-trace-rays/internal/camera"
+	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
+	"github.com/sendelivery/go-trace-rays/internal/object/hittable"
+	"github.com/sendelivery/go-trace-rays/internal/texture"
+)
+
+// Scene is a loaded glTF scene, ready to render. Camera is the scene's first perspective
+// camera, or one framing the whole world if it has none.
+type Scene struct {
+	World    hittable.Hittabler
+	Camera   *camera.Camera
+	Warnings []string // Everything in the file that was left out, in the order it was found
+}
+
+// document is the JSON part of a glTF file, holding the parts of the format we read
+type document struct {
+	Asset struct {
+		Version string `json:"version"`
+	} `json:"asset"`
+	ExtensionsUsed     []string `json:"extensionsUsed"`
+	ExtensionsRequired []string `json:"extensionsRequired"`
+
+	Scene       *int          `json:"scene"`
+	Scenes      []sceneDef    `json:"scenes"`
+	Nodes       []node        `json:"nodes"`
+	Meshes      []meshDef     `json:"meshes"`
+	Materials   []materialDef `json:"materials"`
+	Textures    []textureDef  `json:"textures"`
+	Images      []imageDef    `json:"images"`
+	Samplers    []samplerDef  `json:"samplers"`
+	Cameras     []cameraDef   `json:"cameras"`
+	Accessors   []accessor    `json:"accessors"`
+	BufferViews []bufferView  `json:"bufferViews"`
+	Buffers     []buffer      `json:"buffers"`
+
+	Animations []json.RawMessage `json:"animations"`
+	Skins      []json.RawMessage `json:"skins"`
+}
+
+type sceneDef struct {
+	Nodes []int `json:"nodes"`
+}
+
+type node struct {
+	Name        string    `json:"name"`
+	Children    []int     `json:"children"`
+	Mesh        *int      `json:"mesh"`
+	Camera      *int      `json:"camera"`
+	Matrix      []float64 `json:"matrix"` // Column-major
+	Translation []float64 `json:"translation"`
+	Rotation    []float64 `json:"rotation"` // Quaternion as x, y, z, w
+	Scale       []float64 `json:"scale"`
+}
+
+type meshDef struct {
+	Name       string      `json:"name"`
+	Primitives []primitive `json:"primitives"`
+}
+
+type primitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    *int           `json:"indices"`
+	Material   *int           `json:"material"`
+	Mode       *int           `json:"mode"`
+}
+
+type textureDef struct {
+	Source  *int `json:"source"`
+	Sampler *int `json:"sampler"`
+}
+
+type imageDef struct {
+	URI        string `json:"uri"`
+	BufferView *int   `json:"bufferView"`
+	MimeType   string `json:"mimeType"`
+}
+
+type samplerDef struct {
+	WrapS *int `json:"wrapS"`
+	WrapT *int `json:"wrapT"`
+}
+
+type cameraDef struct {
+	Type        string `json:"type"`
+	Perspective *struct {
+		AspectRatio *float64 `json:"aspectRatio"`
+		Yfov        float64  `json:"yfov"` // Vertical field of view in radians
+	} `json:"perspective"`
+}
+
+type bufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	ByteStride int `json:"byteStride"`
+}
+
+type buffer struct {
+	URI        string `json:"uri"`
+	ByteLength int    `json:"byteLength"`
+}
+
+// Extensions whose meaning is read, all others are reported as left out
+var supportedExtensions = []string{
+	"KHR_materials_clearcoat",
+	"KHR_materials_emissive_strength",
+	"KHR_materials_ior",
+	"KHR_materials_sheen",
+	"KHR_materials_transmission",
+}
+
+// decoder holds the state built up while loading a glTF file
+type decoder struct {
+	fsys fs.FS
+	doc  document
+	bin  []byte // The binary chunk of a .glb file
+
+	buffers   [][]byte
+	textures  map[textureKey]texture.Texturer
+	materials []hitrecord.Scatterer
+
+	// The material of primitives without one, made when first needed
+	defaultMaterial hitrecord.Scatterer
+
+	warnings []string
+	warned   map[string]bool
+}
+
+// Load reads the .gltf or .glb file at path. Buffers and images it refers to are resolved
+// relative to the directory containing the file.
+func Load(path string) (*Scene, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Decode(f, filepath.Base(path), os.DirFS(filepath.Dir(path)))
+}
+
+// Decode reads a glTF file from r, telling the JSON and binary forms apart by their contents.
+// name identifies the file in errors and fsys is used to open the buffers and images it
+// refers to; fsys may be nil if everything is embedded.
+func Decode(r io.Reader, name string, fsys fs.FS) (*Scene, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	d := decoder{
+		fsys:     fsys,
+		textures: make(map[textureKey]texture.Texturer),
+		warned:   make(map[string]bool),
+	}
+	if bytes.HasPrefix(data, []byte(glbMagic)) {
+		if data, d.bin, err = splitGLB(data); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	if err := json.Unmarshal(data, &d.doc); err != nil {
+		return nil, fmt.Errorf("%s: decoding JSON: %w", name, err)
+	}
+
+	scene, err := d.scene()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return scene, nil
+}
+
+// IsGLTF reports whether path names a glTF file, by its extension
+func IsGLTF(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".gltf" || ext == ".glb"
+}
+
+// warnf records a warning, once however often it comes up
+func (d *decoder) warnf(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	if !d.warned[msg] {
+		d.warned[msg] = true
+		d.warnings = append(d.warnings, msg)
+	}
+}
+
+// checkExtensions warns about the extensions used that aren't read
+func (d *decoder) checkExtensions() {
+	for _, ext := range d.doc.ExtensionsUsed {
+		if slices.Contains(supportedExtensions, ext) {
+			continue
+		}
+		if slices.Contains(d.doc.ExtensionsRequired, ext) {
+			d.warnf("required extension %s is not supported, the scene may not look as intended", ext)
+		} else {
+			d.warnf("extension %s is not supported and is ignored", ext)
+		}
+	}
+	if len(d.doc.Animations) > 0 {
+		d.warnf("animations are not supported, the scene is shown in its rest pose")
+	}
+	if len(d.doc.Skins) > 0 {
+		d.warnf("skins are not supported, skinned meshes are shown in their bind pose")
+	}
+}
+
+const (
+	glbMagic     = "glTF"
+	glbJSONChunk = 0x4e4f534a
+	glbBINChunk  = 0x004e4942
+)
+
+// splitGLB returns the JSON and binary chunks of a .glb file
+func splitGLB(data []byte) (jsonChunk, binChunk []byte, err error) {
+	if len(data) < 12 {
+		return nil, nil, errors.New("glb header is truncated")
+	}
+	if version := binary.LittleEndian.Uint32(data[4:]); version != 2 {
+		return nil, nil, fmt.Errorf("unsupported glb version %d", version)
+	}
+	length := int(binary.LittleEndian.Uint32(data[8:]))
+	if length < 12 || length > len(data) {
+		return nil, nil, fmt.Errorf("glb is %d bytes long, its header says %d", len(data), length)
+	}
+
+	for rest := data[12:length]; len(rest) > 0; {
+		if len(rest) < 8 {
+			return nil, nil, errors.New("glb chunk header is truncated")
+		}
+		size := int(binary.LittleEndian.Uint32(rest))
+		kind := binary.LittleEndian.Uint32(rest[4:])
+		if size > len(rest)-8 {
+			return nil, nil, fmt.Errorf("glb chunk of %d bytes overruns the file", size)
+		}
+		chunk := rest[8 : 8+size]
+		rest = rest[8+size:]
+
+		switch {
+		case kind == glbJSONChunk && jsonChunk == nil:
+			jsonChunk = chunk
+		case kind == glbBINChunk && binChunk == nil:
+			binChunk = chunk
+		}
+	}
+	if jsonChunk == nil {
+		return nil, nil, errors.New("glb has no JSON chunk")
+	}
+	return jsonChunk, binChunk, nil
+}
+
+// buffer returns the contents of buffer i, loading it the first time it is asked for
+func (d *decoder) buffer(i int) ([]byte, error) {
+	if i < 0 || i >= len(d.doc.Buffers) {
+		return nil, fmt.Errorf("buffer %d does not exist", i)
+	}
+	if d.buffers == nil {
+		d.buffers = make([][]byte, len(d.doc.Buffers))
+	}
+	if d.buffers[i] != nil {
+		return d.buffers[i], nil
+	}
+
+	b := d.doc.Buffers[i]
+	var data []byte
+	if b.URI == "" {
+		if i != 0 || d.bin == nil {
+			return nil, fmt.Errorf("buffer %d has no uri", i)
+		}
+		data = d.bin
+	} else {
+		var err error
+		if data, err = d.readURI(b.URI); err != nil {
+			return nil, fmt.Errorf("buffer %d: %w", i, err)
+		}
+	}
+	if len(data) < b.ByteLength {
+		return nil, fmt.Errorf("buffer %d is %d bytes long, want %d", i, len(data), b.ByteLength)
+	}
+
+	d.buffers[i] = data
+	return data, nil
+}
+
+// bufferView returns the bytes of buffer view i
+func (d *decoder) bufferView(i int) ([]byte, bufferView, error) {
+	if i < 0 || i >= len(d.doc.BufferViews) {
+		return nil, bufferView{}, fmt.Errorf("buffer view %d does not exist", i)
+	}
+	bv := d.doc.BufferViews[i]
+	data, err := d.buffer(bv.Buffer)
+	if err != nil {
+		return nil, bv, err
+	}
+	if bv.ByteOffset < 0 || bv.ByteLength < 0 || bv.ByteOffset > len(data) || bv.ByteLength > len(data)-bv.ByteOffset {
+		return nil, bv, fmt.Errorf("buffer view %d overruns buffer %d", i, bv.Buffer)
+	}
+	return data[bv.ByteOffset : bv.ByteOffset+bv.ByteLength], bv, nil
+}
+
+// readURI returns the data a buffer or image uri points to, either embedded in a data URI or
+// in a file relative to the glTF file
+func (d *decoder) readURI(uri string) ([]byte, error) {
+	if rest, ok := strings.CutPrefix(uri, "data:"); ok {
+		_, payload, ok := strings.Cut(rest, ";base64,")
+		if !ok {
+			return nil, errors.New("data uri is not base64 encoded")
+		}
+		return base64.StdEncoding.DecodeString(payload)
+	}
+
+	if d.fsys == nil {
+		return nil, fmt.Errorf("cannot open %q, no file system given", uri)
+	}
+	path, err := url.PathUnescape(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid uri %q: %w", uri, err)
+	}
+	return fs.ReadFile(d.fsys, filepath.ToSlash(path))
+}
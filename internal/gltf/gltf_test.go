@@ -0,0 +1,455 @@
+package gltf_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"image"
+	imagecolor "image/color"
+	"image/png"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/gltf"
+	"github.com/sendelivery/go-trace-rays/internal/interval"
+	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
+	"github.com/sendelivery/go-trace-rays/internal/object/material"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// quad returns a glTF document for a 2x2 quad facing +Z, moved to z=-5 by its parent node and
+// seen by a camera at z=5, along with its binary buffer. The quad glows with a 1x2 image, red
+// on top and blue below, and a second quad drawn with the default material sits off to the
+// side.
+func quad(t *testing.T) (map[string]any, []byte) {
+	t.Helper()
+
+	var bin bytes.Buffer
+	write := func(data any) int {
+		offset := bin.Len()
+		if err := binary.Write(&bin, binary.LittleEndian, data); err != nil {
+			t.Fatal(err)
+		}
+		return offset
+	}
+	positions := write([]float32{-1, -1, 0, 1, -1, 0, 1, 1, 0, -1, 1, 0})
+	normals := write([]float32{0, 0, 1, 0, 0, 1, 0, 0, 1, 0, 0, 1})
+	uvs := write([]float32{0, 1, 1, 1, 1, 0, 0, 0})
+	indices := write([]uint16{0, 1, 2, 0, 2, 3})
+
+	img := image.NewRGBA(image.Rect(0, 0, 1, 2))
+	img.Set(0, 0, imagecolor.RGBA{255, 0, 0, 255})
+	img.Set(0, 1, imagecolor.RGBA{0, 0, 255, 255})
+	for bin.Len()%4 != 0 {
+		bin.WriteByte(0)
+	}
+	imageOffset := bin.Len()
+	if err := png.Encode(&bin, img); err != nil {
+		t.Fatal(err)
+	}
+
+	view := func(offset, length int) map[string]any {
+		return map[string]any{"buffer": 0, "byteOffset": offset, "byteLength": length}
+	}
+	doc := map[string]any{
+		"asset":          map[string]any{"version": "2.0"},
+		"extensionsUsed": []string{"KHR_lights_punctual"},
+		"scene":          0,
+		"scenes":         []any{map[string]any{"nodes": []int{0, 2, 3}}},
+		"nodes": []any{
+			map[string]any{"translation": []float64{0, 0, -5}, "children": []int{1}},
+			map[string]any{"mesh": 0},
+			map[string]any{"translation": []float64{0, 0, 5}, "camera": 0},
+			map[string]any{"translation": []float64{10, 0, 0}, "mesh": 1},
+		},
+		"meshes": []any{
+			map[string]any{"primitives": []any{map[string]any{
+				"attributes": map[string]int{"POSITION": 0, "NORMAL": 1, "TEXCOORD_0": 2},
+				"indices":    3,
+				"material":   0,
+			}}},
+			map[string]any{"primitives": []any{map[string]any{
+				"attributes": map[string]int{"POSITION": 0},
+				"indices":    3,
+			}}},
+		},
+		"materials": []any{map[string]any{
+			"emissiveFactor":  []float64{1, 1, 1},
+			"emissiveTexture": map[string]any{"index": 0},
+		}},
+		"textures": []any{map[string]any{"source": 0}},
+		"images":   []any{map[string]any{"bufferView": 4, "mimeType": "image/png"}},
+		"cameras": []any{map[string]any{
+			"type":        "perspective",
+			"perspective": map[string]any{"yfov": math.Pi / 4, "aspectRatio": 1.5, "znear": 0.1},
+		}},
+		"accessors": []any{
+			map[string]any{"bufferView": 0, "componentType": 5126, "count": 4, "type": "VEC3"},
+			map[string]any{"bufferView": 1, "componentType": 5126, "count": 4, "type": "VEC3"},
+			map[string]any{"bufferView": 2, "componentType": 5126, "count": 4, "type": "VEC2"},
+			map[string]any{"bufferView": 3, "componentType": 5123, "count": 6, "type": "SCALAR"},
+		},
+		"bufferViews": []any{
+			view(positions, 48),
+			view(normals, 48),
+			view(uvs, 32),
+			view(indices, 12),
+			view(imageOffset, bin.Len()-imageOffset),
+		},
+		"buffers": []any{map[string]any{"byteLength": bin.Len()}},
+	}
+	return doc, bin.Bytes()
+}
+
+func encodeJSON(t *testing.T, doc map[string]any) []byte {
+	t.Helper()
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// glb packs doc and bin into a binary glTF file
+func glb(t *testing.T, doc map[string]any, bin []byte) []byte {
+	t.Helper()
+
+	jsonChunk := encodeJSON(t, doc)
+	for len(jsonChunk)%4 != 0 {
+		jsonChunk = append(jsonChunk, ' ')
+	}
+	for len(bin)%4 != 0 {
+		bin = append(bin, 0)
+	}
+
+	var out bytes.Buffer
+	le := binary.LittleEndian
+	out.WriteString("glTF")
+	binary.Write(&out, le, uint32(2))
+	binary.Write(&out, le, uint32(12+8+len(jsonChunk)+8+len(bin)))
+	binary.Write(&out, le, uint32(len(jsonChunk)))
+	binary.Write(&out, le, uint32(0x4e4f534a))
+	out.Write(jsonChunk)
+	binary.Write(&out, le, uint32(len(bin)))
+	binary.Write(&out, le, uint32(0x004e4942))
+	out.Write(bin)
+	return out.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+	doc, bin := quad(t)
+	glbData := glb(t, doc, bin)
+
+	external := fstest.MapFS{"quad.bin": {Data: bin}}
+	doc["buffers"] = []any{map[string]any{"uri": "quad.bin", "byteLength": len(bin)}}
+	externalData := encodeJSON(t, doc)
+
+	doc["buffers"] = []any{map[string]any{
+		"uri":        "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(bin),
+		"byteLength": len(bin),
+	}}
+	embeddedData := encodeJSON(t, doc)
+
+	tests := map[string]struct {
+		data []byte
+		fsys fstest.MapFS
+	}{
+		"glb":      {data: glbData},
+		"external": {data: externalData, fsys: external},
+		"embedded": {data: embeddedData},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := gltf.Decode(bytes.NewReader(tt.data), "quad", tt.fsys)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			// The quad is at z=-5, glowing red above its middle and blue below
+			for _, tc := range []struct {
+				y    float64
+				want color.Color
+			}{
+				{y: 0.5, want: color.New(1, 0, 0)},
+				{y: -0.5, want: color.New(0, 0, 1)},
+			} {
+				r := ray.New(vec3.New(0.5, tc.y, 10), vec3.New(0, 0, -1))
+				hr, ok := s.World.Hit(r, interval.New(1e-3, math.Inf(1)))
+				if !ok || math.Abs(hr.T()-15) > 1e-6 {
+					t.Fatalf("unexpected hit, got=%v at t=%v. want=true at t=15.", ok, hr.T())
+				}
+				light, ok := hr.Material().(hitrecord.Emitter)
+				if !ok {
+					t.Fatalf("unexpected material, got=%T. want an emitter.", hr.Material())
+				}
+				if got := light.Emitted(r, hr); !vec3.Equal(got, tc.want) {
+					t.Errorf("unexpected emission at y=%v, got=%q. want=%q.", tc.y, &got, &tc.want)
+				}
+			}
+
+			// The second quad has the default material
+			r := ray.New(vec3.New(10, 0, 10), vec3.New(0, 0, -1))
+			hr, ok := s.World.Hit(r, interval.New(1e-3, math.Inf(1)))
+			if !ok {
+				t.Fatal("expected to hit the second quad")
+			}
+			if _, ok := hr.Material().(*material.Principled); !ok {
+				t.Errorf("unexpected material, got=%T. want=*material.Principled.", hr.Material())
+			}
+
+			cam := s.Camera
+			if want := vec3.New(0, 0, 5); !vec3.Equal(cam.LookFrom, want) {
+				t.Errorf("unexpected camera position, got=%q. want=%q.", &cam.LookFrom, &want)
+			}
+			if want := vec3.New(0, 0, 4); !vec3.Equal(cam.LookAt, want) {
+				t.Errorf("unexpected camera target, got=%q. want=%q.", &cam.LookAt, &want)
+			}
+			if math.Abs(cam.VerticalFov-45) > 1e-9 || cam.AspectRatio != 1.5 {
+				t.Errorf("unexpected camera view, got fov=%v aspect=%v. want fov=45 aspect=1.5.", cam.VerticalFov, cam.AspectRatio)
+			}
+
+			want := []string{"extension KHR_lights_punctual is not supported and is ignored"}
+			if !slices.Equal(s.Warnings, want) {
+				t.Errorf("unexpected warnings, got=%q. want=%q.", s.Warnings, want)
+			}
+		})
+	}
+}
+
+// An emissive material still reflects light off its surface as well as giving off its own
+func TestDecodeEmissiveMetal(t *testing.T) {
+	doc, bin := quad(t)
+	mat := doc["materials"].([]any)[0].(map[string]any)
+	mat["pbrMetallicRoughness"] = map[string]any{
+		"baseColorFactor": []float64{0.5, 0.5, 0.5, 1},
+		"metallicFactor":  1,
+		"roughnessFactor": 0,
+	}
+
+	s, err := gltf.Decode(bytes.NewReader(glb(t, doc, bin)), "quad.glb", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r := ray.New(vec3.New(0.5, 0.5, 10), vec3.New(0, 0, -1))
+	hr, ok := s.World.Hit(r, interval.New(1e-3, math.Inf(1)))
+	if !ok {
+		t.Fatal("expected to hit the quad")
+	}
+
+	light, ok := hr.Material().(hitrecord.Emitter)
+	if !ok {
+		t.Fatalf("unexpected material, got=%T. want an emitter.", hr.Material())
+	}
+	if got, want := light.Emitted(r, hr), color.New(1, 0, 0); !vec3.Equal(got, want) {
+		t.Errorf("unexpected emission, got=%q. want=%q.", &got, &want)
+	}
+
+	attenuation, scattered, ok := hr.Material().Scatter(r, hr, utility.NewRand(1, 0))
+	if !ok {
+		t.Fatal("expected the emissive metal to scatter")
+	}
+	if got, want := attenuation, color.New(0.5, 0.5, 0.5); vec3.Sub(got, want).Length() > 1e-3 {
+		t.Errorf("unexpected attenuation, got=%q. want=%q.", &got, &want)
+	}
+	if got := scattered.Direction(); got.Z() < 0.99 {
+		t.Errorf("unexpected direction, got=%q. want the mirror direction (0, 0, 1).", &got)
+	}
+}
+
+// Without a camera of its own, the scene is framed from the front
+func TestDecodeFramed(t *testing.T) {
+	doc, bin := quad(t)
+	doc["scenes"] = []any{map[string]any{"nodes": []int{0}}}
+
+	s, err := gltf.Decode(bytes.NewReader(glb(t, doc, bin)), "quad.glb", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cam := s.Camera
+	if want := vec3.New(0, 0, -5); !vec3.Equal(cam.LookAt, want) {
+		t.Errorf("unexpected camera target, got=%q. want=%q.", &cam.LookAt, &want)
+	}
+	if cam.LookFrom.Z() <= -5 {
+		t.Errorf("unexpected camera position, got=%q. want in front of the quad.", &cam.LookFrom)
+	}
+}
+
+// withSparse lays count sparse positions over the quad's, indexed by its triangle indices
+func withSparse(doc map[string]any, count, componentType, offset int) {
+	doc["accessors"].([]any)[0].(map[string]any)["sparse"] = map[string]any{
+		"count":   count,
+		"indices": map[string]any{"bufferView": 3, "byteOffset": offset, "componentType": componentType},
+		"values":  map[string]any{"bufferView": 0, "byteOffset": offset},
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := map[string]struct {
+		edit func(doc map[string]any)
+		want string
+	}{
+		"version": {
+			edit: func(doc map[string]any) { doc["asset"] = map[string]any{"version": "1.0"} },
+			want: `unsupported glTF version "1.0"`,
+		},
+		"overrun": {
+			edit: func(doc map[string]any) {
+				doc["accessors"].([]any)[0].(map[string]any)["count"] = 100
+			},
+			want: "accessor 0 overruns buffer view 0",
+		},
+		"negative offset": {
+			edit: func(doc map[string]any) {
+				doc["accessors"].([]any)[0].(map[string]any)["byteOffset"] = -8
+			},
+			want: "accessor 0 has negative byte offset -8",
+		},
+		"negative stride": {
+			edit: func(doc map[string]any) {
+				doc["bufferViews"].([]any)[0].(map[string]any)["byteStride"] = -12
+			},
+			want: "buffer view 0 has byte stride -12",
+		},
+		"short stride": {
+			edit: func(doc map[string]any) {
+				doc["bufferViews"].([]any)[0].(map[string]any)["byteStride"] = 8
+			},
+			want: "accessor 0 has 12 byte elements, buffer view 0 has byte stride 8",
+		},
+		"long stride": {
+			edit: func(doc map[string]any) {
+				doc["bufferViews"].([]any)[0].(map[string]any)["byteStride"] = 256
+			},
+			want: "buffer view 0 has byte stride 256",
+		},
+		"signed sparse indices": {
+			edit: func(doc map[string]any) { withSparse(doc, 3, 5122, 0) },
+			want: "sparse indices have unsupported component type 5122",
+		},
+		"negative sparse count": {
+			edit: func(doc map[string]any) { withSparse(doc, -1, 5123, 0) },
+			want: "sparse data has negative count -1",
+		},
+		"negative sparse offset": {
+			edit: func(doc map[string]any) { withSparse(doc, 3, 5123, -2) },
+			want: "sparse data has negative byte offset",
+		},
+		"too much sparse data": {
+			edit: func(doc map[string]any) {
+				withSparse(doc, 3, 5123, 0)
+				doc["accessors"].([]any)[0].(map[string]any)["count"] = 2
+			},
+			want: "sparse data has 3 elements, more than the accessor's 2",
+		},
+		"sparse index out of range": {
+			edit: func(doc map[string]any) {
+				// Starting from the third triangle index, which is 2
+				withSparse(doc, 2, 5123, 4)
+				doc["accessors"].([]any)[0].(map[string]any)["count"] = 2
+			},
+			want: "sparse index 2 out of range, 2 elements",
+		},
+		"count overflows": {
+			edit: func(doc map[string]any) {
+				a := doc["accessors"].([]any)[0].(map[string]any)
+				a["type"], a["count"] = "VEC4", 2305843009213693952
+			},
+			want: "accessor 0 overruns buffer view 0",
+		},
+		"count exhausts memory": {
+			edit: func(doc map[string]any) {
+				a := doc["accessors"].([]any)[0].(map[string]any)
+				a["type"], a["count"] = "VEC4", 100000000000
+			},
+			want: "accessor 0 overruns buffer view 0",
+		},
+		"count without buffer view": {
+			edit: func(doc map[string]any) {
+				a := doc["accessors"].([]any)[0].(map[string]any)
+				delete(a, "bufferView")
+				a["type"], a["count"] = "VEC4", 100000000000
+			},
+			want: "accessor 0 has 100000000000 elements without a buffer view",
+		},
+		"buffer view offset overflows": {
+			edit: func(doc map[string]any) {
+				doc["bufferViews"].([]any)[0].(map[string]any)["byteOffset"] = math.MaxInt64 - 10
+			},
+			want: "buffer view 0 overruns buffer 0",
+		},
+		"missing buffer": {
+			edit: func(doc map[string]any) { doc["buffers"] = []any{} },
+			want: "buffer 0 does not exist",
+		},
+		"cycle": {
+			edit: func(doc map[string]any) {
+				doc["nodes"].([]any)[1].(map[string]any)["children"] = []int{0}
+			},
+			want: "node 0 is its own ancestor",
+		},
+		"no meshes": {
+			edit: func(doc map[string]any) { doc["scenes"] = []any{map[string]any{"nodes": []int{2}}} },
+			want: "scene has no meshes",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			doc, bin := quad(t)
+			tt.edit(doc)
+
+			_, err := gltf.Decode(bytes.NewReader(glb(t, doc, bin)), "quad.glb", nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("unexpected error, got=%v. want=%q.", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeGLBErrors(t *testing.T) {
+	doc, bin := quad(t)
+	valid := glb(t, doc, bin)
+
+	// withLength returns the file with the length in its header replaced
+	withLength := func(length uint32) []byte {
+		data := slices.Clone(valid)
+		binary.LittleEndian.PutUint32(data[8:], length)
+		return data
+	}
+
+	tests := map[string]struct {
+		data []byte
+		want string
+	}{
+		"truncated header": {data: valid[:8], want: "glb header is truncated"},
+		"zero length":      {data: withLength(0), want: "its header says 0"},
+		"short length":     {data: withLength(4), want: "its header says 4"},
+		"long length":      {data: withLength(uint32(len(valid) + 4)), want: "its header says"},
+		"truncated chunk":  {data: withLength(16), want: "glb chunk header is truncated"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := gltf.Decode(bytes.NewReader(tt.data), "quad.glb", nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("unexpected error, got=%v. want=%q.", err, tt.want)
+			}
+		})
+	}
+}
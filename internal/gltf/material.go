@@ -0,0 +1,333 @@
+package gltf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
+	"github.com/sendelivery/go-trace-rays/internal/object/material"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/texture"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+type materialDef struct {
+	Name                 string `json:"name"`
+	PBRMetallicRoughness *struct {
+		BaseColorFactor          []float64    `json:"baseColorFactor"`
+		BaseColorTexture         *textureInfo `json:"baseColorTexture"`
+		MetallicFactor           *float64     `json:"metallicFactor"`
+		RoughnessFactor          *float64     `json:"roughnessFactor"`
+		MetallicRoughnessTexture *textureInfo `json:"metallicRoughnessTexture"`
+	} `json:"pbrMetallicRoughness"`
+	NormalTexture    *textureInfo `json:"normalTexture"`
+	OcclusionTexture *textureInfo `json:"occlusionTexture"`
+	EmissiveTexture  *textureInfo `json:"emissiveTexture"`
+	EmissiveFactor   []float64    `json:"emissiveFactor"`
+	AlphaMode        string       `json:"alphaMode"`
+
+	Extensions struct {
+		Clearcoat *struct {
+			ClearcoatFactor           float64      `json:"clearcoatFactor"`
+			ClearcoatTexture          *textureInfo `json:"clearcoatTexture"`
+			ClearcoatRoughnessFactor  float64      `json:"clearcoatRoughnessFactor"`
+			ClearcoatRoughnessTexture *textureInfo `json:"clearcoatRoughnessTexture"`
+		} `json:"KHR_materials_clearcoat"`
+		EmissiveStrength *struct {
+			EmissiveStrength *float64 `json:"emissiveStrength"`
+		} `json:"KHR_materials_emissive_strength"`
+		IOR *struct {
+			IOR *float64 `json:"ior"`
+		} `json:"KHR_materials_ior"`
+		Sheen *struct {
+			SheenColorFactor  []float64    `json:"sheenColorFactor"`
+			SheenColorTexture *textureInfo `json:"sheenColorTexture"`
+		} `json:"KHR_materials_sheen"`
+		Transmission *struct {
+			TransmissionFactor  float64      `json:"transmissionFactor"`
+			TransmissionTexture *textureInfo `json:"transmissionTexture"`
+		} `json:"KHR_materials_transmission"`
+	} `json:"extensions"`
+}
+
+// textureInfo is a material's reference to a texture
+type textureInfo struct {
+	Index      int                        `json:"index"`
+	TexCoord   int                        `json:"texCoord"`
+	Extensions map[string]json.RawMessage `json:"extensions"`
+}
+
+// textureKey identifies a texture as loaded for one use, images of colours being converted
+// from sRGB and images of numbers being left as they are
+type textureKey struct {
+	index  int
+	linear bool
+}
+
+// Sampler wrap modes
+const (
+	wrapClamp    = 33071
+	wrapMirrored = 33648
+	wrapRepeat   = 10497
+)
+
+// buildMaterials builds every material in the file. Each becomes a Principled material, which
+// emissive ones also give off light from.
+func (d *decoder) buildMaterials() error {
+	d.materials = make([]hitrecord.Scatterer, len(d.doc.Materials))
+	for i, m := range d.doc.Materials {
+		mat, err := d.material(m)
+		if err != nil {
+			return fmt.Errorf("material %d: %w", i, err)
+		}
+		d.materials[i] = mat
+	}
+	return nil
+}
+
+// defaultParams returns glTF's defaults for a material, a white, fully rough metal. They are
+// also the material of primitives that don't name one.
+func defaultParams() material.PrincipledParams {
+	return material.PrincipledParams{
+		BaseColor: texture.NewSolidValue(1),
+		Metallic:  texture.NewSolidValue(1),
+		Roughness: texture.NewSolidValue(1),
+	}
+}
+
+func (d *decoder) material(m materialDef) (hitrecord.Scatterer, error) {
+	if m.NormalTexture != nil {
+		d.warnf("normal textures are not supported and are ignored")
+	}
+	if m.OcclusionTexture != nil {
+		d.warnf("occlusion textures are not supported and are ignored")
+	}
+	if m.AlphaMode == "MASK" || m.AlphaMode == "BLEND" {
+		d.warnf("alpha mode %s is not supported, surfaces are drawn opaque", m.AlphaMode)
+	}
+
+	p := defaultParams()
+	var err error
+
+	if pbr := m.PBRMetallicRoughness; pbr != nil {
+		factor := color.White
+		if len(pbr.BaseColorFactor) >= 3 {
+			factor = color.New(pbr.BaseColorFactor[0], pbr.BaseColorFactor[1], pbr.BaseColorFactor[2])
+		}
+		if p.BaseColor, err = d.scaled(pbr.BaseColorTexture, false, -1, factor); err != nil {
+			return nil, err
+		}
+
+		// Roughness and metalness are packed into the green and blue channels of one image
+		metallic, roughness := 1.0, 1.0
+		if pbr.MetallicFactor != nil {
+			metallic = *pbr.MetallicFactor
+		}
+		if pbr.RoughnessFactor != nil {
+			roughness = *pbr.RoughnessFactor
+		}
+		if p.Metallic, err = d.scaled(pbr.MetallicRoughnessTexture, true, 2, grey(metallic)); err != nil {
+			return nil, err
+		}
+		if p.Roughness, err = d.scaled(pbr.MetallicRoughnessTexture, true, 1, grey(roughness)); err != nil {
+			return nil, err
+		}
+	}
+
+	ext := m.Extensions
+	if ext.IOR != nil && ext.IOR.IOR != nil {
+		p.IOR = *ext.IOR.IOR
+	}
+	if t := ext.Transmission; t != nil {
+		if p.Transmission, err = d.scaled(t.TransmissionTexture, true, 0, grey(t.TransmissionFactor)); err != nil {
+			return nil, err
+		}
+	}
+	if c := ext.Clearcoat; c != nil {
+		if p.Clearcoat, err = d.scaled(c.ClearcoatTexture, true, 0, grey(c.ClearcoatFactor)); err != nil {
+			return nil, err
+		}
+		if p.ClearcoatRoughness, err = d.scaled(c.ClearcoatRoughnessTexture, true, 1, grey(c.ClearcoatRoughnessFactor)); err != nil {
+			return nil, err
+		}
+	}
+	if s := ext.Sheen; s != nil && len(s.SheenColorFactor) >= 3 {
+		// The sheen here is a single strength, with the colour white
+		p.Sheen = texture.NewSolidValue(max(s.SheenColorFactor[0], s.SheenColorFactor[1], s.SheenColorFactor[2]))
+		p.SheenTint = texture.NewSolidValue(0)
+		if s.SheenColorTexture != nil {
+			d.warnf("sheen colour textures are not supported and are ignored")
+		}
+	}
+
+	surface := material.NewPrincipled(p)
+
+	emission, err := d.emission(m)
+	if err != nil {
+		return nil, err
+	}
+	if emission != nil {
+		return emissive{surface, emission}, nil
+	}
+	return surface, nil
+}
+
+// emission returns the texture of the light an emissive material gives off, or nil if it gives
+// off none
+func (d *decoder) emission(m materialDef) (texture.Texturer, error) {
+	if len(m.EmissiveFactor) < 3 {
+		return nil, nil
+	}
+	factor := color.New(m.EmissiveFactor[0], m.EmissiveFactor[1], m.EmissiveFactor[2])
+	if s := m.Extensions.EmissiveStrength; s != nil && s.EmissiveStrength != nil {
+		factor.Mulf(*s.EmissiveStrength)
+	}
+	if vec3.IsNearZero(factor) {
+		return nil, nil
+	}
+
+	return d.scaled(m.EmissiveTexture, false, -1, factor)
+}
+
+// emissive is a surface that gives off light of its own as well as scattering the light
+// arriving at it. Like a DiffuseLight, it glows from both sides.
+type emissive struct {
+	hitrecord.Scatterer
+	emit texture.Texturer
+}
+
+func (e emissive) Emitted(in ray.Ray, hr hitrecord.HitRecord) color.Color {
+	return e.emit.Value(hr.U(), hr.V(), hr.Point())
+}
+
+// scaled returns the texture info refers to multiplied by factor, or factor alone if info is
+// nil. channel picks out one channel of the image for all three, or -1 keeps the colour.
+func (d *decoder) scaled(info *textureInfo, linear bool, channel int, factor color.Color) (texture.Texturer, error) {
+	if info == nil {
+		return texture.NewSolidColor(factor), nil
+	}
+	if info.TexCoord != 0 {
+		d.warnf("only the first set of texture coordinates is supported")
+	}
+	if _, ok := info.Extensions["KHR_texture_transform"]; ok {
+		d.warnf("texture transforms are not supported and are ignored")
+	}
+
+	tex, err := d.texture(textureKey{info.Index, linear})
+	if err != nil || tex == nil {
+		return texture.NewSolidColor(factor), err
+	}
+	if channel >= 0 {
+		tex = texture.NewChannel(tex, channel)
+	}
+	return texture.NewScaled(tex, factor), nil
+}
+
+// texture returns the texture identified by key, loading it the first time it is asked for.
+// Textures whose image can't be found in the core format are nil.
+func (d *decoder) texture(key textureKey) (texture.Texturer, error) {
+	if tex, ok := d.textures[key]; ok {
+		return tex, nil
+	}
+	if key.index < 0 || key.index >= len(d.doc.Textures) {
+		return nil, fmt.Errorf("texture %d does not exist", key.index)
+	}
+
+	t := d.doc.Textures[key.index]
+	if t.Source == nil {
+		d.warnf("texture %d has no image in a supported format and is ignored", key.index)
+		d.textures[key] = nil
+		return nil, nil
+	}
+	img, err := d.image(*t.Source)
+	if err != nil {
+		return nil, fmt.Errorf("texture %d: %w", key.index, err)
+	}
+
+	var tex texture.Texturer
+	if key.linear {
+		tex = texture.NewImageLinear(img)
+	} else {
+		tex = texture.NewImage(img)
+	}
+
+	wrapS, wrapT := wrapRepeat, wrapRepeat
+	if t.Sampler != nil {
+		if *t.Sampler < 0 || *t.Sampler >= len(d.doc.Samplers) {
+			return nil, fmt.Errorf("texture %d: sampler %d does not exist", key.index, *t.Sampler)
+		}
+		s := d.doc.Samplers[*t.Sampler]
+		if s.WrapS != nil {
+			wrapS = *s.WrapS
+		}
+		if s.WrapT != nil {
+			wrapT = *s.WrapT
+		}
+	}
+	if wrapS != wrapClamp || wrapT != wrapClamp {
+		tex = wrapped{tex, wrapS, wrapT}
+	}
+
+	d.textures[key] = tex
+	return tex, nil
+}
+
+// image decodes image i, a PNG or JPEG file
+func (d *decoder) image(i int) (image.Image, error) {
+	if i < 0 || i >= len(d.doc.Images) {
+		return nil, fmt.Errorf("image %d does not exist", i)
+	}
+	im := d.doc.Images[i]
+
+	var data []byte
+	var err error
+	if im.BufferView != nil {
+		data, _, err = d.bufferView(*im.BufferView)
+	} else {
+		data, err = d.readURI(im.URI)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("image %d: %w", i, err)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("image %d: %w", i, err)
+	}
+	return img, nil
+}
+
+// wrapped repeats a texture beyond the unit square of texture coordinates, which image textures
+// otherwise clamp to
+type wrapped struct {
+	tex          texture.Texturer
+	wrapS, wrapT int
+}
+
+func (w wrapped) Value(u, v float64, p vec3.Vector3) color.Color {
+	return w.tex.Value(wrap(u, w.wrapS), wrap(v, w.wrapT), p)
+}
+
+// wrap maps the texture coordinate x into 0 to 1 by the sampler's wrap mode
+func wrap(x float64, mode int) float64 {
+	switch mode {
+	case wrapRepeat:
+		return x - math.Floor(x)
+	case wrapMirrored:
+		x = x - 2*math.Floor(x/2)
+		if x > 1 {
+			x = 2 - x
+		}
+		return x
+	default:
+		return x
+	}
+}
+
+func grey(x float64) color.Color {
+	return color.New(x, x, x)
+}
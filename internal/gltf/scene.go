@@ -0,0 +1,393 @@
+package gltf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/sendelivery/go-trace-rays/internal/aabb"
+	"github.com/sendelivery/go-trace-rays/internal/camera"
+	"github.com/sendelivery/go-trace-rays/internal/mat4"
+	"github.com/sendelivery/go-trace-rays/internal/object/bvh"
+	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
+	"github.com/sendelivery/go-trace-rays/internal/object/hittable"
+	"github.com/sendelivery/go-trace-rays/internal/object/material"
+	"github.com/sendelivery/go-trace-rays/internal/object/mesh"
+	"github.com/sendelivery/go-trace-rays/internal/object/transform"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// Primitive modes
+const (
+	modeTriangles     = 4
+	modeTriangleStrip = 5
+	modeTriangleFan   = 6
+)
+
+// scene builds the file's default scene, or its first if it names none
+func (d *decoder) scene() (*Scene, error) {
+	if v := d.doc.Asset.Version; !strings.HasPrefix(v, "2.") {
+		return nil, fmt.Errorf("unsupported glTF version %q", v)
+	}
+	d.checkExtensions()
+	if err := d.buildMaterials(); err != nil {
+		return nil, err
+	}
+
+	roots, err := d.roots()
+	if err != nil {
+		return nil, err
+	}
+
+	w := walker{d: d, meshes: make(map[int]hittable.Hittabler), onPath: make([]bool, len(d.doc.Nodes))}
+	for _, i := range roots {
+		if err := w.walk(i, mat4.Identity()); err != nil {
+			return nil, err
+		}
+	}
+	if len(w.objects) == 0 {
+		return nil, errors.New("scene has no meshes")
+	}
+
+	world := bvh.NewFromObjects(w.objects)
+	if w.camera == nil {
+		w.camera = frame(world.BoundingBox())
+	}
+	return &Scene{World: world, Camera: w.camera, Warnings: d.warnings}, nil
+}
+
+// roots returns the nodes at the top of the scene's hierarchy
+func (d *decoder) roots() ([]int, error) {
+	if len(d.doc.Scenes) == 0 {
+		// Without scenes, every node that isn't a child of another is drawn
+		child := make([]bool, len(d.doc.Nodes))
+		for _, n := range d.doc.Nodes {
+			for _, c := range n.Children {
+				if c >= 0 && c < len(child) {
+					child[c] = true
+				}
+			}
+		}
+		var roots []int
+		for i, isChild := range child {
+			if !isChild {
+				roots = append(roots, i)
+			}
+		}
+		return roots, nil
+	}
+
+	scene := 0
+	if d.doc.Scene != nil {
+		scene = *d.doc.Scene
+	}
+	if scene < 0 || scene >= len(d.doc.Scenes) {
+		return nil, fmt.Errorf("scene %d does not exist", scene)
+	}
+	return d.doc.Scenes[scene].Nodes, nil
+}
+
+// walker places the meshes and finds the camera of a node hierarchy
+type walker struct {
+	d       *decoder
+	meshes  map[int]hittable.Hittabler // Each mesh is built once and instanced by every node using it
+	onPath  []bool                     // Nodes between the root and the current node, to catch cycles
+	objects []hittable.Hittabler
+	camera  *camera.Camera
+}
+
+// walk adds node i, whose parent is placed in the world by parent, and its children
+func (w *walker) walk(i int, parent mat4.Matrix4) error {
+	if i < 0 || i >= len(w.d.doc.Nodes) {
+		return fmt.Errorf("node %d does not exist", i)
+	}
+	if w.onPath[i] {
+		return fmt.Errorf("node %d is its own ancestor", i)
+	}
+	w.onPath[i] = true
+	defer func() { w.onPath[i] = false }()
+
+	n := w.d.doc.Nodes[i]
+	local, err := n.matrix()
+	if err != nil {
+		return fmt.Errorf("node %d: %w", i, err)
+	}
+	m := mat4.Mul(parent, local)
+
+	if n.Mesh != nil {
+		object, err := w.mesh(*n.Mesh)
+		if err != nil {
+			return err
+		}
+		if object != nil {
+			if !mat4.Equal(m, mat4.Identity(), 0) {
+				tr, err := transform.New(object, m)
+				if err != nil {
+					w.d.warnf("node %d has a degenerate transform and is skipped", i)
+					object = nil
+				} else {
+					object = tr
+				}
+			}
+			if object != nil {
+				w.objects = append(w.objects, object)
+			}
+		}
+	}
+
+	if n.Camera != nil && w.camera == nil {
+		if w.camera, err = w.d.camera(*n.Camera, m); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range n.Children {
+		if err := w.walk(c, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matrix returns the node's transform relative to its parent
+func (n node) matrix() (mat4.Matrix4, error) {
+	if n.Matrix != nil {
+		if len(n.Matrix) != 16 {
+			return mat4.Matrix4{}, fmt.Errorf("matrix has %d elements, want 16", len(n.Matrix))
+		}
+		var m mat4.Matrix4
+		for col := range 4 {
+			for row := range 4 {
+				m[row][col] = n.Matrix[col*4+row]
+			}
+		}
+		return m, nil
+	}
+
+	t, r, s := vec3.New(0, 0, 0), mat4.IdentityRotation(), vec3.New(1, 1, 1)
+	switch {
+	case n.Translation == nil:
+	case len(n.Translation) == 3:
+		t = vec3.New(n.Translation[0], n.Translation[1], n.Translation[2])
+	default:
+		return mat4.Matrix4{}, fmt.Errorf("translation has %d elements, want 3", len(n.Translation))
+	}
+	switch {
+	case n.Rotation == nil:
+	case len(n.Rotation) == 4:
+		r = mat4.Quaternion{W: n.Rotation[3], X: n.Rotation[0], Y: n.Rotation[1], Z: n.Rotation[2]}.Normalize()
+	default:
+		return mat4.Matrix4{}, fmt.Errorf("rotation has %d elements, want 4", len(n.Rotation))
+	}
+	switch {
+	case n.Scale == nil:
+	case len(n.Scale) == 3:
+		s = vec3.New(n.Scale[0], n.Scale[1], n.Scale[2])
+	default:
+		return mat4.Matrix4{}, fmt.Errorf("scale has %d elements, want 3", len(n.Scale))
+	}
+	return mat4.TRS(t, r, s), nil
+}
+
+// mesh returns mesh i with its primitives gathered into one hittable, building it the first
+// time it is asked for. Meshes with nothing to draw are nil.
+func (w *walker) mesh(i int) (hittable.Hittabler, error) {
+	if object, ok := w.meshes[i]; ok {
+		return object, nil
+	}
+	if i < 0 || i >= len(w.d.doc.Meshes) {
+		return nil, fmt.Errorf("mesh %d does not exist", i)
+	}
+
+	var primitives []hittable.Hittabler
+	for j, p := range w.d.doc.Meshes[i].Primitives {
+		msh, err := w.d.primitive(p)
+		if err != nil {
+			return nil, fmt.Errorf("mesh %d primitive %d: %w", i, j, err)
+		}
+		if msh != nil {
+			primitives = append(primitives, msh)
+		}
+	}
+
+	var object hittable.Hittabler
+	switch len(primitives) {
+	case 0:
+	case 1:
+		object = primitives[0]
+	default:
+		object = bvh.NewFromObjects(primitives)
+	}
+	w.meshes[i] = object
+	return object, nil
+}
+
+// primitive returns the triangles of p, or nil if it draws none
+func (d *decoder) primitive(p primitive) (*mesh.Mesh, error) {
+	mode := modeTriangles
+	if p.Mode != nil {
+		mode = *p.Mode
+	}
+	if mode != modeTriangles && mode != modeTriangleStrip && mode != modeTriangleFan {
+		d.warnf("primitives drawing points or lines are not supported and are skipped")
+		return nil, nil
+	}
+	position, ok := p.Attributes["POSITION"]
+	if !ok {
+		d.warnf("primitives without positions are skipped")
+		return nil, nil
+	}
+
+	var vb mesh.VertexBuffer
+	positions, err := d.vectors(position, "POSITION")
+	if err != nil {
+		return nil, err
+	}
+	vb.Positions = positions
+
+	if i, ok := p.Attributes["NORMAL"]; ok {
+		if vb.Normals, err = d.vectors(i, "NORMAL"); err != nil {
+			return nil, err
+		}
+		for k, n := range vb.Normals {
+			if !vec3.IsNearZero(n) {
+				vb.Normals[k] = vec3.UnitVector(n)
+			}
+		}
+	}
+
+	if i, ok := p.Attributes["TEXCOORD_0"]; ok {
+		uv, n, err := d.read(i)
+		if err != nil {
+			return nil, err
+		}
+		if n != 2 {
+			return nil, fmt.Errorf("TEXCOORD_0 has %d components, want 2", n)
+		}
+		// glTF measures v down from the top of the image, image textures up from the bottom
+		vb.UVs = make([]mesh.UV, len(uv)/2)
+		for k := range vb.UVs {
+			vb.UVs[k] = mesh.UV{U: uv[2*k], V: 1 - uv[2*k+1]}
+		}
+	}
+
+	var indices []int
+	if p.Indices != nil {
+		values, n, err := d.read(*p.Indices)
+		if err != nil {
+			return nil, err
+		}
+		if n != 1 {
+			return nil, fmt.Errorf("indices have %d components, want 1", n)
+		}
+		indices = make([]int, len(values))
+		for k, v := range values {
+			indices[k] = int(v)
+		}
+	} else {
+		indices = make([]int, len(vb.Positions))
+		for k := range indices {
+			indices[k] = k
+		}
+	}
+	indices = triangles(indices, mode)
+	if len(indices) == 0 {
+		return nil, nil
+	}
+
+	var mat hitrecord.Scatterer
+	if p.Material != nil {
+		if *p.Material < 0 || *p.Material >= len(d.materials) {
+			return nil, fmt.Errorf("material %d does not exist", *p.Material)
+		}
+		mat = d.materials[*p.Material]
+	} else {
+		if d.defaultMaterial == nil {
+			d.defaultMaterial = material.NewPrincipled(defaultParams())
+		}
+		mat = d.defaultMaterial
+	}
+
+	return mesh.New(&vb, indices, mat)
+}
+
+// vectors returns accessor i, which must hold three component vectors, as vectors
+func (d *decoder) vectors(i int, name string) ([]vec3.Vector3, error) {
+	values, n, err := d.read(i)
+	if err != nil {
+		return nil, err
+	}
+	if n != 3 {
+		return nil, fmt.Errorf("%s has %d components, want 3", name, n)
+	}
+	out := make([]vec3.Vector3, len(values)/3)
+	for k := range out {
+		out[k] = vec3.New(values[3*k], values[3*k+1], values[3*k+2])
+	}
+	return out, nil
+}
+
+// triangles returns the indices of a strip or fan of triangles as separate triangles, three
+// indices each, keeping their winding
+func triangles(indices []int, mode int) []int {
+	switch mode {
+	case modeTriangleStrip:
+		var out []int
+		for k := 0; k+2 < len(indices); k++ {
+			if k%2 == 0 {
+				out = append(out, indices[k], indices[k+1], indices[k+2])
+			} else {
+				out = append(out, indices[k+1], indices[k], indices[k+2])
+			}
+		}
+		return out
+	case modeTriangleFan:
+		var out []int
+		for k := 1; k+1 < len(indices); k++ {
+			out = append(out, indices[0], indices[k], indices[k+1])
+		}
+		return out
+	default:
+		return indices[:len(indices)-len(indices)%3]
+	}
+}
+
+// camera returns a camera for camera i, placed in the world by m. glTF cameras look down their
+// -Z axis with +Y up.
+func (d *decoder) camera(i int, m mat4.Matrix4) (*camera.Camera, error) {
+	if i < 0 || i >= len(d.doc.Cameras) {
+		return nil, fmt.Errorf("camera %d does not exist", i)
+	}
+	c := d.doc.Cameras[i]
+	if c.Type != "perspective" || c.Perspective == nil {
+		d.warnf("%s cameras are not supported and are ignored", c.Type)
+		return nil, nil
+	}
+
+	cam := camera.New()
+	cam.LookFrom = m.Point(vec3.New(0, 0, 0))
+	cam.LookAt = m.Point(vec3.New(0, 0, -1))
+	cam.VUp = vec3.UnitVector(m.Direction(vec3.New(0, 1, 0)))
+	cam.VerticalFov = c.Perspective.Yfov * 180 / math.Pi
+	if r := c.Perspective.AspectRatio; r != nil && *r > 0 {
+		cam.AspectRatio = *r
+	}
+	return cam, nil
+}
+
+// frame returns a camera looking at the whole of bb from the front, down -Z
+func frame(bb aabb.AABB) *camera.Camera {
+	lo := vec3.New(bb.X.Min, bb.Y.Min, bb.Z.Min)
+	hi := vec3.New(bb.X.Max, bb.Y.Max, bb.Z.Max)
+	centre := vec3.Mulf(vec3.Add(lo, hi), 0.5)
+	radius := vec3.Sub(hi, lo).Length() / 2
+
+	cam := camera.New()
+	cam.VerticalFov = 40
+	distance := radius / math.Sin(cam.VerticalFov/2*math.Pi/180)
+	cam.LookFrom = vec3.Add(centre, vec3.Mulf(vec3.UnitVector(vec3.New(0, 0.3, 1)), distance))
+	cam.LookAt = centre
+	cam.FocusDistance = distance
+	return cam
+}
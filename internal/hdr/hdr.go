@@ -0,0 +1,86 @@
+// Package hdr reads high dynamic range images of linear radiance, as written by the hdr and pfm
+// encoders, for lighting scenes with environment maps
+package hdr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sendelivery/go-trace-rays/internal/color"
+)
+
+// maxPixels is the largest image the decoders read, 16384x8192, well beyond the environment
+// maps in use, so that a corrupt header can't ask for more memory than a real image would
+const maxPixels = 16384 * 8192
+
+// Image is a grid of linear radiance values, stored a row at a time from the top
+type Image struct {
+	Width, Height int
+	Pix           []color.Color
+}
+
+// New returns a black image of the given size
+func New(width, height int) *Image {
+	return &Image{width, height, make([]color.Color, width*height)}
+}
+
+// At returns the pixel in column x of row y, counting rows from the top
+func (img *Image) At(x, y int) color.Color {
+	return img.Pix[y*img.Width+x]
+}
+
+func (img *Image) Set(x, y int, col color.Color) {
+	img.Pix[y*img.Width+x] = col
+}
+
+// checkSize returns an error for image sizes from a file header that are invalid or too large
+func checkSize(width, height int) error {
+	if width <= 0 || height <= 0 {
+		return fmt.Errorf("invalid image size %dx%d", width, height)
+	}
+	if width > maxPixels/height {
+		return fmt.Errorf("image size %dx%d is larger than the %d pixels supported", width, height, maxPixels)
+	}
+	return nil
+}
+
+// Supported reports whether path has the extension of a format Load can read
+func Supported(path string) bool {
+	return decoder(path) != nil
+}
+
+// Load reads a Radiance RGBE (.hdr or .pic) or Portable Float Map (.pfm) file, choosing the
+// format by the file's extension
+func Load(path string) (*Image, error) {
+	decode := decoder(path)
+	if decode == nil {
+		return nil, fmt.Errorf("%s: unknown HDR image format, expected .hdr or .pfm", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, err := decode(bufio.NewReader(f))
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", path, err)
+	}
+	return img, nil
+}
+
+// decoder returns the decoder for path's extension, or nil if there isn't one
+func decoder(path string) func(io.Reader) (*Image, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".hdr", ".pic":
+		return DecodeRGBE
+	case ".pfm":
+		return DecodePFM
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package hdr_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/encode"
+	"github.com/sendelivery/go-trace-rays/internal/hdr"
+	"github.com/sendelivery/go-trace-rays/internal/image"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// newImage returns a 3x2 image with a distinct colour in every pixel, some brighter than 1
+func newImage(t *testing.T) *image.Image {
+	t.Helper()
+
+	img := image.New(3, 2)
+	for y := range 2 {
+		for x := range 3 {
+			col := color.New(float64(x)+0.25, float64(y)*10+0.5, 0.125*float64(x+y))
+			if err := img.Add(image.NewPixelCoord(x, y), col); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return &img
+}
+
+// The decoders read back what the encoders write, within the precision of the format
+func TestRoundTrip(t *testing.T) {
+	tests := map[string]struct {
+		enc       encode.Encoder
+		decode    func(io.Reader) (*hdr.Image, error)
+		tolerance float64 // Relative to the brightest channel of each pixel
+	}{
+		"rgbe": {enc: encode.HDR{}, decode: hdr.DecodeRGBE, tolerance: 1.0 / 128},
+		"pfm":  {enc: encode.PFM{}, decode: hdr.DecodePFM, tolerance: 1e-6},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			src := newImage(t)
+			var buf bytes.Buffer
+			if err := tc.enc.Encode(&buf, src); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got, err := tc.decode(&buf)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Width != 3 || got.Height != 2 {
+				t.Fatalf("unexpected size, got=%dx%d. want=3x2.", got.Width, got.Height)
+			}
+
+			for y := range 2 {
+				for x := range 3 {
+					want, _ := src.Get(image.NewPixelCoord(x, y))
+					col := got.At(x, y)
+					limit := tc.tolerance * max(want.X(), want.Y(), want.Z())
+					if vec3.Sub(col, want).Length() > limit {
+						t.Errorf("unexpected pixel %d, %d, got=%q. want=%q.", x, y, &col, &want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRunLengthEncoded(t *testing.T) {
+	// A 10 pixel row, each channel written as a run of 10 and, for blue, literals then a run
+	var data bytes.Buffer
+	data.WriteString("#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y 1 +X 10\n")
+	data.Write([]byte{2, 2, 0, 10})
+	data.Write([]byte{128 + 10, 128})          // red
+	data.Write([]byte{128 + 10, 64})           // green
+	data.Write([]byte{2, 0, 128, 128 + 8, 32}) // blue
+	data.Write([]byte{128 + 10, 129})          // exponent
+
+	img, err := hdr.DecodeRGBE(&data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		x    int
+		want color.Color
+	}{
+		{x: 0, want: color.New(128.5/128, 64.5/128, 0.5/128)},
+		{x: 1, want: color.New(128.5/128, 64.5/128, 128.5/128)},
+		{x: 9, want: color.New(128.5/128, 64.5/128, 32.5/128)},
+	}
+	for _, tc := range tests {
+		if got := img.At(tc.x, 0); !vec3.Equal(got, tc.want) {
+			t.Errorf("unexpected pixel %d, got=%q. want=%q.", tc.x, &got, &tc.want)
+		}
+	}
+}
+
+func TestGreyscalePFM(t *testing.T) {
+	// Big-endian, one value per pixel, bottom row first
+	var data bytes.Buffer
+	data.WriteString("Pf\n1 2\n1.0\n")
+	for _, v := range []float32{2, 0.5} {
+		binary.Write(&data, binary.BigEndian, math.Float32bits(v))
+	}
+
+	img, err := hdr.DecodePFM(&data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if top, want := img.At(0, 0), color.New(0.5, 0.5, 0.5); !vec3.Equal(top, want) {
+		t.Errorf("unexpected top pixel, got=%q. want=%q.", &top, &want)
+	}
+	if bottom, want := img.At(0, 1), color.New(2, 2, 2); !vec3.Equal(bottom, want) {
+		t.Errorf("unexpected bottom pixel, got=%q. want=%q.", &bottom, &want)
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	tests := map[string]struct {
+		src    string
+		decode func(io.Reader) (*hdr.Image, error)
+	}{
+		"rgbe magic":       {src: "P6\n1 1\n255\n", decode: hdr.DecodeRGBE},
+		"rgbe format":      {src: "#?RADIANCE\nFORMAT=32-bit_rle_xyze\n\n-Y 1 +X 1\n", decode: hdr.DecodeRGBE},
+		"rgbe orientation": {src: "#?RADIANCE\n\n+Y 1 +X 1\n", decode: hdr.DecodeRGBE},
+		"rgbe truncated":   {src: "#?RADIANCE\n\n-Y 2 +X 1\n\x80\x80\x80\x81", decode: hdr.DecodeRGBE},
+		"pfm magic":        {src: "P6\n1 1\n255\n", decode: hdr.DecodePFM},
+		"pfm truncated":    {src: "PF\n1 1\n-1.0\n\x00\x00", decode: hdr.DecodePFM},
+		"rgbe too large":   {src: "#?RADIANCE\n\n-Y 100000 +X 100000\n", decode: hdr.DecodeRGBE},
+		"rgbe overflow":    {src: "#?RADIANCE\n\n-Y 4611686018427387904 +X 4\n", decode: hdr.DecodeRGBE},
+		"pfm too large":    {src: "PF\n100000 100000\n-1.0\n", decode: hdr.DecodePFM},
+		"pfm short data":   {src: "PF\n4096 4096\n-1.0\n\x00\x00\x00\x00", decode: hdr.DecodePFM},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := tc.decode(strings.NewReader(tc.src)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestLoadUnknownExtension(t *testing.T) {
+	if _, err := hdr.Load("sky.png"); err == nil || !strings.Contains(err.Error(), "unknown HDR image format") {
+		t.Errorf("unexpected error, got=%v.", err)
+	}
+}
+
+func TestRGBE(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]color.Color{
+		"black":    color.New(0, 0, 0),
+		"unit":     color.New(1, 1, 1),
+		"bright":   color.New(100, 20, 0.5),
+		"dim":      color.New(0.001, 0.002, 0.003),
+		"negative": color.New(-1, 0.5, 0.25),
+	}
+
+	for name, col := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got := hdr.FromRGBE(hdr.ToRGBE(col))
+			want := color.New(max(col.X(), 0), max(col.Y(), 0), max(col.Z(), 0))
+
+			// Each component keeps 8 bits relative to the brightest
+			if diff := vec3.Sub(got, want).Length(); diff > max(want.X(), want.Y(), want.Z())/128 {
+				t.Errorf("unexpected colour, got=%v. want=%v.", got, want)
+			}
+		})
+	}
+}
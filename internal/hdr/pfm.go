@@ -0,0 +1,82 @@
+package hdr
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/sendelivery/go-trace-rays/internal/color"
+)
+
+// DecodePFM reads a Portable Float Map, either colour (PF) or greyscale (Pf). The sign of the
+// scale in the header gives the byte order, negative for little-endian.
+func DecodePFM(r io.Reader) (*Image, error) {
+	br := bufio.NewReader(r)
+
+	var magic string
+	var width, height int
+	var scale float64
+	if _, err := fmt.Fscan(br, &magic, &width, &height, &scale); err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+
+	channels := 0
+	switch magic {
+	case "PF":
+		channels = 3
+	case "Pf":
+		channels = 1
+	default:
+		return nil, errors.New("not a Portable Float Map")
+	}
+	if err := checkSize(width, height); err != nil {
+		return nil, err
+	}
+	if scale == 0 {
+		return nil, errors.New("invalid scale 0")
+	}
+
+	// A single whitespace character separates the header from the data
+	if _, err := br.ReadByte(); err != nil {
+		return nil, err
+	}
+
+	var order binary.ByteOrder = binary.BigEndian
+	if scale < 0 {
+		order = binary.LittleEndian
+	}
+
+	// The data is read before the image is made, so a header claiming more pixels than the file
+	// holds is found out without allocating room for them
+	rowSize := 4 * channels * width
+	data, err := io.ReadAll(io.LimitReader(br, int64(rowSize*height)))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < rowSize*height {
+		return nil, fmt.Errorf("image data is %d bytes long, its %dx%d size needs %d", len(data), width, height, rowSize*height)
+	}
+
+	img := New(width, height)
+	var row []byte
+	value := func(i int) float64 {
+		return float64(math.Float32frombits(order.Uint32(row[4*i:])))
+	}
+
+	// Rows are stored from the bottom of the image up
+	for y := height - 1; y >= 0; y-- {
+		row = data[(height-1-y)*rowSize : (height-y)*rowSize]
+		for x := range width {
+			if channels == 1 {
+				v := value(x)
+				img.Set(x, y, color.New(v, v, v))
+				continue
+			}
+			img.Set(x, y, color.New(value(3*x), value(3*x+1), value(3*x+2)))
+		}
+	}
+	return img, nil
+}
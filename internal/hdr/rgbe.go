@@ -0,0 +1,159 @@
+package hdr
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/sendelivery/go-trace-rays/internal/color"
+)
+
+// DecodeRGBE reads a Radiance RGBE image. Scanlines may be flat or run length encoded. Only
+// the standard orientation, rows from the top and columns from the left, is supported.
+func DecodeRGBE(r io.Reader) (*Image, error) {
+	br := bufio.NewReader(r)
+
+	magic, err := readLine(br)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(magic, "#?") {
+		return nil, errors.New("not a Radiance RGBE image")
+	}
+	for {
+		line, err := readLine(br)
+		if err != nil {
+			return nil, err
+		}
+		if line == "" {
+			break
+		}
+		if format, ok := strings.CutPrefix(line, "FORMAT="); ok && format != "32-bit_rle_rgbe" {
+			return nil, fmt.Errorf("unsupported pixel format %q", format)
+		}
+	}
+
+	res, err := readLine(br)
+	if err != nil {
+		return nil, err
+	}
+	var width, height int
+	if _, err := fmt.Sscanf(res, "-Y %d +X %d", &height, &width); err != nil {
+		return nil, fmt.Errorf("unsupported resolution line %q", res)
+	}
+	if err := checkSize(width, height); err != nil {
+		return nil, err
+	}
+
+	// The image grows a row at a time, so a header claiming more rows than the file holds
+	// costs no more than the rows that are there
+	img := &Image{Width: width, Height: height, Pix: make([]color.Color, 0, min(width*height, 1<<20))}
+	scanline := make([]byte, 4*width)
+	for y := range height {
+		if err := readScanline(br, scanline); err != nil {
+			return nil, fmt.Errorf("row %d: %w", y, err)
+		}
+		for x := range width {
+			img.Pix = append(img.Pix, FromRGBE([4]byte(scanline[4*x:4*x+4])))
+		}
+	}
+	return img, nil
+}
+
+func readLine(br *bufio.Reader) (string, error) {
+	line, err := br.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("reading header: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readScanline fills buf with one row of RGBE pixels. Run length encoded rows start with the
+// bytes 2, 2 and the row width, then hold each of the four channels in turn as runs.
+func readScanline(br *bufio.Reader, buf []byte) error {
+	width := len(buf) / 4
+	head, err := br.Peek(4)
+	if err != nil {
+		return err
+	}
+	if width < 8 || width > 0x7fff || head[0] != 2 || head[1] != 2 || head[2]&0x80 != 0 {
+		_, err := io.ReadFull(br, buf)
+		return err
+	}
+	if int(head[2])<<8|int(head[3]) != width {
+		return errors.New("run length encoded row has the wrong width")
+	}
+	br.Discard(4)
+
+	for c := range 4 {
+		for x := 0; x < width; {
+			n, err := br.ReadByte()
+			if err != nil {
+				return err
+			}
+
+			// Counts above 128 are runs of one repeated value, others are literal values
+			if n > 128 {
+				count := int(n) - 128
+				if x+count > width {
+					return errors.New("run overflows the row")
+				}
+				v, err := br.ReadByte()
+				if err != nil {
+					return err
+				}
+				for range count {
+					buf[4*x+c] = v
+					x++
+				}
+				continue
+			}
+
+			count := int(n)
+			if count == 0 || x+count > width {
+				return errors.New("invalid literal length")
+			}
+			for range count {
+				v, err := br.ReadByte()
+				if err != nil {
+					return err
+				}
+				buf[4*x+c] = v
+				x++
+			}
+		}
+	}
+	return nil
+}
+
+// ToRGBE packs a linear colour into three mantissas sharing the exponent of the brightest
+// component, clamping negative components to 0
+func ToRGBE(col color.Color) [4]byte {
+	r, g, b := max(col.X(), 0), max(col.Y(), 0), max(col.Z(), 0)
+
+	v := max(r, g, b)
+	if v < 1e-32 {
+		return [4]byte{}
+	}
+
+	frac, exp := math.Frexp(v)
+	scale := frac * 256 / v
+
+	return [4]byte{byte(r * scale), byte(g * scale), byte(b * scale), byte(exp + 128)}
+}
+
+// FromRGBE unpacks three mantissas sharing an exponent into a linear colour
+func FromRGBE(p [4]byte) color.Color {
+	if p[3] == 0 {
+		return color.Black
+	}
+	f := math.Ldexp(1, int(p[3])-(128+8))
+	return color.New(
+		(float64(p[0])+0.5)*f,
+		(float64(p[1])+0.5)*f,
+		(float64(p[2])+0.5)*f,
+	)
+}
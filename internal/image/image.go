@@ -2,10 +2,8 @@ package image
 
 import (
 	"fmt"
-	"sync"
 
 	"github.com/sendelivery/go-trace-rays/internal/color"
-	"github.com/sendelivery/go-trace-rays/internal/interval"
 )
 
 type PixelCoord struct {
@@ -40,37 +38,110 @@ func NewChunk(start, end PixelCoord) Chunk {
 	return Chunk{start, end}
 }
 
+// Image is a dense framebuffer accumulating the sum of the samples taken for every pixel,
+// alongside how many samples that sum is made of. Each colour channel is stored in its own
+// contiguous float32 plane, indexed in scanline order.
+//
+// Pixels are independent of one another, so goroutines writing to disjoint regions of the
+// image, such as separate chunks, need no locking.
 type Image struct {
-	width  interval.Interval
-	height interval.Interval
-	m      sync.Map
+	width, height int
+	r, g, b       []float32
+	samples       []uint32
 }
 
 func New(width, height int) Image {
+	n := width * height
 	return Image{
-		width:  interval.New(0, float64(width-1)),
-		height: interval.New(0, float64(height-1)),
+		width:   width,
+		height:  height,
+		r:       make([]float32, n),
+		g:       make([]float32, n),
+		b:       make([]float32, n),
+		samples: make([]uint32, n),
 	}
 }
 
+// Width returns the image width in pixels
+func (i *Image) Width() int { return i.width }
+
+// Height returns the image height in pixels
+func (i *Image) Height() int { return i.height }
+
+// index returns the offset of pc into the channel planes
+func (i *Image) index(pc PixelCoord) (int, error) {
+	if pc.y < 0 || pc.y >= i.height {
+		return 0, fmt.Errorf("y %d is outside of image bounds", pc.y)
+	}
+	if pc.x < 0 || pc.x >= i.width {
+		return 0, fmt.Errorf("x %d is outside of image bounds", pc.x)
+	}
+	return pc.y*i.width + pc.x, nil
+}
+
+// Add sets the pixel at pc to col, replacing any samples accumulated so far.
 func (i *Image) Add(pc PixelCoord, col color.Color) error {
-	if !i.height.Contains(float64(pc.y)) {
-		return fmt.Errorf("y %d is outside of image bounds", pc.y)
+	idx, err := i.index(pc)
+	if err != nil {
+		return err
 	}
-	if !i.width.Contains(float64(pc.x)) {
-		return fmt.Errorf("x %d is outside of image bounds", pc.x)
+
+	i.r[idx] = float32(col.X())
+	i.g[idx] = float32(col.Y())
+	i.b[idx] = float32(col.Z())
+	i.samples[idx] = 1
+	return nil
+}
+
+// Accumulate adds sum, the total of n samples, to the pixel at pc. A render may be resumed or
+// refined by accumulating further samples into the same image.
+func (i *Image) Accumulate(pc PixelCoord, sum color.Color, n int) error {
+	idx, err := i.index(pc)
+	if err != nil {
+		return err
 	}
 
-	i.m.Store(pc, col)
+	i.r[idx] += float32(sum.X())
+	i.g[idx] += float32(sum.Y())
+	i.b[idx] += float32(sum.Z())
+	i.samples[idx] += uint32(n)
 	return nil
 }
 
+// Get returns the average of the samples accumulated at pc. The bool is false if pc is out of
+// bounds or no samples have been accumulated there yet.
 func (i *Image) Get(pc PixelCoord) (color.Color, bool) {
-	px, ok := i.m.Load(pc)
-	if !ok {
+	idx, err := i.index(pc)
+	if err != nil || i.samples[idx] == 0 {
 		return color.Black, false
 	}
 
-	pxc, ok := px.(color.Color)
-	return pxc, ok
+	scale := 1 / float64(i.samples[idx])
+	return color.New(
+		float64(i.r[idx])*scale,
+		float64(i.g[idx])*scale,
+		float64(i.b[idx])*scale,
+	), true
+}
+
+// FillEmpty sets every pixel that has no samples yet to col, for example to write out a render
+// that was stopped part way through.
+func (i *Image) FillEmpty(col color.Color) {
+	for idx, n := range i.samples {
+		if n == 0 {
+			i.r[idx] = float32(col.X())
+			i.g[idx] = float32(col.Y())
+			i.b[idx] = float32(col.Z())
+			i.samples[idx] = 1
+		}
+	}
+}
+
+// Samples returns how many samples have been accumulated at pc.
+func (i *Image) Samples(pc PixelCoord) int {
+	idx, err := i.index(pc)
+	if err != nil {
+		return 0
+	}
+	return int(i.samples[idx])
 }
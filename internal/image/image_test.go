@@ -0,0 +1,82 @@
+package image_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/image"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+func TestAccumulate(t *testing.T) {
+	img := image.New(2, 2)
+	pc := image.NewPixelCoord(1, 0)
+
+	if _, ok := img.Get(pc); ok {
+		t.Error("expected a pixel without samples not to be ok")
+	}
+
+	if err := img.Accumulate(pc, color.New(2, 4, 6), 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := img.Accumulate(pc, color.New(1, 2, 3), 2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, ok := img.Get(pc)
+	want := color.New(0.75, 1.5, 2.25)
+	if !ok || !vec3.Equal(got, want) {
+		t.Errorf("unexpected result, got=%q. want=%q.", &got, &want)
+	}
+	if n := img.Samples(pc); n != 4 {
+		t.Errorf("unexpected sample count, got=%d. want=4.", n)
+	}
+
+	// Add replaces everything accumulated so far
+	if err := img.Add(pc, color.White); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, _ := img.Get(pc); !vec3.Equal(got, color.White) {
+		t.Errorf("unexpected result, got=%q. want=%q.", &got, &color.White)
+	}
+}
+
+func TestOutOfBounds(t *testing.T) {
+	img := image.New(2, 2)
+
+	for _, pc := range []image.PixelCoord{
+		image.NewPixelCoord(-1, 0),
+		image.NewPixelCoord(2, 0),
+		image.NewPixelCoord(0, 2),
+	} {
+		if err := img.Add(pc, color.White); err == nil {
+			t.Errorf("expected an error for %d, %d", pc.X(), pc.Y())
+		}
+	}
+}
+
+// Run with -race to check that disjoint rows can be written concurrently
+func TestConcurrentRows(t *testing.T) {
+	img := image.New(64, 64)
+
+	var wg sync.WaitGroup
+	for y := range img.Height() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for x := range img.Width() {
+				img.Accumulate(image.NewPixelCoord(x, y), color.White, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	for y := range img.Height() {
+		for x := range img.Width() {
+			if _, ok := img.Get(image.NewPixelCoord(x, y)); !ok {
+				t.Fatalf("missing pixel at %d, %d", x, y)
+			}
+		}
+	}
+}
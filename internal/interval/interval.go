@@ -14,6 +14,11 @@ func New(min, max float64) Interval {
 	return Interval{Min: min, Max: max}
 }
 
+// NewFromIntervals returns the tightest interval enclosing both a and b.
+func NewFromIntervals(a, b Interval) Interval {
+	return Interval{Min: min(a.Min, b.Min), Max: max(a.Max, b.Max)}
+}
+
 func (i Interval) Size() float64 {
 	return i.Max - i.Min
 }
@@ -36,6 +41,12 @@ func (i Interval) Clamp(x float64) float64 {
 	return x
 }
 
+// Expand returns a copy of the interval padded by delta, half on either side.
+func (i Interval) Expand(delta float64) Interval {
+	padding := delta / 2
+	return Interval{Min: i.Min - padding, Max: i.Max + padding}
+}
+
 // EmptyInterval is an interval within which nothing lies
 var EmptyInterval = Default()
 
@@ -0,0 +1,147 @@
+// Package mat4 provides 4x4 matrices for affine transforms of points and directions in 3D, and
+// quaternions for building and interpolating rotations. Angles are in radians.
+package mat4
+
+import (
+	"math"
+
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// Matrix4 is a row-major 4x4 matrix. Points are treated as column vectors, so the matrix
+// Mul(a, b) applies b first and then a.
+type Matrix4 [4][4]float64
+
+func Identity() Matrix4 {
+	return Matrix4{
+		{1, 0, 0, 0},
+		{0, 1, 0, 0},
+		{0, 0, 1, 0},
+		{0, 0, 0, 1},
+	}
+}
+
+// Translate returns the matrix moving points by v
+func Translate(v vec3.Vector3) Matrix4 {
+	m := Identity()
+	m[0][3], m[1][3], m[2][3] = v.X(), v.Y(), v.Z()
+	return m
+}
+
+// Scale returns the matrix scaling each axis by the matching component of v
+func Scale(v vec3.Vector3) Matrix4 {
+	m := Identity()
+	m[0][0], m[1][1], m[2][2] = v.X(), v.Y(), v.Z()
+	return m
+}
+
+// Rotate returns the matrix rotating angle radians anticlockwise about axis, when looking
+// back down the axis towards the origin
+func Rotate(axis vec3.Vector3, angle float64) Matrix4 {
+	return FromAxisAngle(axis, angle).Matrix()
+}
+
+// TRS returns the matrix that scales by s, then rotates by r, then translates by t
+func TRS(t vec3.Vector3, r Quaternion, s vec3.Vector3) Matrix4 {
+	return Mul(Translate(t), Mul(r.Matrix(), Scale(s)))
+}
+
+// Mul returns the product a * b, the transform applying b and then a
+func Mul(a, b Matrix4) Matrix4 {
+	var m Matrix4
+	for i := range 4 {
+		for j := range 4 {
+			for k := range 4 {
+				m[i][j] += a[i][k] * b[k][j]
+			}
+		}
+	}
+	return m
+}
+
+func (m Matrix4) Transpose() Matrix4 {
+	var t Matrix4
+	for i := range 4 {
+		for j := range 4 {
+			t[i][j] = m[j][i]
+		}
+	}
+	return t
+}
+
+// Inverse returns the inverse of m. ok is false if m is singular, for example a scale by 0.
+func (m Matrix4) Inverse() (inv Matrix4, ok bool) {
+	// Gauss-Jordan elimination with partial pivoting, reducing m to the identity while
+	// applying the same row operations to inv
+	inv = Identity()
+	for col := range 4 {
+		pivot := col
+		for row := col + 1; row < 4; row++ {
+			if math.Abs(m[row][col]) > math.Abs(m[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(m[pivot][col]) < 1e-12 {
+			return Matrix4{}, false
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+		inv[col], inv[pivot] = inv[pivot], inv[col]
+
+		scale := 1 / m[col][col]
+		for j := range 4 {
+			m[col][j] *= scale
+			inv[col][j] *= scale
+		}
+
+		for row := range 4 {
+			if row == col {
+				continue
+			}
+			f := m[row][col]
+			for j := range 4 {
+				m[row][j] -= f * m[col][j]
+				inv[row][j] -= f * inv[col][j]
+			}
+		}
+	}
+	return inv, true
+}
+
+// Point returns the point p transformed by m
+func (m Matrix4) Point(p vec3.Vector3) vec3.Vector3 {
+	x, y, z := p.X(), p.Y(), p.Z()
+	return vec3.New(
+		m[0][0]*x+m[0][1]*y+m[0][2]*z+m[0][3],
+		m[1][0]*x+m[1][1]*y+m[1][2]*z+m[1][3],
+		m[2][0]*x+m[2][1]*y+m[2][2]*z+m[2][3],
+	)
+}
+
+// Direction returns the direction d transformed by m, which unlike a point is not translated
+func (m Matrix4) Direction(d vec3.Vector3) vec3.Vector3 {
+	x, y, z := d.X(), d.Y(), d.Z()
+	return vec3.New(
+		m[0][0]*x+m[0][1]*y+m[0][2]*z,
+		m[1][0]*x+m[1][1]*y+m[1][2]*z,
+		m[2][0]*x+m[2][1]*y+m[2][2]*z,
+	)
+}
+
+// Normal returns the unit surface normal n transformed by the matrix whose inverse is m.
+// Normals must be transformed by the inverse transpose to stay perpendicular to their surface
+// under non-uniform scaling.
+func (m Matrix4) Normal(n vec3.Vector3) vec3.Vector3 {
+	return vec3.UnitVector(m.Transpose().Direction(n))
+}
+
+// Equal reports whether every element of a and b is within epsilon of each other
+func Equal(a, b Matrix4, epsilon float64) bool {
+	for i := range 4 {
+		for j := range 4 {
+			if math.Abs(a[i][j]-b[i][j]) > epsilon {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+package mat4_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sendelivery/go-trace-rays/internal/mat4"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+const epsilon = 1e-9
+
+func near(a, b vec3.Vector3) bool {
+	return vec3.Sub(a, b).Length() < epsilon
+}
+
+func TestTransforms(t *testing.T) {
+	t.Parallel()
+
+	p := vec3.New(1, 2, 3)
+
+	tests := []struct {
+		name      string
+		m         mat4.Matrix4
+		point     vec3.Vector3
+		direction vec3.Vector3
+	}{
+		{name: "identity", m: mat4.Identity(), point: p, direction: p},
+		{name: "translate", m: mat4.Translate(vec3.New(1, -1, 2)), point: vec3.New(2, 1, 5), direction: p},
+		{name: "scale", m: mat4.Scale(vec3.New(2, 3, -1)), point: vec3.New(2, 6, -3), direction: vec3.New(2, 6, -3)},
+		{name: "rotate y", m: mat4.Rotate(vec3.New(0, 1, 0), math.Pi/2), point: vec3.New(3, 2, -1), direction: vec3.New(3, 2, -1)},
+		{name: "rotate z", m: mat4.Rotate(vec3.New(0, 0, 5), math.Pi/2), point: vec3.New(-2, 1, 3), direction: vec3.New(-2, 1, 3)},
+		{
+			name:      "trs",
+			m:         mat4.TRS(vec3.New(10, 0, 0), mat4.FromAxisAngle(vec3.New(1, 0, 0), math.Pi), vec3.New(2, 2, 2)),
+			point:     vec3.New(12, -4, -6),
+			direction: vec3.New(2, -4, -6),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tc.m.Point(p); !near(got, tc.point) {
+				t.Errorf("unexpected point, got=%q. want=%q.", &got, &tc.point)
+			}
+			if got := tc.m.Direction(p); !near(got, tc.direction) {
+				t.Errorf("unexpected direction, got=%q. want=%q.", &got, &tc.direction)
+			}
+
+			inv, ok := tc.m.Inverse()
+			if !ok {
+				t.Fatalf("matrix unexpectedly singular")
+			}
+			if !mat4.Equal(mat4.Mul(tc.m, inv), mat4.Identity(), epsilon) {
+				t.Errorf("m * inverse is not the identity, got=%v.", mat4.Mul(tc.m, inv))
+			}
+			if got := inv.Point(tc.m.Point(p)); !near(got, p) {
+				t.Errorf("inverse did not restore the point, got=%q. want=%q.", &got, &p)
+			}
+		})
+	}
+}
+
+func TestSingular(t *testing.T) {
+	t.Parallel()
+
+	if _, ok := mat4.Scale(vec3.New(1, 0, 1)).Inverse(); ok {
+		t.Errorf("unexpected result, got=%v. want=%v.", ok, false)
+	}
+}
+
+// Normals of a non-uniformly scaled surface must stay perpendicular to it
+func TestNormal(t *testing.T) {
+	t.Parallel()
+
+	m := mat4.Scale(vec3.New(4, 1, 1))
+	inv, _ := m.Inverse()
+
+	// The plane x + y = 0 has normal (1, 1, 0) and contains the direction (1, -1, 0)
+	tangent := m.Direction(vec3.New(1, -1, 0))
+	normal := inv.Normal(vec3.UnitVector(vec3.New(1, 1, 0)))
+
+	if d := vec3.Dot(tangent, normal); math.Abs(d) > epsilon {
+		t.Errorf("normal is not perpendicular to the surface, got dot=%v.", d)
+	}
+	if l := normal.Length(); math.Abs(l-1) > epsilon {
+		t.Errorf("unexpected length, got=%v. want=%v.", l, 1)
+	}
+}
+
+func TestQuaternion(t *testing.T) {
+	t.Parallel()
+
+	v := vec3.New(1, 0, 0)
+
+	euler := mat4.FromEuler(0, math.Pi/2, math.Pi/2)
+	// X rotated about Y goes to -Z, which rotating about Z leaves alone
+	if got, want := euler.Rotate(v), vec3.New(0, 0, -1); !near(got, want) {
+		t.Errorf("unexpected rotation, got=%q. want=%q.", &got, &want)
+	}
+
+	a := mat4.IdentityRotation()
+	b := mat4.FromAxisAngle(vec3.New(0, 0, 1), math.Pi/2)
+
+	tests := []struct {
+		t    float64
+		want vec3.Vector3
+	}{
+		{t: 0, want: v},
+		{t: 0.5, want: vec3.New(math.Sqrt2/2, math.Sqrt2/2, 0)},
+		{t: 1, want: vec3.New(0, 1, 0)},
+	}
+	for _, tc := range tests {
+		if got := mat4.Slerp(a, b, tc.t).Rotate(v); !near(got, tc.want) {
+			t.Errorf("unexpected slerp at %v, got=%q. want=%q.", tc.t, &got, &tc.want)
+		}
+	}
+}
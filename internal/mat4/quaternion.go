@@ -0,0 +1,104 @@
+package mat4
+
+import (
+	"math"
+
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// Quaternion represents a rotation. Only unit quaternions are valid rotations.
+type Quaternion struct {
+	W, X, Y, Z float64
+}
+
+// IdentityRotation returns the quaternion for no rotation
+func IdentityRotation() Quaternion {
+	return Quaternion{W: 1}
+}
+
+// FromAxisAngle returns the quaternion rotating angle radians about axis
+func FromAxisAngle(axis vec3.Vector3, angle float64) Quaternion {
+	a := vec3.UnitVector(axis)
+	s := math.Sin(angle / 2)
+	return Quaternion{W: math.Cos(angle / 2), X: a.X() * s, Y: a.Y() * s, Z: a.Z() * s}
+}
+
+// FromEuler returns the quaternion rotating x radians about the X axis, then y about Y, then z
+// about Z
+func FromEuler(x, y, z float64) Quaternion {
+	qx := FromAxisAngle(vec3.New(1, 0, 0), x)
+	qy := FromAxisAngle(vec3.New(0, 1, 0), y)
+	qz := FromAxisAngle(vec3.New(0, 0, 1), z)
+	return qz.Mul(qy).Mul(qx)
+}
+
+// Mul returns the rotation applying r and then q
+func (q Quaternion) Mul(r Quaternion) Quaternion {
+	return Quaternion{
+		W: q.W*r.W - q.X*r.X - q.Y*r.Y - q.Z*r.Z,
+		X: q.W*r.X + q.X*r.W + q.Y*r.Z - q.Z*r.Y,
+		Y: q.W*r.Y - q.X*r.Z + q.Y*r.W + q.Z*r.X,
+		Z: q.W*r.Z + q.X*r.Y - q.Y*r.X + q.Z*r.W,
+	}
+}
+
+// Conjugate returns the inverse rotation of the unit quaternion q
+func (q Quaternion) Conjugate() Quaternion {
+	return Quaternion{q.W, -q.X, -q.Y, -q.Z}
+}
+
+func (q Quaternion) Dot(r Quaternion) float64 {
+	return q.W*r.W + q.X*r.X + q.Y*r.Y + q.Z*r.Z
+}
+
+func (q Quaternion) Normalize() Quaternion {
+	l := math.Sqrt(q.Dot(q))
+	return Quaternion{q.W / l, q.X / l, q.Y / l, q.Z / l}
+}
+
+// Rotate returns v rotated by q
+func (q Quaternion) Rotate(v vec3.Vector3) vec3.Vector3 {
+	return q.Matrix().Direction(v)
+}
+
+// Matrix returns the rotation matrix equivalent to the unit quaternion q
+func (q Quaternion) Matrix() Matrix4 {
+	w, x, y, z := q.W, q.X, q.Y, q.Z
+	return Matrix4{
+		{1 - 2*(y*y+z*z), 2 * (x*y - w*z), 2 * (x*z + w*y), 0},
+		{2 * (x*y + w*z), 1 - 2*(x*x+z*z), 2 * (y*z - w*x), 0},
+		{2 * (x*z - w*y), 2 * (y*z + w*x), 1 - 2*(x*x+y*y), 0},
+		{0, 0, 0, 1},
+	}
+}
+
+// Slerp spherically interpolates between the rotations a and b, returning a when t is 0 and b
+// when t is 1. It always takes the shortest path between the two.
+func Slerp(a, b Quaternion, t float64) Quaternion {
+	d := a.Dot(b)
+	if d < 0 {
+		// q and -q are the same rotation, flip b so the interpolation takes the short way round
+		b = Quaternion{-b.W, -b.X, -b.Y, -b.Z}
+		d = -d
+	}
+
+	// Nearly identical rotations would divide by almost zero, linear interpolation is accurate
+	if d > 0.9995 {
+		return Quaternion{
+			a.W + t*(b.W-a.W),
+			a.X + t*(b.X-a.X),
+			a.Y + t*(b.Y-a.Y),
+			a.Z + t*(b.Z-a.Z),
+		}.Normalize()
+	}
+
+	theta := math.Acos(d)
+	sa := math.Sin((1-t)*theta) / math.Sin(theta)
+	sb := math.Sin(t*theta) / math.Sin(theta)
+	return Quaternion{
+		sa*a.W + sb*b.W,
+		sa*a.X + sb*b.X,
+		sa*a.Y + sb*b.Y,
+		sa*a.Z + sb*b.Z,
+	}
+}
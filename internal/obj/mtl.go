@@ -0,0 +1,165 @@
+package obj
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
+	"github.com/sendelivery/go-trace-rays/internal/object/material"
+)
+
+// mtlParams holds the subset of MTL statements we translate into materials
+type mtlParams struct {
+	kd, ks    color.Color
+	ns        float64 // Specular exponent
+	ni        float64 // Index of refraction
+	d         float64 // Dissolve, 1 is fully opaque
+	illum     int
+	seenIllum bool
+}
+
+func newMtlParams() mtlParams {
+	return mtlParams{
+		kd: color.New(0.8, 0.8, 0.8),
+		ks: color.Black,
+		ns: 0,
+		ni: 1.5,
+		d:  1,
+	}
+}
+
+// material picks the closest of our materials for the MTL parameters:
+//   - anything transparent (d < 1, or illum 4, 6, 7 or 9) becomes a Dielectric with index Ni
+//   - anything whose specular colour outweighs its diffuse colour becomes a Metal tinted by Ks,
+//     with the fuzz derived from the specular exponent Ns
+//   - everything else becomes a Lambertian with albedo Kd
+func (p mtlParams) material() hitrecord.Scatterer {
+	transparentIllum := p.seenIllum && (p.illum == 4 || p.illum == 6 || p.illum == 7 || p.illum == 9)
+	if p.d < 1 || transparentIllum {
+		return material.NewDielectric(p.ni)
+	}
+
+	if maxComponent(p.ks) > maxComponent(p.kd) {
+		// A common approximation mapping a Phong exponent to a microfacet roughness
+		fuzz := math.Sqrt(2 / (p.ns + 2))
+		return material.NewMetal(p.ks, fuzz)
+	}
+
+	return material.NewLambertian(p.kd)
+}
+
+func maxComponent(c color.Color) float64 {
+	return max(c.X(), c.Y(), c.Z())
+}
+
+// DecodeMTL reads a Wavefront material library, returning its materials by name. name is
+// used to identify the library in errors.
+func DecodeMTL(r io.Reader, name string) (map[string]hitrecord.Scatterer, error) {
+	materials := make(map[string]hitrecord.Scatterer)
+
+	var current string
+	var params mtlParams
+	flush := func() {
+		if current != "" {
+			materials[current] = params.material()
+		}
+	}
+
+	s := bufio.NewScanner(r)
+	line := 0
+	for s.Scan() {
+		line++
+		fields := strings.Fields(stripComment(s.Text()))
+		if len(fields) == 0 {
+			continue
+		}
+
+		var err error
+		switch fields[0] {
+		case "newmtl":
+			if len(fields) < 2 {
+				err = fmt.Errorf("newmtl requires a name")
+				break
+			}
+			flush()
+			current = strings.Join(fields[1:], " ")
+			params = newMtlParams()
+		case "Kd", "Ks", "Ns", "Ni", "d", "Tr", "illum":
+			if current == "" {
+				err = fmt.Errorf("%s appears before any newmtl", fields[0])
+				break
+			}
+			err = params.set(fields[0], fields[1:])
+		}
+
+		if err != nil {
+			return nil, &ParseError{File: name, Line: line, Err: err}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, &ParseError{File: name, Line: line, Err: err}
+	}
+
+	flush()
+	return materials, nil
+}
+
+func (p *mtlParams) set(key string, args []string) error {
+	switch key {
+	case "Kd", "Ks":
+		c, err := parseColor(key, args)
+		if err != nil {
+			return err
+		}
+		if key == "Kd" {
+			p.kd = c
+		} else {
+			p.ks = c
+		}
+	case "Ns", "Ni", "d", "Tr":
+		f, err := parseFloats(key, args, 1, 1)
+		if err != nil {
+			return err
+		}
+		switch key {
+		case "Ns":
+			p.ns = max(f[0], 0)
+		case "Ni":
+			p.ni = f[0]
+		case "d":
+			p.d = f[0]
+		case "Tr":
+			p.d = 1 - f[0]
+		}
+	case "illum":
+		f, err := parseFloats(key, args, 1, 1)
+		if err != nil {
+			return err
+		}
+		p.illum = int(f[0])
+		p.seenIllum = true
+	}
+	return nil
+}
+
+// parseColor reads an MTL colour statement, a single value is used for all three channels
+func parseColor(key string, args []string) (color.Color, error) {
+	if len(args) > 0 && (args[0] == "spectral" || args[0] == "xyz") {
+		return color.Black, fmt.Errorf("%s %s colours are not supported", key, args[0])
+	}
+	f, err := parseFloats(key, args, 1, 3)
+	if err != nil {
+		return color.Black, err
+	}
+	if len(f) == 1 {
+		return color.New(f[0], f[0], f[0]), nil
+	}
+	if len(f) != 3 {
+		return color.Black, fmt.Errorf("%s requires 1 or 3 values, got %d", key, len(f))
+	}
+	return color.New(f[0], f[1], f[2]), nil
+}
@@ -0,0 +1,381 @@
+package obj
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/object/bvh"
+	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
+	"github.com/sendelivery/go-trace-rays/internal/object/hittable"
+	"github.com/sendelivery/go-trace-rays/internal/object/material"
+	"github.com/sendelivery/go-trace-rays/internal/object/mesh"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// ParseError describes a problem found at a particular line of an OBJ or MTL file.
+type ParseError struct {
+	File string
+	Line int
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Model is a loaded OBJ file. Every usemtl group becomes its own mesh, all of which share a
+// single vertex buffer.
+type Model struct {
+	Meshes    []*mesh.Mesh
+	Materials map[string]hitrecord.Scatterer
+}
+
+// Hittable returns all of the model's meshes wrapped in a single BVH.
+func (m *Model) Hittable() hittable.Hittabler {
+	objects := make([]hittable.Hittabler, len(m.Meshes))
+	for i, msh := range m.Meshes {
+		objects[i] = msh
+	}
+	return bvh.NewFromObjects(objects)
+}
+
+// Load reads the OBJ file at path. Material libraries are resolved relative to the directory
+// containing the file. Faces that appear before any usemtl statement use defaultMat, or a grey
+// Lambertian if defaultMat is nil.
+func Load(path string, defaultMat hitrecord.Scatterer) (*Model, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Decode(f, filepath.Base(path), os.DirFS(filepath.Dir(path)), defaultMat)
+}
+
+// vertexKey identifies a unique combination of OBJ position, texture and normal indices. The
+// texture and normal indices are -1 when absent. A vertex without a normal takes the geometric
+// normal of its face, flat, so it is only shared between faces lying in the same plane.
+type vertexKey struct {
+	v, vt, vn int
+	flat      vec3.Vector3
+}
+
+// group collects the triangle indices of one usemtl group
+type group struct {
+	mat     hitrecord.Scatterer
+	indices []int
+}
+
+// decoder holds the state accumulated while reading an OBJ file
+type decoder struct {
+	name string
+	fsys fs.FS
+
+	positions []vec3.Vector3
+	texcoords []mesh.UV
+	normals   []vec3.Vector3
+
+	vb       mesh.VertexBuffer
+	hasUV    bool
+	anyN     bool
+	vertices map[vertexKey]int
+
+	materials map[string]hitrecord.Scatterer
+	groups    []*group
+	byMat     map[string]*group
+	current   *group
+}
+
+// Decode reads an OBJ file from r. name identifies the file in errors and fsys is used to
+// open any material libraries it references; fsys may be nil if the file has none.
+//
+// Faces with more than three vertices are split into a triangle fan, which is correct for the
+// convex polygons exporters produce. Negative indices count back from the most recently
+// defined element. Lines, points, groups and smoothing statements are ignored.
+func Decode(r io.Reader, name string, fsys fs.FS, defaultMat hitrecord.Scatterer) (*Model, error) {
+	if defaultMat == nil {
+		defaultMat = material.NewLambertian(color.New(0.8, 0.8, 0.8))
+	}
+
+	d := decoder{
+		name:      name,
+		fsys:      fsys,
+		vertices:  make(map[vertexKey]int),
+		materials: make(map[string]hitrecord.Scatterer),
+		byMat:     make(map[string]*group),
+	}
+	d.current = &group{mat: defaultMat}
+	d.groups = append(d.groups, d.current)
+
+	s := bufio.NewScanner(r)
+	line := 0
+	for s.Scan() {
+		line++
+		if err := d.parseLine(s.Text()); err != nil {
+			return nil, &ParseError{File: name, Line: line, Err: err}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, &ParseError{File: name, Line: line, Err: err}
+	}
+
+	return d.model()
+}
+
+func (d *decoder) parseLine(text string) error {
+	fields := strings.Fields(stripComment(text))
+	if len(fields) == 0 {
+		return nil
+	}
+
+	switch fields[0] {
+	case "v":
+		// Allow for the optional w component as well as the common vertex colour extension
+		f, err := parseFloats("v", fields[1:], 3, 7)
+		if err != nil {
+			return err
+		}
+		d.positions = append(d.positions, vec3.New(f[0], f[1], f[2]))
+	case "vt":
+		f, err := parseFloats("vt", fields[1:], 1, 3)
+		if err != nil {
+			return err
+		}
+		uv := mesh.UV{U: f[0]}
+		if len(f) > 1 {
+			uv.V = f[1]
+		}
+		d.texcoords = append(d.texcoords, uv)
+	case "vn":
+		f, err := parseFloats("vn", fields[1:], 3, 3)
+		if err != nil {
+			return err
+		}
+		n := vec3.New(f[0], f[1], f[2])
+		if vec3.IsNearZero(n) {
+			return fmt.Errorf("vn has zero length")
+		}
+		d.normals = append(d.normals, vec3.UnitVector(n))
+	case "f":
+		return d.parseFace(fields[1:])
+	case "usemtl":
+		if len(fields) < 2 {
+			return fmt.Errorf("usemtl requires a material name")
+		}
+		return d.useMaterial(strings.Join(fields[1:], " "))
+	case "mtllib":
+		if len(fields) < 2 {
+			return fmt.Errorf("mtllib requires a file name")
+		}
+		for _, lib := range fields[1:] {
+			if err := d.loadMaterialLibrary(lib); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (d *decoder) parseFace(args []string) error {
+	if len(args) < 3 {
+		return fmt.Errorf("face requires at least 3 vertices, got %d", len(args))
+	}
+
+	keys := make([]vertexKey, len(args))
+	for i, a := range args {
+		key, err := d.parseFaceVertex(a)
+		if err != nil {
+			return err
+		}
+		keys[i] = key
+	}
+
+	// Vertices without a normal of their own shade flat, with the face's geometric normal
+	p := d.positions
+	flat := vec3.Cross(vec3.Sub(p[keys[1].v], p[keys[0].v]), vec3.Sub(p[keys[2].v], p[keys[0].v]))
+	if !vec3.IsNearZero(flat) {
+		flat = vec3.UnitVector(flat)
+	}
+
+	verts := make([]int, len(keys))
+	for i, key := range keys {
+		if key.vn < 0 {
+			key.flat = flat
+		}
+		verts[i] = d.vertex(key)
+	}
+
+	for i := 1; i+1 < len(verts); i++ {
+		d.current.indices = append(d.current.indices, verts[0], verts[i], verts[i+1])
+	}
+	return nil
+}
+
+// parseFaceVertex parses one of the v, v/vt, v//vn or v/vt/vn forms, resolving negative
+// indices and converting to zero based indices.
+func (d *decoder) parseFaceVertex(s string) (vertexKey, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) > 3 {
+		return vertexKey{}, fmt.Errorf("malformed face vertex %q", s)
+	}
+
+	key := vertexKey{v: -1, vt: -1, vn: -1}
+	var err error
+
+	if key.v, err = resolveIndex(parts[0], len(d.positions), "vertex"); err != nil {
+		return key, err
+	}
+	if len(parts) > 1 && parts[1] != "" {
+		if key.vt, err = resolveIndex(parts[1], len(d.texcoords), "texture coordinate"); err != nil {
+			return key, err
+		}
+	}
+	if len(parts) > 2 && parts[2] != "" {
+		if key.vn, err = resolveIndex(parts[2], len(d.normals), "normal"); err != nil {
+			return key, err
+		}
+	}
+	return key, nil
+}
+
+func resolveIndex(s string, count int, kind string) (int, error) {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s index %q", kind, s)
+	}
+	switch {
+	case i > 0 && i <= count:
+		return i - 1, nil
+	case i < 0 && -i <= count:
+		return count + i, nil
+	}
+	return 0, fmt.Errorf("%s index %d out of range, %d defined", kind, i, count)
+}
+
+// vertex returns the vertex buffer index for key, appending a new vertex if it hasn't been
+// seen before.
+func (d *decoder) vertex(key vertexKey) int {
+	if idx, ok := d.vertices[key]; ok {
+		return idx
+	}
+
+	idx := len(d.vb.Positions)
+	d.vertices[key] = idx
+
+	d.vb.Positions = append(d.vb.Positions, d.positions[key.v])
+
+	uv := mesh.UV{}
+	if key.vt >= 0 {
+		uv = d.texcoords[key.vt]
+		d.hasUV = true
+	}
+	d.vb.UVs = append(d.vb.UVs, uv)
+
+	n := key.flat
+	if key.vn >= 0 {
+		n = d.normals[key.vn]
+		d.anyN = true
+	}
+	d.vb.Normals = append(d.vb.Normals, n)
+
+	return idx
+}
+
+func (d *decoder) useMaterial(name string) error {
+	if g, ok := d.byMat[name]; ok {
+		d.current = g
+		return nil
+	}
+
+	mat, ok := d.materials[name]
+	if !ok {
+		return fmt.Errorf("undefined material %q", name)
+	}
+
+	g := &group{mat: mat}
+	d.byMat[name] = g
+	d.groups = append(d.groups, g)
+	d.current = g
+	return nil
+}
+
+func (d *decoder) loadMaterialLibrary(name string) error {
+	if d.fsys == nil {
+		return fmt.Errorf("cannot open material library %q, no file system given", name)
+	}
+
+	f, err := d.fsys.Open(filepath.ToSlash(name))
+	if err != nil {
+		return fmt.Errorf("opening material library: %w", err)
+	}
+	defer f.Close()
+
+	materials, err := DecodeMTL(f, name)
+	if err != nil {
+		return err
+	}
+	for k, v := range materials {
+		d.materials[k] = v
+	}
+	return nil
+}
+
+func (d *decoder) model() (*Model, error) {
+	if !d.hasUV {
+		d.vb.UVs = nil
+	}
+	if !d.anyN {
+		d.vb.Normals = nil
+	}
+
+	m := Model{Materials: d.materials}
+	for _, g := range d.groups {
+		if len(g.indices) == 0 {
+			continue
+		}
+		msh, err := mesh.New(&d.vb, g.indices, g.mat)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", d.name, err)
+		}
+		m.Meshes = append(m.Meshes, msh)
+	}
+	return &m, nil
+}
+
+func stripComment(s string) string {
+	if i := strings.IndexByte(s, '#'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// parseFloats parses between minN and maxN floats from args
+func parseFloats(key string, args []string, minN, maxN int) ([]float64, error) {
+	if len(args) < minN || len(args) > maxN {
+		if minN == maxN {
+			return nil, fmt.Errorf("%s requires %d values, got %d", key, minN, len(args))
+		}
+		return nil, fmt.Errorf("%s requires %d to %d values, got %d", key, minN, maxN, len(args))
+	}
+
+	out := make([]float64, len(args))
+	for i, a := range args {
+		f, err := strconv.ParseFloat(a, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s has invalid number %q", key, a)
+		}
+		out[i] = f
+	}
+	return out, nil
+}
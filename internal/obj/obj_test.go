@@ -0,0 +1,183 @@
+package obj_test
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/sendelivery/go-trace-rays/internal/interval"
+	"github.com/sendelivery/go-trace-rays/internal/obj"
+	"github.com/sendelivery/go-trace-rays/internal/object/material"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+const cubeMTL = `
+newmtl red
+Kd 0.8 0.1 0.1
+
+newmtl chrome
+Kd 0 0 0
+Ks 0.9 0.9 0.9
+Ns 500
+
+newmtl glass
+Ni 1.45
+d 0.2
+`
+
+func TestDecode(t *testing.T) {
+	src := `
+mtllib cube.mtl
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+vt 0 0
+vt 1 0
+vt 1 1
+vt 0 1
+vn 0 0 1
+
+usemtl red
+f 1/1/1 2/2/1 3/3/1 4/4/1   # a quad, split into two triangles
+usemtl chrome
+f -4/-4/-1 -3/-3/-1 -2/-2/-1
+usemtl glass
+f 1 3 4
+usemtl red
+f 1//1 2//1 3//1
+`
+	fsys := fstest.MapFS{"cube.mtl": {Data: []byte(cubeMTL)}}
+
+	m, err := obj.Decode(strings.NewReader(src), "cube.obj", fsys, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(m.Meshes) != 3 {
+		t.Fatalf("unexpected mesh count, got=%d. want=3.", len(m.Meshes))
+	}
+
+	wantTris := []int{3, 1, 1}
+	for i, want := range wantTris {
+		if got := m.Meshes[i].TriangleCount(); got != want {
+			t.Errorf("unexpected triangle count for mesh %d, got=%d. want=%d.", i, got, want)
+		}
+	}
+
+	if _, ok := m.Materials["red"].(*material.Lambertian); !ok {
+		t.Errorf("expected red to be Lambertian, got=%T.", m.Materials["red"])
+	}
+	if _, ok := m.Materials["chrome"].(*material.Metal); !ok {
+		t.Errorf("expected chrome to be Metal, got=%T.", m.Materials["chrome"])
+	}
+	if _, ok := m.Materials["glass"].(*material.Dielectric); !ok {
+		t.Errorf("expected glass to be Dielectric, got=%T.", m.Materials["glass"])
+	}
+}
+
+// Faces without normals shade flat even where they share corners with faces at an angle to
+// them, in a file where other faces do give normals
+func TestDecodeFlatNormals(t *testing.T) {
+	src := `
+v 0 0 0
+v 0 0 1
+v 0 1 0
+v 1 0 0
+v 5 0 0
+v 6 0 0
+v 5 1 0
+vn 0 0 1
+f 1 2 3
+f 1 4 3
+f 5//1 6//1 7//1
+`
+	m, err := obj.Decode(strings.NewReader(src), "corner.obj", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := map[string]struct {
+		r    ray.Ray
+		want vec3.Vector3
+	}{
+		"x=0 face": {r: ray.New(vec3.New(-1, 0.2, 0.2), vec3.New(1, 0, 0)), want: vec3.New(-1, 0, 0)},
+		"z=0 face": {r: ray.New(vec3.New(0.2, 0.2, 1), vec3.New(0, 0, -1)), want: vec3.New(0, 0, 1)},
+	}
+	for name, tt := range tests {
+		hr, ok := m.Meshes[0].Hit(tt.r, interval.New(1e-3, math.Inf(1)))
+		if !ok {
+			t.Fatalf("%s: expected a hit", name)
+		}
+		if got := hr.Normal(); vec3.Sub(got, tt.want).Length() > 1e-9 {
+			t.Errorf("%s: unexpected normal, got=%q. want=%q.", name, &got, &tt.want)
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		line int
+	}{
+		{
+			name: "index out of range",
+			src:  "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 4\n",
+			line: 4,
+		},
+		{
+			name: "bad number",
+			src:  "v 0 0 0\nv 1 zero 0\n",
+			line: 2,
+		},
+		{
+			name: "too few face vertices",
+			src:  "v 0 0 0\nv 1 0 0\n\nf 1 2\n",
+			line: 4,
+		},
+		{
+			name: "undefined material",
+			src:  "v 0 0 0\nusemtl missing\n",
+			line: 2,
+		},
+		{
+			name: "zero normal",
+			src:  "v 0 0 0\nvn 0 0 1\nvn 0 0 0\n",
+			line: 3,
+		},
+		{
+			name: "negative index before definition",
+			src:  "f -1 -2 -3\n",
+			line: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := obj.Decode(strings.NewReader(tc.src), "test.obj", nil, nil)
+
+			var pe *obj.ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("expected a ParseError, got=%v.", err)
+			}
+			if pe.Line != tc.line {
+				t.Errorf("unexpected line, got=%d. want=%d. (%v)", pe.Line, tc.line, err)
+			}
+		})
+	}
+}
+
+func TestDecodeMTLErrors(t *testing.T) {
+	_, err := obj.DecodeMTL(strings.NewReader("newmtl a\nKd 1 1\n"), "bad.mtl")
+
+	var pe *obj.ParseError
+	if !errors.As(err, &pe) || pe.Line != 2 {
+		t.Errorf("expected a ParseError on line 2, got=%v.", err)
+	}
+}
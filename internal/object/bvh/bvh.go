@@ -0,0 +1,107 @@
+package bvh
+
+import (
+	"slices"
+
+	"github.com/sendelivery/go-trace-rays/internal/aabb"
+	"github.com/sendelivery/go-trace-rays/internal/interval"
+	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
+	"github.com/sendelivery/go-trace-rays/internal/object/hittable"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+)
+
+// Node is a bounding volume hierarchy node. Each node holds two children which may themselves
+// be nodes, leaf objects, or the same object twice when only one remains. A ray that misses a
+// node's bounding box skips everything underneath it, so intersection cost grows with the
+// logarithm of the object count rather than linearly.
+type Node struct {
+	left, right hittable.Hittabler
+	leaf        bool // Whether left and right are the same single object
+	bbox        aabb.AABB
+}
+
+// New builds a BVH over every object in the given list. The list itself is left untouched.
+func New(list hittable.HittableList) *Node {
+	return NewFromObjects(list.Objects())
+}
+
+// NewFromObjects builds a BVH over the given objects. The objects slice is copied before being
+// sorted, so callers may continue to use it afterwards.
+func NewFromObjects(objects []hittable.Hittabler) *Node {
+	if len(objects) == 0 {
+		return &Node{bbox: aabb.Empty}
+	}
+	return build(slices.Clone(objects))
+}
+
+// build recursively splits objects along the longest axis of their combined bounding box.
+// objects is sorted in place.
+func build(objects []hittable.Hittabler) *Node {
+	n := Node{bbox: aabb.Empty}
+	for _, o := range objects {
+		n.bbox = aabb.NewFromBoxes(n.bbox, o.BoundingBox())
+	}
+
+	axis := n.bbox.LongestAxis()
+
+	switch len(objects) {
+	case 1:
+		n.left, n.right = objects[0], objects[0]
+		n.leaf = true
+	case 2:
+		n.left, n.right = objects[0], objects[1]
+	default:
+		slices.SortFunc(objects, func(a, b hittable.Hittabler) int {
+			aMin := a.BoundingBox().AxisInterval(axis).Min
+			bMin := b.BoundingBox().AxisInterval(axis).Min
+			switch {
+			case aMin < bMin:
+				return -1
+			case aMin > bMin:
+				return 1
+			}
+			return 0
+		})
+
+		mid := len(objects) / 2
+		n.left = build(objects[:mid])
+		n.right = build(objects[mid:])
+	}
+
+	return &n
+}
+
+// Objects returns the node's children, letting hittable.Lights search the hierarchy
+func (n *Node) Objects() []hittable.Hittabler {
+	switch {
+	case n.left == nil:
+		return nil
+	case n.leaf:
+		return []hittable.Hittabler{n.left}
+	}
+	return []hittable.Hittabler{n.left, n.right}
+}
+
+func (n *Node) Hit(r ray.Ray, rt interval.Interval) (hitrecord.HitRecord, bool) {
+	if n.left == nil || !n.bbox.Hit(r, rt) {
+		return hitrecord.HitRecord{}, false
+	}
+
+	hr, hitLeft := n.left.Hit(r, rt)
+	if hitLeft {
+		rt.Max = hr.T()
+	}
+
+	if n.leaf {
+		return hr, hitLeft
+	}
+
+	if rhr, hitRight := n.right.Hit(r, rt); hitRight {
+		return rhr, true
+	}
+	return hr, hitLeft
+}
+
+func (n *Node) BoundingBox() aabb.AABB {
+	return n.bbox
+}
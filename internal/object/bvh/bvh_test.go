@@ -0,0 +1,69 @@
+package bvh_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sendelivery/go-trace-rays/internal/interval"
+	"github.com/sendelivery/go-trace-rays/internal/object/bvh"
+	"github.com/sendelivery/go-trace-rays/internal/object/hittable"
+	"github.com/sendelivery/go-trace-rays/internal/object/sphere"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// The BVH must report exactly the same closest hit as a brute force search of the list.
+func TestHitMatchesList(t *testing.T) {
+	rng := utility.NewRand(1, 0)
+
+	var list hittable.HittableList
+	for range 200 {
+		list.Add(sphere.New(vec3.NewRandomN(rng, -10, 10), rng.Range(0.1, 1), nil))
+	}
+	node := bvh.New(list)
+
+	for range 1000 {
+		r := ray.New(vec3.NewRandomN(rng, -20, 20), vec3.NewRandomUnitVector(rng))
+		rt := interval.New(1e-3, math.Inf(1))
+
+		want, wantOk := list.Hit(r, rt)
+		got, gotOk := node.Hit(r, rt)
+
+		if gotOk != wantOk {
+			t.Fatalf("unexpected hit result, got=%t. want=%t.", gotOk, wantOk)
+		}
+		if gotOk && got.T() != want.T() {
+			t.Fatalf("unexpected hit distance, got=%f. want=%f.", got.T(), want.T())
+		}
+	}
+}
+
+func TestBoundingBox(t *testing.T) {
+	var list hittable.HittableList
+	list.Add(
+		sphere.New(vec3.New(0, 0, 0), 1, nil),
+		sphere.New(vec3.New(5, -2, 3), 2, nil),
+	)
+	bbox := bvh.New(list).BoundingBox()
+
+	want := []interval.Interval{
+		interval.New(-1, 7),
+		interval.New(-4, 1),
+		interval.New(-1, 5),
+	}
+	for axis, w := range want {
+		if got := bbox.AxisInterval(axis); got != w {
+			t.Errorf("unexpected interval for axis %d, got=%v. want=%v.", axis, got, w)
+		}
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	node := bvh.NewFromObjects(nil)
+	r := ray.New(vec3.New(0, 0, 0), vec3.New(0, 0, -1))
+
+	if _, ok := node.Hit(r, interval.UniverseInterval); ok {
+		t.Error("expected an empty BVH not to be hit")
+	}
+}
@@ -3,16 +3,33 @@ package hitrecord
 import (
 	"github.com/sendelivery/go-trace-rays/internal/color"
 	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
 	"github.com/sendelivery/go-trace-rays/internal/vec3"
 )
 
 type Scatterer interface {
-	Scatter(in ray.Ray, hr HitRecord) (color.Color, ray.Ray, bool)
+	// Scatter returns the attenuation and direction of the ray scattered from the hit, or false
+	// if the ray is absorbed. rng is owned by the calling goroutine.
+	Scatter(in ray.Ray, hr HitRecord, rng *utility.Rand) (color.Color, ray.Ray, bool)
+
+	// Evaluate returns the fraction of light arriving from direction dir that is scattered
+	// back along in, including the cosine term, and the probability density per unit solid
+	// angle of Scatter choosing dir. The attenuation returned by Scatter is the first divided
+	// by the second. Materials scattering only in discrete directions, like mirrors and glass,
+	// return a density of 0, so the renderer doesn't aim rays at lights from them.
+	Evaluate(in ray.Ray, hr HitRecord, dir vec3.Vector3) (color.Color, float64)
+}
+
+// Emitter is implemented by materials that give off light. Emitted returns the radiance leaving
+// the surface at the hit point back along the incoming ray.
+type Emitter interface {
+	Emitted(in ray.Ray, hr HitRecord) color.Color
 }
 
 type HitRecord struct {
 	point, normal vec3.Vector3
 	t             float64
+	u, v          float64 // Surface coordinates of the hit point
 	frontFace     bool
 	mat           Scatterer
 }
@@ -38,8 +55,36 @@ func (hr *HitRecord) setFaceNormal(r ray.Ray, outwardNormal vec3.Vector3) {
 	}
 }
 
+// SetUV sets the surface coordinates of the hit point.
+func (hr *HitRecord) SetUV(u, v float64) {
+	hr.u = u
+	hr.v = v
+}
+
+// SetShadingNormal replaces the HitRecord's normal with n, for example an interpolated vertex
+// normal used for smooth shading. Which side was hit is still decided by the geometric normal
+// given to New, n is flipped to face the same way. n is assumed to have unit length.
+func (hr *HitRecord) SetShadingNormal(n vec3.Vector3) {
+	if hr.frontFace {
+		hr.normal = n
+	} else {
+		hr.normal = vec3.Mulf(n, -1)
+	}
+}
+
+// Transform moves the hit into another space, for example from an object's local space into
+// the world. point is the hit point in the new space and normal is its unit normal there,
+// still facing the side that was hit. The ray parameter, surface coordinates and which side
+// was hit are unchanged.
+func (hr *HitRecord) Transform(point, normal vec3.Vector3) {
+	hr.point = point
+	hr.normal = normal
+}
+
 func (hr *HitRecord) Point() vec3.Vector3  { return hr.point }
 func (hr *HitRecord) Normal() vec3.Vector3 { return hr.normal }
 func (hr *HitRecord) T() float64           { return hr.t }
+func (hr *HitRecord) U() float64           { return hr.u }
+func (hr *HitRecord) V() float64           { return hr.v }
 func (hr *HitRecord) FrontFace() bool      { return hr.frontFace }
 func (hr *HitRecord) Material() Scatterer  { return hr.mat }
@@ -1,6 +1,7 @@
 package hittable
 
 import (
+	"github.com/sendelivery/go-trace-rays/internal/aabb"
 	"github.com/sendelivery/go-trace-rays/internal/interval"
 	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
 	"github.com/sendelivery/go-trace-rays/internal/ray"
@@ -11,20 +12,36 @@ type Hittabler interface {
 	// indicating if the object was hit by the ray. The pointer will be nil and the bool
 	// false if it was not hit.
 	Hit(r ray.Ray, rt interval.Interval) (hitrecord.HitRecord, bool)
+
+	// BoundingBox returns an axis-aligned box enclosing the whole object.
+	BoundingBox() aabb.AABB
 }
 
 type HittableList struct {
 	objects []Hittabler
+	bbox    aabb.AABB
 }
 
 func (hl *HittableList) Clear() {
 	hl.objects = make([]Hittabler, 0)
+	hl.bbox = aabb.Empty
 }
 
 func (hl *HittableList) Add(o ...Hittabler) {
+	if len(hl.objects) == 0 {
+		hl.bbox = aabb.Empty
+	}
+	for _, obj := range o {
+		hl.bbox = aabb.NewFromBoxes(hl.bbox, obj.BoundingBox())
+	}
 	hl.objects = append(hl.objects, o...)
 }
 
+// Objects returns the objects held by the list. The returned slice must not be modified.
+func (hl HittableList) Objects() []Hittabler {
+	return hl.objects
+}
+
 func (hl HittableList) Hit(r ray.Ray, rt interval.Interval) (hitrecord.HitRecord, bool) {
 	var result hitrecord.HitRecord
 	var hitAnything bool
@@ -40,3 +57,10 @@ func (hl HittableList) Hit(r ray.Ray, rt interval.Interval) (hitrecord.HitRecord
 
 	return result, hitAnything
 }
+
+func (hl HittableList) BoundingBox() aabb.AABB {
+	if len(hl.objects) == 0 {
+		return aabb.Empty
+	}
+	return hl.bbox
+}
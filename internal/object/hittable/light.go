@@ -0,0 +1,82 @@
+package hittable
+
+import (
+	"math"
+
+	"github.com/sendelivery/go-trace-rays/internal/interval"
+	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// Sampler is implemented by shapes that can pick random points on their surface, so that when
+// they give off light the renderer can aim rays at them directly rather than waiting for a
+// bounce to find them.
+type Sampler interface {
+	Hittabler
+
+	// Material returns the shape's material, the shape is only sampled as a light if it is a
+	// hitrecord.Emitter.
+	Material() hitrecord.Scatterer
+
+	// Sample returns the direction from origin to a random point on the shape as it is at the
+	// given time. rng is owned by the calling goroutine.
+	Sample(origin vec3.Vector3, time float64, rng *utility.Rand) vec3.Vector3
+
+	// PDF returns the probability density, per unit solid angle, of Sample choosing r's
+	// direction from r's origin. Only points where r hits the shape within rt are counted, so
+	// it is 0 if r misses the shape there.
+	PDF(r ray.Ray, rt interval.Interval) float64
+}
+
+// Group is implemented by hittables made up of other hittables, letting Lights search inside
+// them
+type Group interface {
+	Objects() []Hittabler
+}
+
+// LightHolder is implemented by hittables that can't be searched as a Group because they change
+// the objects they hold, such as transforms. Lights returns the Samplers inside, changed to match.
+type LightHolder interface {
+	Lights() []Sampler
+}
+
+// Lights returns every Sampler in world, searching through Groups and LightHolders, whose
+// material gives off light. Lights inside other kinds of hittable, such as media, aren't found,
+// but still light the scene when bounced rays happen to hit them.
+func Lights(world Hittabler) []Sampler {
+	var lights []Sampler
+
+	var walk func(h Hittabler)
+	walk = func(h Hittabler) {
+		switch o := h.(type) {
+		case Sampler:
+			if _, ok := o.Material().(hitrecord.Emitter); ok {
+				lights = append(lights, o)
+			}
+		case Group:
+			for _, child := range o.Objects() {
+				walk(child)
+			}
+		case LightHolder:
+			lights = append(lights, o.Lights()...)
+		}
+	}
+	walk(world)
+
+	return lights
+}
+
+// AreaPDF converts a density of 1/area, for points picked uniformly over a shape's surface, into
+// a density per unit solid angle of the direction of r, which hit the shape at hr
+func AreaPDF(r ray.Ray, hr hitrecord.HitRecord, area float64) float64 {
+	dir := r.Direction()
+	length := dir.Length()
+	distanceSquared := hr.T() * hr.T() * length * length
+	cosine := math.Abs(vec3.Dot(dir, hr.Normal())) / length
+	if cosine < 1e-8 {
+		return 0
+	}
+	return distanceSquared / (cosine * area)
+}
@@ -0,0 +1,149 @@
+package hittable_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/interval"
+	"github.com/sendelivery/go-trace-rays/internal/mat4"
+	"github.com/sendelivery/go-trace-rays/internal/object/bvh"
+	"github.com/sendelivery/go-trace-rays/internal/object/hittable"
+	"github.com/sendelivery/go-trace-rays/internal/object/material"
+	"github.com/sendelivery/go-trace-rays/internal/object/mesh"
+	"github.com/sendelivery/go-trace-rays/internal/object/planar"
+	"github.com/sendelivery/go-trace-rays/internal/object/sphere"
+	"github.com/sendelivery/go-trace-rays/internal/object/transform"
+	"github.com/sendelivery/go-trace-rays/internal/object/triangle"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// onlyLight returns the single light found in h
+func onlyLight(t *testing.T, h hittable.Hittabler) hittable.Sampler {
+	t.Helper()
+
+	lights := hittable.Lights(h)
+	if len(lights) != 1 {
+		t.Fatalf("unexpected number of lights, got=%d. want=%d.", len(lights), 1)
+	}
+	return lights[0]
+}
+
+// Every sampled direction must hit the shape, and the densities must match the solid angle
+// the shape covers, which is found separately by firing rays in uniformly random directions
+func TestSamplers(t *testing.T) {
+	t.Parallel()
+
+	light := material.NewDiffuseLight(color.White)
+
+	// A pyramid without a base, its faces of different sizes and at different angles
+	msh, err := mesh.New(&mesh.VertexBuffer{
+		Positions: []vec3.Vector3{
+			vec3.New(-1, 2, -1), vec3.New(1, 2, -1), vec3.New(1, 2, 1), vec3.New(-1, 2, 1), vec3.New(0.5, 3, 0),
+		},
+	}, []int{0, 1, 4, 1, 2, 4, 2, 3, 4, 3, 0, 4}, light)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Squashed, sheared by the rotation after the uneven scale, and moved
+	skew := mat4.Mul(mat4.Translate(vec3.New(0, 3, 0)), mat4.Mul(mat4.Rotate(vec3.New(1, 0, 1), 0.7), mat4.Scale(vec3.New(2, 0.5, 1))))
+	tr, err := transform.New(planar.NewDisk(vec3.New(0, 0, 0), vec3.New(0, 1, 1), 1, light), skew)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	trSphere, err := transform.New(sphere.New(vec3.New(0, 0, 0), 1, light), skew)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	anim, err := transform.NewAnimated(planar.NewQuad(vec3.New(-1, 0, -1), vec3.New(2, 0, 0), vec3.New(0, 0, 2), light), []transform.Keyframe{
+		{Time: -1, Translate: vec3.New(-2, 1, 0), Rotate: mat4.IdentityRotation(), Scale: vec3.New(1, 1, 1)},
+		{Time: 1, Translate: vec3.New(2, 5, 0), Rotate: mat4.FromAxisAngle(vec3.New(0, 0, 1), 1), Scale: vec3.New(3, 1, 0.5)},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := map[string]struct {
+		shape  hittable.Sampler
+		origin vec3.Vector3
+	}{
+		"mesh":               {shape: msh},
+		"transformed disk":   {shape: onlyLight(t, tr)},
+		"transformed sphere": {shape: onlyLight(t, trSphere)},
+		"animated quad":      {shape: onlyLight(t, anim)},
+		"quad":               {shape: planar.NewQuad(vec3.New(-1, 2, -1), vec3.New(2, 0, 0), vec3.New(0, 0.5, 1), nil)},
+		"disk":               {shape: planar.NewDisk(vec3.New(1, 2, 0), vec3.New(1, -1, 0), 1, nil)},
+		"triangle":           {shape: triangle.New(vec3.New(0, 2, 0), vec3.New(2, 2, 1), vec3.New(-1, 3, 0), nil)},
+		"sphere outside":     {shape: sphere.New(vec3.New(0, 3, 1), 1, nil)},
+		"sphere inside":      {shape: sphere.New(vec3.New(0, 0.5, 0), 1, nil)},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rng := utility.NewRand(1, 0)
+			rt := interval.New(1e-6, math.Inf(1))
+
+			const n = 100000
+			var sampled, hits float64
+			for range n {
+				dir := tt.shape.Sample(tt.origin, 0, rng)
+				pdf := tt.shape.PDF(ray.New(tt.origin, dir), rt)
+				if pdf <= 0 {
+					t.Fatalf("sampled direction %q missed the shape", &dir)
+				}
+				sampled += 1 / pdf
+
+				if _, ok := tt.shape.Hit(ray.New(tt.origin, vec3.NewRandomUnitVector(rng)), rt); ok {
+					hits++
+				}
+			}
+
+			got, want := sampled/n, 4*math.Pi*hits/n
+			if math.Abs(got-want) > 0.02*want+0.01 {
+				t.Errorf("unexpected solid angle, got=%v. want=%v.", got, want)
+			}
+		})
+	}
+}
+
+func TestLights(t *testing.T) {
+	t.Parallel()
+
+	light := material.NewDiffuseLight(color.White)
+	matte := material.NewLambertian(color.White)
+
+	var inner hittable.HittableList
+	inner.Add(
+		sphere.New(vec3.New(0, 0, 0), 1, light),
+		sphere.New(vec3.New(3, 0, 0), 1, matte),
+	)
+	lamp, err := mesh.New(&mesh.VertexBuffer{
+		Positions: []vec3.Vector3{vec3.New(0, 4, 0), vec3.New(1, 4, 0), vec3.New(0, 4, 1)},
+	}, []int{0, 1, 2}, light)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	moved, err := transform.New(bvh.New(inner), mat4.Translate(vec3.New(0, 0, 10)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var world hittable.HittableList
+	world.Add(
+		bvh.New(inner),
+		moved,
+		lamp,
+		planar.NewQuad(vec3.New(0, 5, 0), vec3.New(1, 0, 0), vec3.New(0, 0, 1), light),
+		planar.NewPlane(vec3.New(0, -1, 0), vec3.New(0, 1, 0), light),
+		planar.NewBox(vec3.New(5, 0, 0), vec3.New(6, 1, 1), matte),
+	)
+
+	if got := len(hittable.Lights(world)); got != 4 {
+		t.Errorf("unexpected number of lights, got=%d. want=%d.", got, 4)
+	}
+}
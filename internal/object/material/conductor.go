@@ -0,0 +1,191 @@
+package material
+
+import (
+	"math"
+	"slices"
+	"sync"
+
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// ComplexIOR is the complex refractive index Eta + iK of a conductor, for red, green and blue
+// light
+type ComplexIOR struct {
+	Eta, K color.Color
+}
+
+// Measured refractive indices of common metals
+var (
+	Gold      = ComplexIOR{Eta: color.New(0.143, 0.374, 1.442), K: color.New(3.983, 2.385, 1.603)}
+	Copper    = ComplexIOR{Eta: color.New(0.200, 0.924, 1.102), K: color.New(3.912, 2.452, 2.142)}
+	Aluminium = ComplexIOR{Eta: color.New(1.657, 0.880, 0.521), K: color.New(9.224, 6.270, 4.837)}
+	Silver    = ComplexIOR{Eta: color.New(0.155, 0.117, 0.138), K: color.New(4.828, 3.122, 2.147)}
+)
+
+var complexIORs = map[string]ComplexIOR{
+	"gold":      Gold,
+	"copper":    Copper,
+	"aluminium": Aluminium,
+	"silver":    Silver,
+}
+
+// ComplexIORByName returns the refractive index of the named metal, one of ComplexIORNames
+func ComplexIORByName(name string) (ComplexIOR, bool) {
+	ior, ok := complexIORs[name]
+	return ior, ok
+}
+
+// ComplexIORNames returns the names of the metals with known refractive indices, sorted
+func ComplexIORNames() []string {
+	names := make([]string, 0, len(complexIORs))
+	for name := range complexIORs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Conductor is a metal with a rough surface of GGX microfacets, reflecting light with the
+// Fresnel reflectance of its complex refractive index. A roughness of 0 is a perfect mirror.
+//
+// A single bounce off the microfacets loses the light that would reflect between them, so the
+// reflection is brightened to make up for it, following S. Turquin, "Practical multiple
+// scattering compensation for microfacet models".
+type Conductor struct {
+	ior ComplexIOR
+	ggx ggx
+	f0  color.Color // Reflectance facing the surface head on
+}
+
+// NewConductor returns a Conductor with the refractive index ior. roughness runs from 0 for a
+// mirror to 1 for a very rough surface, and is clamped to that range.
+func NewConductor(ior ComplexIOR, roughness float64) *Conductor {
+	c := Conductor{ior: ior, ggx: newGGX(roughness)}
+	c.f0 = c.fresnel(1)
+	return &c
+}
+
+func (c *Conductor) Scatter(in ray.Ray, hr hitrecord.HitRecord, rng *utility.Rand) (color.Color, ray.Ray, bool) {
+	f := newFrame(hr.Normal())
+	v := f.toLocal(vec3.UnitVector(vec3.Mulf(in.Direction(), -1)))
+	if v.Z() <= 0 {
+		return color.Black, ray.Ray{}, false
+	}
+
+	if c.ggx.smooth() {
+		l := vec3.New(-v.X(), -v.Y(), v.Z())
+		return c.fresnel(v.Z()), ray.NewWithTime(hr.Point(), f.toWorld(l), in.Time()), true
+	}
+
+	m := c.ggx.sample(v, rng.Float64(), rng.Float64())
+	l := reflectAbout(v, m)
+	if l.Z() <= 0 {
+		return color.Black, ray.Ray{}, false
+	}
+
+	attenuation := c.fresnel(vec3.Dot(v, m))
+	attenuation.Mulv(c.compensation(v.Z()))
+	attenuation.Mulf(c.ggx.g2(v, l) / c.ggx.g1(v))
+	return attenuation, ray.NewWithTime(hr.Point(), f.toWorld(l), in.Time()), true
+}
+
+// Evaluate returns the microfacet reflection towards dir, and the density of sampling it
+// through the visible normals. Mirrors return nothing, they reflect in one direction only.
+func (c *Conductor) Evaluate(in ray.Ray, hr hitrecord.HitRecord, dir vec3.Vector3) (color.Color, float64) {
+	if c.ggx.smooth() {
+		return color.Black, 0
+	}
+
+	f := newFrame(hr.Normal())
+	v := f.toLocal(vec3.UnitVector(vec3.Mulf(in.Direction(), -1)))
+	l := f.toLocal(vec3.UnitVector(dir))
+	if v.Z() <= 0 || l.Z() <= 0 {
+		return color.Black, 0
+	}
+
+	m := vec3.UnitVector(vec3.Add(v, l))
+	d := c.ggx.d(m)
+	pdf := c.ggx.g1(v) * d / (4 * v.Z())
+
+	value := c.fresnel(vec3.Dot(v, m))
+	value.Mulv(c.compensation(v.Z()))
+	value.Mulf(c.ggx.g2(v, l) * d / (4 * v.Z()))
+	return value, pdf
+}
+
+// fresnel returns the reflectance of each channel for light arriving at an angle with cosine
+// cos to the microfacet
+func (c *Conductor) fresnel(cos float64) color.Color {
+	return color.New(
+		fresnelConductor(cos, c.ior.Eta.X(), c.ior.K.X()),
+		fresnelConductor(cos, c.ior.Eta.Y(), c.ior.K.Y()),
+		fresnelConductor(cos, c.ior.Eta.Z(), c.ior.K.Z()),
+	)
+}
+
+// compensation returns how much to brighten each channel of the reflection seen from an angle
+// with cosine cos to the surface, to add back the light lost between the microfacets
+func (c *Conductor) compensation(cos float64) color.Color {
+	e := reflectedEnergy(cos, c.ggx.alpha)
+	k := (1 - e) / e
+	return color.New(1+c.f0.X()*k, 1+c.f0.Y()*k, 1+c.f0.Z()*k)
+}
+
+const (
+	energyTableSize = 32 // Rows and columns of the reflected energy table
+	energySamples   = 24 // Rows and columns of the grid of normals integrated over for each entry
+)
+
+var (
+	energyOnce  sync.Once
+	energyTable [energyTableSize][energyTableSize]float64 // By cosine, then alpha
+)
+
+// reflectedEnergy returns the share of light a single bounce off a perfectly reflective GGX
+// surface of width alpha sends back, for light arriving at an angle with cosine cos
+func reflectedEnergy(cos, alpha float64) float64 {
+	energyOnce.Do(func() {
+		for i := range energyTableSize {
+			for j := range energyTableSize {
+				energyTable[i][j] = integrateReflection(tableNode(i), float64(j)/(energyTableSize-1))
+			}
+		}
+	})
+
+	// Interpolate between the four nearest entries
+	x := max(0, min(cos*(energyTableSize-1), energyTableSize-1))
+	y := max(0, min(alpha*(energyTableSize-1), energyTableSize-1))
+	i, j := min(int(x), energyTableSize-2), min(int(y), energyTableSize-2)
+	fx, fy := x-float64(i), y-float64(j)
+	return (1-fx)*((1-fy)*energyTable[i][j]+fy*energyTable[i][j+1]) +
+		fx*((1-fy)*energyTable[i+1][j]+fy*energyTable[i+1][j+1])
+}
+
+// tableNode returns the cosine of entry i of a table spanning 0 to 1, nudged off 0 where
+// nothing can be seen
+func tableNode(i int) float64 {
+	return max(1e-3, float64(i)/(energyTableSize-1))
+}
+
+// integrateReflection returns the share of light reflected by a single bounce off a perfectly
+// reflective GGX surface of width alpha, seen at an angle with cosine cos, from a grid of
+// visible normals
+func integrateReflection(cos, alpha float64) float64 {
+	g := ggx{max(alpha, minAlpha)}
+	v := vec3.New(math.Sqrt(1-cos*cos), 0, cos)
+
+	sum := 0.0
+	for a := range energySamples {
+		for b := range energySamples {
+			m := g.sample(v, (float64(a)+0.5)/energySamples, (float64(b)+0.5)/energySamples)
+			if l := reflectAbout(v, m); l.Z() > 0 {
+				sum += g.g2(v, l) / g.g1(v)
+			}
+		}
+	}
+	return sum / (energySamples * energySamples)
+}
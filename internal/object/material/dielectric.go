@@ -20,7 +20,7 @@ func NewDielectric(refractionIndex float64) *Dielectric {
 	}
 }
 
-func (d *Dielectric) Scatter(in ray.Ray, hr hitrecord.HitRecord) (color.Color, ray.Ray, bool) {
+func (d *Dielectric) Scatter(in ray.Ray, hr hitrecord.HitRecord, rng *utility.Rand) (color.Color, ray.Ray, bool) {
 	ri := d.refractionIndex
 	if hr.FrontFace() {
 		ri = 1.0 / d.refractionIndex
@@ -34,16 +34,21 @@ func (d *Dielectric) Scatter(in ray.Ray, hr hitrecord.HitRecord) (color.Color, r
 	cannotRefract := ri*sinTheta > 1
 
 	var direction vec3.Vector3
-	if cannotRefract || reflectance(cosTheta, ri) > utility.Random() {
+	if cannotRefract || reflectance(cosTheta, ri) > rng.Float64() {
 		direction = vec3.Reflect(unitDir, hr.Normal())
 	} else {
 		direction = vec3.Refract(unitDir, hr.Normal(), ri)
 	}
 
-	scattered := ray.New(hr.Point(), direction)
+	scattered := ray.NewWithTime(hr.Point(), direction, in.Time())
 	return color.White, scattered, true
 }
 
+// Evaluate returns nothing, glass only reflects and refracts in discrete directions
+func (d *Dielectric) Evaluate(in ray.Ray, hr hitrecord.HitRecord, dir vec3.Vector3) (color.Color, float64) {
+	return color.Black, 0
+}
+
 // reflectance uses Schlick's approximation of reflectance.
 func reflectance(cosine, refractionIndex float64) float64 {
 	r0 := (1 - refractionIndex) / (1 + refractionIndex)
@@ -0,0 +1,39 @@
+package material
+
+import (
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/texture"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// DiffuseLight is an area light material, emitting the same radiance in every direction from
+// both sides of the surface. It doesn't scatter any incoming light.
+type DiffuseLight struct {
+	tex texture.Texturer
+}
+
+func NewDiffuseLight(emit color.Color) *DiffuseLight {
+	return NewDiffuseLightTexture(texture.NewSolidColor(emit))
+}
+
+// NewDiffuseLightTexture returns a DiffuseLight whose emitted radiance is looked up from tex.
+func NewDiffuseLightTexture(tex texture.Texturer) *DiffuseLight {
+	return &DiffuseLight{
+		tex: tex,
+	}
+}
+
+func (dl *DiffuseLight) Scatter(in ray.Ray, hr hitrecord.HitRecord, rng *utility.Rand) (color.Color, ray.Ray, bool) {
+	return color.Black, ray.Ray{}, false
+}
+
+func (dl *DiffuseLight) Evaluate(in ray.Ray, hr hitrecord.HitRecord, dir vec3.Vector3) (color.Color, float64) {
+	return color.Black, 0
+}
+
+func (dl *DiffuseLight) Emitted(in ray.Ray, hr hitrecord.HitRecord) color.Color {
+	return dl.tex.Value(hr.U(), hr.V(), hr.Point())
+}
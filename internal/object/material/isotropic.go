@@ -0,0 +1,99 @@
+package material
+
+import (
+	"math"
+
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/texture"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// Isotropic is the phase function of a participating medium that scatters light equally in
+// every direction. It is meant for the particles of a medium, not for surfaces.
+type Isotropic struct {
+	tex texture.Texturer
+}
+
+func NewIsotropic(albedo color.Color) *Isotropic {
+	return NewIsotropicTexture(texture.NewSolidColor(albedo))
+}
+
+// NewIsotropicTexture returns an Isotropic whose albedo is looked up from tex.
+func NewIsotropicTexture(tex texture.Texturer) *Isotropic {
+	return &Isotropic{
+		tex: tex,
+	}
+}
+
+func (i *Isotropic) Scatter(in ray.Ray, hr hitrecord.HitRecord, rng *utility.Rand) (color.Color, ray.Ray, bool) {
+	scattered := ray.NewWithTime(hr.Point(), vec3.NewRandomUnitVector(rng), in.Time())
+	return i.tex.Value(hr.U(), hr.V(), hr.Point()), scattered, true
+}
+
+func (i *Isotropic) Evaluate(in ray.Ray, hr hitrecord.HitRecord, dir vec3.Vector3) (color.Color, float64) {
+	pdf := 1 / (4 * math.Pi)
+	return vec3.Mulf(i.tex.Value(hr.U(), hr.V(), hr.Point()), pdf), pdf
+}
+
+// HenyeyGreenstein is the phase function of a participating medium that favours scattering
+// forwards or backwards. The asymmetry g is the mean cosine of the scattering angle, positive
+// values scatter forwards like fog or clouds, negative values back towards the light and zero
+// is isotropic.
+type HenyeyGreenstein struct {
+	tex texture.Texturer
+	g   float64
+}
+
+func NewHenyeyGreenstein(albedo color.Color, g float64) *HenyeyGreenstein {
+	return NewHenyeyGreensteinTexture(texture.NewSolidColor(albedo), g)
+}
+
+// NewHenyeyGreensteinTexture returns a HenyeyGreenstein whose albedo is looked up from tex.
+// g is clamped to [-0.99, 0.99], beyond which the distribution is too sharp to sample.
+func NewHenyeyGreensteinTexture(tex texture.Texturer, g float64) *HenyeyGreenstein {
+	return &HenyeyGreenstein{
+		tex: tex,
+		g:   min(max(g, -0.99), 0.99),
+	}
+}
+
+func (hg *HenyeyGreenstein) Scatter(in ray.Ray, hr hitrecord.HitRecord, rng *utility.Rand) (color.Color, ray.Ray, bool) {
+	cosTheta := hg.sampleCos(rng.Float64())
+	sinTheta := math.Sqrt(max(0, 1-cosTheta*cosTheta))
+	phi := 2 * math.Pi * rng.Float64()
+
+	// Angles are measured from the direction the light was travelling in
+	w := vec3.UnitVector(in.Direction())
+	u, v := vec3.Basis(w)
+	dir := vec3.Mulf(w, cosTheta)
+	dir.Add(vec3.Mulf(u, sinTheta*math.Cos(phi)))
+	dir.Add(vec3.Mulf(v, sinTheta*math.Sin(phi)))
+
+	scattered := ray.NewWithTime(hr.Point(), dir, in.Time())
+	return hg.tex.Value(hr.U(), hr.V(), hr.Point()), scattered, true
+}
+
+func (hg *HenyeyGreenstein) Evaluate(in ray.Ray, hr hitrecord.HitRecord, dir vec3.Vector3) (color.Color, float64) {
+	pdf := hg.Phase(vec3.Dot(vec3.UnitVector(in.Direction()), vec3.UnitVector(dir)))
+	return vec3.Mulf(hg.tex.Value(hr.U(), hr.V(), hr.Point()), pdf), pdf
+}
+
+// Phase returns the probability density of scattering through an angle with the given cosine
+func (hg *HenyeyGreenstein) Phase(cosTheta float64) float64 {
+	denom := 1 + hg.g*hg.g - 2*hg.g*cosTheta
+	return (1 - hg.g*hg.g) / (4 * math.Pi * denom * math.Sqrt(denom))
+}
+
+// sampleCos inverts the distribution's CDF to turn a uniform xi into the cosine of a
+// scattering angle
+func (hg *HenyeyGreenstein) sampleCos(xi float64) float64 {
+	g := hg.g
+	if math.Abs(g) < 1e-3 {
+		return 1 - 2*xi
+	}
+	s := (1 - g*g) / (1 - g + 2*g*xi)
+	return min(max((1+g*g-s*s)/(2*g), -1), 1)
+}
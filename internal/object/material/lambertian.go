@@ -1,30 +1,49 @@
 package material
 
 import (
+	"math"
+
 	"github.com/sendelivery/go-trace-rays/internal/color"
 	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
 	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/texture"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
 	"github.com/sendelivery/go-trace-rays/internal/vec3"
 )
 
 type Lambertian struct {
-	albedo color.Color
+	tex texture.Texturer
 }
 
 func NewLambertian(albedo color.Color) *Lambertian {
+	return NewLambertianTexture(texture.NewSolidColor(albedo))
+}
+
+// NewLambertianTexture returns a Lambertian whose albedo is looked up from tex.
+func NewLambertianTexture(tex texture.Texturer) *Lambertian {
 	return &Lambertian{
-		albedo: albedo,
+		tex: tex,
 	}
 }
 
-func (l *Lambertian) Scatter(in ray.Ray, hr hitrecord.HitRecord) (color.Color, ray.Ray, bool) {
-	scatterDir := vec3.Add(hr.Normal(), vec3.NewRandomUnitVector())
+func (l *Lambertian) Scatter(in ray.Ray, hr hitrecord.HitRecord, rng *utility.Rand) (color.Color, ray.Ray, bool) {
+	scatterDir := vec3.Add(hr.Normal(), vec3.NewRandomUnitVector(rng))
 
 	// Catch a bad scatter direction (near zero)
 	if vec3.IsNearZero(scatterDir) {
 		scatterDir = hr.Normal()
 	}
 
-	scattered := ray.New(hr.Point(), scatterDir)
-	return l.albedo, scattered, true
+	scattered := ray.NewWithTime(hr.Point(), scatterDir, in.Time())
+	return l.tex.Value(hr.U(), hr.V(), hr.Point()), scattered, true
+}
+
+// Evaluate returns the albedo scaled by cos / pi, and Scatter's cosine weighted density
+func (l *Lambertian) Evaluate(in ray.Ray, hr hitrecord.HitRecord, dir vec3.Vector3) (color.Color, float64) {
+	cosine := vec3.Dot(hr.Normal(), vec3.UnitVector(dir))
+	if cosine <= 0 {
+		return color.Black, 0
+	}
+	pdf := cosine / math.Pi
+	return vec3.Mulf(l.tex.Value(hr.U(), hr.V(), hr.Point()), pdf), pdf
 }
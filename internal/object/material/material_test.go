@@ -0,0 +1,268 @@
+package material_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
+	"github.com/sendelivery/go-trace-rays/internal/object/material"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/texture"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+func TestHenyeyGreenstein(t *testing.T) {
+	t.Parallel()
+
+	// The mean cosine of the scattering angle is g
+	for _, g := range []float64{-0.7, 0, 0.3, 0.9} {
+		hg := material.NewHenyeyGreenstein(color.White, g)
+		in := ray.New(vec3.New(0, 0, 0), vec3.New(1, 2, 3))
+		hr := hitrecord.New(in, 1, vec3.New(1, 0, 0), hg)
+		rng := utility.NewRand(1, 0)
+
+		const n = 50000
+		sum := 0.0
+		for range n {
+			_, out, ok := hg.Scatter(in, hr, rng)
+			if !ok {
+				t.Fatalf("expected g=%v to scatter", g)
+			}
+			sum += vec3.Dot(vec3.UnitVector(in.Direction()), vec3.UnitVector(out.Direction()))
+		}
+
+		if got := sum / n; math.Abs(got-g) > 0.01 {
+			t.Errorf("unexpected mean cosine, got=%v. want=%v.", got, g)
+		}
+	}
+}
+
+func TestHenyeyGreensteinPhase(t *testing.T) {
+	t.Parallel()
+
+	// The phase function integrates to 1 over the sphere
+	for _, g := range []float64{-0.5, 0, 0.8} {
+		hg := material.NewHenyeyGreenstein(color.White, g)
+
+		const n = 10000
+		sum := 0.0
+		for i := range n {
+			cosTheta := -1 + 2*(float64(i)+0.5)/n
+			sum += hg.Phase(cosTheta) * 2 * math.Pi * 2 / n
+		}
+
+		if math.Abs(sum-1) > 1e-3 {
+			t.Errorf("unexpected integral for g=%v, got=%v. want=%v.", g, sum, 1)
+		}
+	}
+}
+
+// Scatter's attenuation must be what Evaluate gives for the direction it picked divided by
+// its density, except for materials scattering in discrete directions, which have no density
+func TestEvaluate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		mat      hitrecord.Scatterer
+		discrete bool
+	}{
+		"lambertian":        {mat: material.NewLambertian(color.New(0.2, 0.4, 0.6))},
+		"isotropic":         {mat: material.NewIsotropic(color.New(0.2, 0.4, 0.6))},
+		"henyey-greenstein": {mat: material.NewHenyeyGreenstein(color.New(0.2, 0.4, 0.6), 0.6)},
+		"metal":             {mat: material.NewMetal(color.New(0.2, 0.4, 0.6), 0.3), discrete: true},
+		"dielectric":        {mat: material.NewDielectric(1.5), discrete: true},
+		"conductor":         {mat: material.NewConductor(material.Gold, 0.4)},
+		"mirror conductor":  {mat: material.NewConductor(material.Silver, 0), discrete: true},
+		"rough dielectric":  {mat: material.NewRoughDielectric(1.5, 0.5)},
+		"smooth dielectric": {mat: material.NewRoughDielectric(1.5, 0), discrete: true},
+		"principled plastic": {mat: material.NewPrincipled(material.PrincipledParams{
+			BaseColor: texture.NewSolidColor(color.New(0.2, 0.4, 0.6)),
+		})},
+		"principled metal": {mat: material.NewPrincipled(material.PrincipledParams{
+			BaseColor: texture.NewSolidColor(color.New(0.9, 0.6, 0.3)),
+			Metallic:  texture.NewSolidValue(1),
+			Roughness: texture.NewSolidValue(0.3),
+		})},
+		"principled everything": {mat: material.NewPrincipled(material.PrincipledParams{
+			BaseColor:    texture.NewSolidColor(color.New(0.2, 0.4, 0.6)),
+			Metallic:     texture.NewSolidValue(0.3),
+			Clearcoat:    texture.NewSolidValue(0.8),
+			Sheen:        texture.NewSolidValue(1),
+			Transmission: texture.NewSolidValue(0.5),
+		})},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			in := ray.New(vec3.New(0, 2, 0), vec3.New(1, -2, 0))
+			hr := hitrecord.New(in, 1, vec3.New(0, 1, 0), tt.mat)
+			rng := utility.NewRand(2, 0)
+
+			for range 100 {
+				attenuation, out, ok := tt.mat.Scatter(in, hr, rng)
+				if !ok {
+					continue
+				}
+				f, pdf := tt.mat.Evaluate(in, hr, out.Direction())
+				if tt.discrete {
+					if pdf != 0 {
+						t.Fatalf("unexpected density, got=%v. want=%v.", pdf, 0)
+					}
+					continue
+				}
+
+				want := vec3.Div(f, pdf)
+				if pdf <= 0 || vec3.Sub(attenuation, want).Length() > 1e-9 {
+					t.Fatalf("unexpected attenuation, got=%q. want=%q.", &attenuation, &want)
+				}
+			}
+		})
+	}
+}
+
+// Inside a white furnace, lit evenly from every direction, materials that absorb nothing must
+// scatter exactly the light they receive, however rough they are and from whichever side
+func TestWhiteFurnace(t *testing.T) {
+	t.Parallel()
+
+	// A conductor reflecting everything, its refractive index is almost entirely imaginary
+	perfect := material.ComplexIOR{Eta: color.New(0, 0, 0), K: color.New(1e4, 1e4, 1e4)}
+
+	tests := map[string]struct {
+		mat  hitrecord.Scatterer
+		back bool
+	}{
+		"smooth conductor":            {mat: material.NewConductor(perfect, 0)},
+		"rough conductor":             {mat: material.NewConductor(perfect, 0.5)},
+		"very rough conductor":        {mat: material.NewConductor(perfect, 1)},
+		"rough dielectric":            {mat: material.NewRoughDielectric(1.5, 0.5)},
+		"very rough dielectric":       {mat: material.NewRoughDielectric(1.5, 1)},
+		"rough dielectric inside":     {mat: material.NewRoughDielectric(1.5, 0.5), back: true},
+		"smooth dielectric inside":    {mat: material.NewRoughDielectric(1.33, 0), back: true},
+		"very rough dense dielectric": {mat: material.NewRoughDielectric(2.4, 1)},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			rng := utility.NewRand(6, 0)
+			for _, angle := range []float64{0, 30, 60, 85} {
+				theta := utility.Deg2Rad(angle)
+				in := ray.New(vec3.New(-math.Sin(theta), math.Cos(theta), 0), vec3.New(math.Sin(theta), -math.Cos(theta), 0))
+
+				// The outward normal faces away from the ray when it arrives from inside
+				normal := vec3.New(0, 1, 0)
+				if tt.back {
+					normal = vec3.New(0, -1, 0)
+				}
+				hr := hitrecord.New(in, 1, normal, tt.mat)
+
+				const n = 20000
+				albedo := 0.0
+				for range n {
+					if attenuation, _, ok := tt.mat.Scatter(in, hr, rng); ok {
+						albedo += attenuation.Y() / n
+					}
+				}
+				if math.Abs(albedo-1) > 0.02 {
+					t.Errorf("unexpected albedo at %v degrees, got=%v. want=%v.", angle, albedo, 1)
+				}
+			}
+		})
+	}
+}
+
+func TestConductorColour(t *testing.T) {
+	t.Parallel()
+
+	in := ray.New(vec3.New(0, 1, 0), vec3.New(0, -1, 0))
+	tests := map[string]struct {
+		ior  material.ComplexIOR
+		want func(col color.Color) bool
+	}{
+		"gold":      {ior: material.Gold, want: func(c color.Color) bool { return c.X() > c.Y() && c.Y() > c.Z() }},
+		"copper":    {ior: material.Copper, want: func(c color.Color) bool { return c.X() > c.Y() && c.X() > c.Z() }},
+		"aluminium": {ior: material.Aluminium, want: func(c color.Color) bool { return c.X() > 0.85 && c.Z() > 0.85 }},
+		"silver":    {ior: material.Silver, want: func(c color.Color) bool { return c.X() > 0.9 && c.Z() > 0.9 }},
+	}
+
+	for name, tt := range tests {
+		mat := material.NewConductor(tt.ior, 0)
+		hr := hitrecord.New(in, 1, vec3.New(0, 1, 0), mat)
+		col, _, _ := mat.Scatter(in, hr, utility.NewRand(1, 0))
+		if !tt.want(col) {
+			t.Errorf("unexpected colour of %s, got=%q.", name, &col)
+		}
+		if ior, ok := material.ComplexIORByName(name); !ok || ior != tt.ior {
+			t.Errorf("unexpected refractive index for %q, got=%v, %v.", name, ior, ok)
+		}
+	}
+}
+
+// The principled material must never scatter more light than it receives, and with a white
+// base colour, the metal and the glass seen from outside absorb almost none of it
+func TestPrincipledFurnace(t *testing.T) {
+	t.Parallel()
+
+	white := texture.NewSolidValue(1)
+	tests := map[string]struct {
+		params   material.PrincipledParams
+		back     bool
+		lossless bool
+	}{
+		"plastic":       {params: material.PrincipledParams{BaseColor: white}},
+		"rough plastic": {params: material.PrincipledParams{BaseColor: white, Roughness: white}},
+		"metal": {
+			params:   material.PrincipledParams{BaseColor: white, Metallic: white, Roughness: texture.NewSolidValue(0.6)},
+			lossless: true,
+		},
+		"glass": {
+			params:   material.PrincipledParams{BaseColor: white, Transmission: white, Roughness: texture.NewSolidValue(0.2)},
+			lossless: true,
+		},
+		"glass inside": {
+			params: material.PrincipledParams{BaseColor: white, Transmission: white, Roughness: texture.NewSolidValue(0.2)},
+			back:   true,
+		},
+		"coated velvet": {params: material.PrincipledParams{
+			BaseColor: white, Clearcoat: white, Sheen: white, SheenTint: texture.NewSolidValue(0), Specular: white,
+		}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mat := material.NewPrincipled(tt.params)
+			rng := utility.NewRand(7, 0)
+			for _, angle := range []float64{0, 45, 80} {
+				theta := utility.Deg2Rad(angle)
+				in := ray.New(vec3.New(-math.Sin(theta), math.Cos(theta), 0), vec3.New(math.Sin(theta), -math.Cos(theta), 0))
+				normal := vec3.New(0, 1, 0)
+				if tt.back {
+					normal = vec3.New(0, -1, 0)
+				}
+				hr := hitrecord.New(in, 1, normal, mat)
+
+				const n = 20000
+				albedo := 0.0
+				for range n {
+					if attenuation, _, ok := mat.Scatter(in, hr, rng); ok {
+						albedo += attenuation.Y() / n
+					}
+				}
+				if albedo > 1.02 {
+					t.Errorf("unexpected albedo at %v degrees, got=%v. want at most 1.", angle, albedo)
+				}
+				if tt.lossless && albedo < 0.95 {
+					t.Errorf("unexpected albedo at %v degrees, got=%v. want=%v.", angle, albedo, 1)
+				}
+			}
+		})
+	}
+}
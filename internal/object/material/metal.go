@@ -4,31 +4,43 @@ import (
 	"github.com/sendelivery/go-trace-rays/internal/color"
 	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
 	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/texture"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
 	"github.com/sendelivery/go-trace-rays/internal/vec3"
 )
 
 type Metal struct {
-	albedo color.Color
-	fuzz   float64
+	tex  texture.Texturer
+	fuzz float64
 }
 
 func NewMetal(albedo color.Color, fuzz float64) *Metal {
+	return NewMetalTexture(texture.NewSolidColor(albedo), fuzz)
+}
+
+// NewMetalTexture returns a Metal whose albedo is looked up from tex.
+func NewMetalTexture(tex texture.Texturer, fuzz float64) *Metal {
 	return &Metal{
-		albedo: albedo,
-		fuzz:   min(fuzz, 1),
+		tex:  tex,
+		fuzz: min(fuzz, 1),
 	}
 }
 
-func (m *Metal) Scatter(in ray.Ray, hr hitrecord.HitRecord) (color.Color, ray.Ray, bool) {
+func (m *Metal) Scatter(in ray.Ray, hr hitrecord.HitRecord, rng *utility.Rand) (color.Color, ray.Ray, bool) {
 	reflected := vec3.Reflect(in.Direction(), hr.Normal())
 	reflected = vec3.UnitVector(reflected)
-	reflected.Add(vec3.Mulf(vec3.NewRandomUnitVector(), m.fuzz))
+	reflected.Add(vec3.Mulf(vec3.NewRandomUnitVector(rng), m.fuzz))
 
-	scattered := ray.New(hr.Point(), reflected)
+	scattered := ray.NewWithTime(hr.Point(), reflected, in.Time())
 
 	// scatter being false signals that we should absorb the ray,
 	// meaning black should be used for this ray
 	scatter := !(vec3.Dot(scattered.Direction(), hr.Normal()) <= 0)
 
-	return m.albedo, scattered, scatter
+	return m.tex.Value(hr.U(), hr.V(), hr.Point()), scattered, scatter
+}
+
+// Evaluate returns nothing, the fuzzed reflection is treated as a discrete direction
+func (m *Metal) Evaluate(in ray.Ray, hr hitrecord.HitRecord, dir vec3.Vector3) (color.Color, float64) {
+	return color.Black, 0
 }
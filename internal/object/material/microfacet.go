@@ -0,0 +1,152 @@
+package material
+
+import (
+	"math"
+	"math/cmplx"
+
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// minAlpha is the GGX roughness below which surfaces are treated as perfectly smooth, reflecting
+// and refracting in discrete directions
+const minAlpha = 1e-3
+
+// ggx is the GGX, or Trowbridge-Reitz, distribution of microfacet normals. Directions are in a
+// local frame with the macrosurface normal along +Z.
+type ggx struct {
+	alpha float64 // Width of the distribution, the square of the perceptual roughness
+}
+
+func newGGX(roughness float64) ggx {
+	r := max(0, min(roughness, 1))
+	return ggx{r * r}
+}
+
+func (g ggx) smooth() bool {
+	return g.alpha < minAlpha
+}
+
+// d returns the density of microfacets with normal m, per unit solid angle and projected area
+func (g ggx) d(m vec3.Vector3) float64 {
+	if m.Z() <= 0 {
+		return 0
+	}
+	a2 := g.alpha * g.alpha
+	t := m.Z()*m.Z()*(a2-1) + 1
+	return a2 / (math.Pi * t * t)
+}
+
+// lambda is Smith's auxiliary function for w, the share of the surface seen from w that is
+// hidden behind other microfacets
+func (g ggx) lambda(w vec3.Vector3) float64 {
+	cos2 := w.Z() * w.Z()
+	if cos2 <= 0 {
+		return math.Inf(1)
+	}
+	tan2 := (1 - cos2) / cos2
+	return (math.Sqrt(1+g.alpha*g.alpha*tan2) - 1) / 2
+}
+
+// g1 returns the fraction of microfacets visible from w
+func (g ggx) g1(w vec3.Vector3) float64 {
+	return 1 / (1 + g.lambda(w))
+}
+
+// g2 returns the fraction of microfacets visible from both v and l, correlated by height
+func (g ggx) g2(v, l vec3.Vector3) float64 {
+	return 1 / (1 + g.lambda(v) + g.lambda(l))
+}
+
+// sample returns a microfacet normal visible from v, which must be above the surface, chosen
+// with density g1(v) * max(0, v.m) * d(m) / v.z from the uniform random numbers u1 and u2. The
+// method is from E. Heitz, "Sampling the GGX Distribution of Visible Normals".
+func (g ggx) sample(v vec3.Vector3, u1, u2 float64) vec3.Vector3 {
+	// Stretch the view so the distribution becomes a hemisphere
+	vh := vec3.UnitVector(vec3.New(g.alpha*v.X(), g.alpha*v.Y(), v.Z()))
+
+	t1 := vec3.New(1, 0, 0)
+	if lensq := vh.X()*vh.X() + vh.Y()*vh.Y(); lensq > 0 {
+		t1 = vec3.Div(vec3.New(-vh.Y(), vh.X(), 0), math.Sqrt(lensq))
+	}
+	t2 := vec3.Cross(vh, t1)
+
+	// Pick a point on the disk the hemisphere projects to, squashed into the part of it that
+	// faces the view
+	r, phi := math.Sqrt(u1), 2*math.Pi*u2
+	p1, p2 := r*math.Cos(phi), r*math.Sin(phi)
+	s := (1 + vh.Z()) / 2
+	p2 = (1-s)*math.Sqrt(max(0, 1-p1*p1)) + s*p2
+
+	nh := vec3.Mulf(t1, p1)
+	nh.Add(vec3.Mulf(t2, p2))
+	nh.Add(vec3.Mulf(vh, math.Sqrt(max(0, 1-p1*p1-p2*p2))))
+
+	// Unstretch back to the microfacet normal
+	return vec3.UnitVector(vec3.New(g.alpha*nh.X(), g.alpha*nh.Y(), max(1e-9, nh.Z())))
+}
+
+// frame converts directions between the world and the local frame of a surface normal
+type frame struct {
+	u, v, n vec3.Vector3
+}
+
+func newFrame(n vec3.Vector3) frame {
+	u, v := vec3.Basis(n)
+	return frame{u, v, n}
+}
+
+func (f frame) toLocal(w vec3.Vector3) vec3.Vector3 {
+	return vec3.New(vec3.Dot(w, f.u), vec3.Dot(w, f.v), vec3.Dot(w, f.n))
+}
+
+func (f frame) toWorld(w vec3.Vector3) vec3.Vector3 {
+	out := vec3.Mulf(f.u, w.X())
+	out.Add(vec3.Mulf(f.v, w.Y()))
+	out.Add(vec3.Mulf(f.n, w.Z()))
+	return out
+}
+
+// reflectAbout returns v mirrored about the unit normal m, both pointing away from the surface
+func reflectAbout(v, m vec3.Vector3) vec3.Vector3 {
+	return vec3.Sub(vec3.Mulf(m, 2*vec3.Dot(v, m)), v)
+}
+
+// refractAbout returns v, pointing away from the surface, refracted through the microfacet
+// with unit normal m into a medium eta times denser, and false if it is totally reflected
+func refractAbout(v, m vec3.Vector3, eta float64) (vec3.Vector3, bool) {
+	c := vec3.Dot(v, m)
+	sin2 := (1 - c*c) / (eta * eta)
+	if sin2 >= 1 {
+		return vec3.Vector3{}, false
+	}
+	out := vec3.Div(v, -eta)
+	out.Add(vec3.Mulf(m, c/eta-math.Sqrt(1-sin2)))
+	return out, true
+}
+
+// fresnelDielectric returns the fraction of unpolarised light reflected by an interface into a
+// medium eta times denser, arriving at an angle with cosine c to its normal
+func fresnelDielectric(c, eta float64) float64 {
+	g2 := eta*eta - 1 + c*c
+	if g2 < 0 {
+		return 1
+	}
+	g := math.Sqrt(g2)
+	a := (g - c) / (g + c)
+	b := (c*(g+c) - 1) / (c*(g-c) + 1)
+	return a * a * (1 + b*b) / 2
+}
+
+// fresnelConductor returns the fraction of unpolarised light reflected by a conductor with the
+// complex refractive index eta + ik, arriving at an angle with cosine c to its normal
+func fresnelConductor(c, eta, k float64) float64 {
+	n := complex(eta, k)
+	cos := complex(max(0, min(c, 1)), 0)
+	sin2 := (1 - cos*cos) / (n * n)
+	cost := cmplx.Sqrt(1 - sin2)
+
+	parallel := (n*cos - cost) / (n*cos + cost)
+	perpendicular := (cos - n*cost) / (cos + n*cost)
+	abs2 := func(z complex128) float64 { return real(z)*real(z) + imag(z)*imag(z) }
+	return (abs2(parallel) + abs2(perpendicular)) / 2
+}
@@ -0,0 +1,282 @@
+package material
+
+import (
+	"math"
+
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/texture"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// PrincipledParams are the parameters of a Principled material. Each is a texture, so it can
+// vary over the surface; the parameters other than BaseColor are numbers from 0 to 1 read
+// from the texture's red channel. Textures left nil take their defaults.
+type PrincipledParams struct {
+	BaseColor texture.Texturer // Diffuse colour, or the reflectance of a metal. Default 0.8 grey.
+	Metallic  texture.Texturer // 0 for a dielectric, 1 for a metal. Default 0.
+	Roughness texture.Texturer // Microfacet roughness, 0 for a mirror finish. Default 0.5.
+
+	// Specular scales the reflectance of the dielectric, with the default 0.5 giving the
+	// reflectance of IOR, and 1 twice that.
+	Specular texture.Texturer
+
+	Clearcoat          texture.Texturer // Strength of a clear varnish layer on top. Default 0.
+	ClearcoatRoughness texture.Texturer // Roughness of the varnish. Default 0.
+	Sheen              texture.Texturer // Velvety glow at grazing angles, like cloth. Default 0.
+	SheenTint          texture.Texturer // Mix of the sheen from white to BaseColor. Default 0.5.
+
+	// Transmission is the share of the light entering the dielectric that passes through,
+	// like glass, rather than being scattered diffusely. Default 0.
+	Transmission texture.Texturer
+
+	IOR float64 // Refractive index of the dielectric. Default 1.5.
+}
+
+// Principled is an uber material, after B. Burley, "Physically-Based Shading at Disney", that
+// blends diffuse, specular, metallic, clearcoat, sheen and transmission lobes, in the way the
+// glTF 2.0 metallic-roughness material and its clearcoat, sheen and transmission extensions
+// describe:
+//
+//   - A dielectric reflects a Fresnel share of the light from GGX microfacets and passes the
+//     rest into a diffuse Lambertian base, with a sheen, or through as refraction.
+//   - A metal reflects all of it from the microfacets, tinted by the base colour.
+//   - Metallic blends between the two, and a clearcoat layer reflects a little more on top.
+type Principled struct {
+	baseColor, metallic, roughness, specular        texture.Texturer
+	clearcoat, clearcoatRoughness, sheen, sheenTint texture.Texturer
+	transmission                                    texture.Texturer
+	ior                                             float64
+}
+
+func NewPrincipled(p PrincipledParams) *Principled {
+	orDefault := func(tex texture.Texturer, x float64) texture.Texturer {
+		if tex == nil {
+			return texture.NewSolidValue(x)
+		}
+		return tex
+	}
+	ior := p.IOR
+	if ior <= 0 {
+		ior = 1.5
+	}
+	return &Principled{
+		baseColor:          orDefault(p.BaseColor, 0.8),
+		metallic:           orDefault(p.Metallic, 0),
+		roughness:          orDefault(p.Roughness, 0.5),
+		specular:           orDefault(p.Specular, 0.5),
+		clearcoat:          orDefault(p.Clearcoat, 0),
+		clearcoatRoughness: orDefault(p.ClearcoatRoughness, 0),
+		sheen:              orDefault(p.Sheen, 0),
+		sheenTint:          orDefault(p.SheenTint, 0.5),
+		transmission:       orDefault(p.Transmission, 0),
+		ior:                ior,
+	}
+}
+
+func (p *Principled) Scatter(in ray.Ray, hr hitrecord.HitRecord, rng *utility.Rand) (color.Color, ray.Ray, bool) {
+	s, ok := p.at(in, hr)
+	if !ok {
+		return color.Black, ray.Ray{}, false
+	}
+
+	// Pick a lobe to sample in proportion to its rough share of the light, then weigh the
+	// direction against every lobe that could have picked it
+	var l vec3.Vector3
+	xi := rng.Float64()
+	switch {
+	case xi < s.pDiffuse:
+		l = vec3.Add(vec3.New(0, 0, 1), vec3.NewRandomUnitVector(rng))
+		if vec3.IsNearZero(l) {
+			l = vec3.New(0, 0, 1)
+		}
+		l = vec3.UnitVector(l)
+	case xi < s.pDiffuse+s.pSpecular:
+		l = reflectAbout(s.v, s.base.sample(s.v, rng.Float64(), rng.Float64()))
+	case xi < s.pDiffuse+s.pSpecular+s.pCoat:
+		l = reflectAbout(s.v, s.coat.sample(s.v, rng.Float64(), rng.Float64()))
+	default:
+		var ok bool
+		if l, ok = refractAbout(s.v, s.base.sample(s.v, rng.Float64(), rng.Float64()), s.eta); !ok || l.Z() >= 0 {
+			return color.Black, ray.Ray{}, false
+		}
+	}
+
+	// Reflections off steep microfacets can point into the surface
+	if xi < s.pDiffuse+s.pSpecular+s.pCoat && l.Z() <= 0 {
+		return color.Black, ray.Ray{}, false
+	}
+
+	f, pdf := s.evaluate(l)
+	if pdf <= 0 {
+		return color.Black, ray.Ray{}, false
+	}
+	return vec3.Div(f, pdf), ray.NewWithTime(hr.Point(), s.frame.toWorld(l), in.Time()), true
+}
+
+func (p *Principled) Evaluate(in ray.Ray, hr hitrecord.HitRecord, dir vec3.Vector3) (color.Color, float64) {
+	s, ok := p.at(in, hr)
+	if !ok {
+		return color.Black, 0
+	}
+	return s.evaluate(s.frame.toLocal(vec3.UnitVector(dir)))
+}
+
+// principledHit is a Principled material's parameters looked up at a hit, seen from v
+type principledHit struct {
+	frame frame
+	v     vec3.Vector3 // Towards the viewer, in the local frame
+
+	baseColor                             color.Color
+	metallic, specular, transmission      float64
+	clearcoat, sheen                      float64
+	sheenColor                            color.Color
+	eta                                   float64 // Ratio of the refractive index beyond the surface to the one in front
+	base, coat                            ggx
+	pDiffuse, pSpecular, pCoat, pTransmit float64 // Chance of sampling each lobe
+}
+
+func (p *Principled) at(in ray.Ray, hr hitrecord.HitRecord) (principledHit, bool) {
+	f := newFrame(hr.Normal())
+	v := f.toLocal(vec3.UnitVector(vec3.Mulf(in.Direction(), -1)))
+	if v.Z() <= 0 {
+		return principledHit{}, false
+	}
+
+	value := func(tex texture.Texturer) float64 {
+		col := tex.Value(hr.U(), hr.V(), hr.Point())
+		return max(0, min(col.X(), 1))
+	}
+	s := principledHit{
+		frame:        f,
+		v:            v,
+		baseColor:    p.baseColor.Value(hr.U(), hr.V(), hr.Point()),
+		metallic:     value(p.metallic),
+		specular:     value(p.specular),
+		transmission: value(p.transmission),
+		clearcoat:    value(p.clearcoat),
+		sheen:        value(p.sheen),
+		eta:          p.ior,
+		base:         ggx{max(minAlpha, math.Pow(value(p.roughness), 2))},
+		coat:         ggx{max(minAlpha, math.Pow(value(p.clearcoatRoughness), 2))},
+	}
+	if !hr.FrontFace() {
+		s.eta = 1 / p.ior
+	}
+
+	tint := color.White
+	if lum := luminance(s.baseColor); lum > 0 {
+		tint = vec3.Div(s.baseColor, lum)
+	}
+	sheenTint := value(p.sheenTint)
+	s.sheenColor = vec3.Add(vec3.Mulf(color.White, 1-sheenTint), vec3.Mulf(tint, sheenTint))
+
+	// Share out the samples by how much light each lobe roughly reflects straight back
+	fv := s.fresnel(v.Z())
+	coat := s.clearcoat * fresnelDielectric(v.Z(), 1.5)
+	dielectric := (1 - s.metallic) * (1 - coat)
+	weights := [4]float64{
+		dielectric * (1 - fv) * (1 - s.transmission),
+		(1-coat)*s.metallic*luminance(schlick(s.baseColor, v.Z())) + dielectric*fv,
+		coat,
+		dielectric * (1 - fv) * s.transmission,
+	}
+	total := weights[0] + weights[1] + weights[2] + weights[3]
+	if total <= 0 {
+		return principledHit{}, false
+	}
+	s.pDiffuse, s.pSpecular, s.pCoat, s.pTransmit = weights[0]/total, weights[1]/total, weights[2]/total, weights[3]/total
+
+	return s, true
+}
+
+// evaluate returns the light scattered from direction l towards the viewer including the
+// cosine term, and the density of Scatter picking l
+func (s *principledHit) evaluate(l vec3.Vector3) (color.Color, float64) {
+	v := s.v
+	coat := s.clearcoat * fresnelDielectric(v.Z(), 1.5)
+
+	if l.Z() < 0 {
+		return s.transmit(l, coat)
+	}
+	if l.Z() == 0 {
+		return color.Black, 0
+	}
+
+	h := vec3.UnitVector(vec3.Add(v, l))
+	vh := vec3.Dot(v, h)
+	fd := s.fresnel(vh)
+
+	// Diffuse base, lit by the light the dielectric's specular reflection lets through. The
+	// sheen takes over from it towards grazing angles, so it adds colour but no energy.
+	sheen := s.sheen * math.Pow(1-vec3.Dot(l, h), 5)
+	diffuse := vec3.Add(vec3.Mulf(s.baseColor, 1-sheen), vec3.Mulf(s.sheenColor, sheen))
+	diffuse.Mulf((1 - s.transmission) * (1 - s.fresnel(v.Z())) * l.Z() / math.Pi)
+
+	// Microfacet reflections, brightened for the light lost between the microfacets
+	d := s.base.d(h)
+	specular := s.base.g2(v, l) * d / (4 * v.Z())
+	e := reflectedEnergy(v.Z(), s.base.alpha)
+	f0d := s.fresnel(1)
+	dielectric := vec3.Add(diffuse, vec3.Mulf(color.White, fd*specular*(1+f0d*(1-e)/e)))
+
+	f0 := s.baseColor
+	metal := schlick(f0, vh)
+	metal.Mulv(color.New(1+f0.X()*(1-e)/e, 1+f0.Y()*(1-e)/e, 1+f0.Z()*(1-e)/e))
+	metal.Mulf(specular)
+
+	f := vec3.Add(vec3.Mulf(dielectric, 1-s.metallic), vec3.Mulf(metal, s.metallic))
+	f.Mulf(1 - coat)
+	dc := s.coat.d(h)
+	f.Add(vec3.Mulf(color.White, coat*s.coat.g2(v, l)*dc/(4*v.Z())))
+
+	pdf := s.pDiffuse*l.Z()/math.Pi +
+		s.pSpecular*s.base.g1(v)*d/(4*v.Z()) +
+		s.pCoat*s.coat.g1(v)*dc/(4*v.Z())
+	return f, pdf
+}
+
+// transmit returns the light refracted from direction l, below the surface, towards the viewer
+// and the density of Scatter picking l
+func (s *principledHit) transmit(l vec3.Vector3, coat float64) (color.Color, float64) {
+	if s.pTransmit <= 0 {
+		return color.Black, 0
+	}
+	v := s.v
+
+	h := vec3.Add(v, vec3.Mulf(l, s.eta))
+	m := vec3.UnitVector(h)
+	if m.Z() < 0 {
+		m = vec3.Mulf(m, -1)
+	}
+	vm, lm := vec3.Dot(v, m), vec3.Dot(l, m)
+	if vm <= 0 || lm >= 0 {
+		return color.Black, 0
+	}
+
+	// Density of the refracted direction given the microfacet normal was visible from v
+	density := s.base.d(m) * vm * -lm * s.eta * s.eta / (v.Z() * h.LengthSquared())
+
+	weight := (1 - s.metallic) * (1 - coat) * s.transmission * (1 - s.fresnel(vm)) * s.base.g2(v, l) * density
+	return vec3.Mulf(s.baseColor, weight), s.pTransmit * s.base.g1(v) * density
+}
+
+// fresnel returns the reflectance of the dielectric for light arriving at an angle with cosine
+// cos to a microfacet, scaled by the specular parameter
+func (s *principledHit) fresnel(cos float64) float64 {
+	return min(1, 2*s.specular*fresnelDielectric(cos, s.eta))
+}
+
+// schlick returns C. Schlick's approximation of the Fresnel reflectance of a surface reflecting
+// f0 head on, for light arriving at an angle with cosine cos
+func schlick(f0 color.Color, cos float64) color.Color {
+	w := math.Pow(1-max(0, min(cos, 1)), 5)
+	return vec3.Add(vec3.Mulf(f0, 1-w), vec3.Mulf(color.White, w))
+}
+
+// luminance returns the brightness of col as perceived by the eye
+func luminance(col color.Color) float64 {
+	return 0.2126*col.X() + 0.7152*col.Y() + 0.0722*col.Z()
+}
@@ -0,0 +1,164 @@
+package material
+
+import (
+	"math"
+
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// RoughDielectric is frosted glass, a clear material with a surface of GGX microfacets that
+// each reflect or refract the light that reaches them. It follows B. Walter et al.,
+// "Microfacet Models for Refraction through Rough Surfaces", and like Dielectric, the light
+// refracted is not rescaled by the change in refractive index. A roughness of 0 is smooth glass.
+//
+// The light lost to shadowing between microfacets is added back, as for Conductor, so none of
+// the light reaching the glass is absorbed.
+type RoughDielectric struct {
+	refractionIndex float64
+	ggx             ggx
+
+	// Share of the light kept by a single bounce, by the cosine of the angle it arrives at,
+	// when entering the glass and when leaving it
+	enter, leave [energyTableSize]float64
+}
+
+// NewRoughDielectric returns a RoughDielectric with the given refractive index. roughness runs
+// from 0 for smooth glass to 1 for very frosted glass, and is clamped to that range.
+func NewRoughDielectric(refractionIndex, roughness float64) *RoughDielectric {
+	d := RoughDielectric{refractionIndex: refractionIndex, ggx: newGGX(roughness)}
+	if !d.ggx.smooth() {
+		for i := range energyTableSize {
+			d.enter[i] = d.integrate(tableNode(i), refractionIndex)
+			d.leave[i] = d.integrate(tableNode(i), 1/refractionIndex)
+		}
+	}
+	return &d
+}
+
+func (d *RoughDielectric) Scatter(in ray.Ray, hr hitrecord.HitRecord, rng *utility.Rand) (color.Color, ray.Ray, bool) {
+	f := newFrame(hr.Normal())
+	v := f.toLocal(vec3.UnitVector(vec3.Mulf(in.Direction(), -1)))
+	if v.Z() <= 0 {
+		return color.Black, ray.Ray{}, false
+	}
+	eta := d.eta(hr)
+
+	m := vec3.New(0, 0, 1)
+	if !d.ggx.smooth() {
+		m = d.ggx.sample(v, rng.Float64(), rng.Float64())
+	}
+
+	// Reflect or refract in proportion to the Fresnel reflectance, which then cancels out of
+	// the attenuation
+	var l vec3.Vector3
+	if rng.Float64() < fresnelDielectric(vec3.Dot(v, m), eta) {
+		l = reflectAbout(v, m)
+		if l.Z() <= 0 {
+			return color.Black, ray.Ray{}, false
+		}
+	} else {
+		var ok bool
+		if l, ok = refractAbout(v, m, eta); !ok || l.Z() >= 0 {
+			return color.Black, ray.Ray{}, false
+		}
+	}
+
+	scattered := ray.NewWithTime(hr.Point(), f.toWorld(l), in.Time())
+	if d.ggx.smooth() {
+		return color.White, scattered, true
+	}
+	weight := d.ggx.g2(v, l) / d.ggx.g1(v) / d.energy(v.Z(), hr)
+	return color.New(weight, weight, weight), scattered, true
+}
+
+// Evaluate returns the light reflected or refracted towards dir, and the density of sampling
+// it through the visible normals. Smooth glass returns nothing, like Dielectric.
+func (d *RoughDielectric) Evaluate(in ray.Ray, hr hitrecord.HitRecord, dir vec3.Vector3) (color.Color, float64) {
+	if d.ggx.smooth() {
+		return color.Black, 0
+	}
+
+	f := newFrame(hr.Normal())
+	v := f.toLocal(vec3.UnitVector(vec3.Mulf(in.Direction(), -1)))
+	l := f.toLocal(vec3.UnitVector(dir))
+	if v.Z() <= 0 || l.Z() == 0 {
+		return color.Black, 0
+	}
+	eta := d.eta(hr)
+
+	// density is the chance of sampling l without the share of microfacets visible from v,
+	// the value has the same terms with the shadowing towards l in place of that share
+	var m vec3.Vector3
+	var density float64
+	if l.Z() > 0 {
+		m = vec3.UnitVector(vec3.Add(v, l))
+		fr := fresnelDielectric(vec3.Dot(v, m), eta)
+		density = fr * d.ggx.d(m) / (4 * v.Z())
+	} else {
+		// The microfacet normal is halfway between the directions once weighted by the
+		// refractive indices, and must face the side the light arrives from
+		h := vec3.Add(v, vec3.Mulf(l, eta))
+		m = vec3.UnitVector(h)
+		if m.Z() < 0 {
+			m = vec3.Mulf(m, -1)
+		}
+		lm := vec3.Dot(l, m)
+		if lm >= 0 {
+			return color.Black, 0
+		}
+		fr := fresnelDielectric(vec3.Dot(v, m), eta)
+		density = (1 - fr) * d.ggx.d(m) * vec3.Dot(v, m) * -lm * eta * eta / (v.Z() * h.LengthSquared())
+	}
+	if vec3.Dot(v, m) <= 0 || density <= 0 {
+		return color.Black, 0
+	}
+
+	value := density * d.ggx.g2(v, l) / d.energy(v.Z(), hr)
+	return color.New(value, value, value), density * d.ggx.g1(v)
+}
+
+// eta returns the ratio of the refractive index on the far side of the surface to the near
+func (d *RoughDielectric) eta(hr hitrecord.HitRecord) float64 {
+	if hr.FrontFace() {
+		return d.refractionIndex
+	}
+	return 1 / d.refractionIndex
+}
+
+// energy returns the share of the light arriving at an angle with cosine cos that a single
+// bounce reflects or refracts, from the side of the surface hit
+func (d *RoughDielectric) energy(cos float64, hr hitrecord.HitRecord) float64 {
+	table := &d.leave
+	if hr.FrontFace() {
+		table = &d.enter
+	}
+	x := max(0, min(cos*(energyTableSize-1), energyTableSize-1))
+	i := min(int(x), energyTableSize-2)
+	fx := x - float64(i)
+	return (1-fx)*table[i] + fx*table[i+1]
+}
+
+// integrate returns the share of light arriving at an angle with cosine cos that a single
+// bounce reflects or refracts into a medium eta times denser, from a grid of visible normals
+func (d *RoughDielectric) integrate(cos, eta float64) float64 {
+	v := vec3.New(math.Sqrt(1-cos*cos), 0, cos)
+
+	sum := 0.0
+	for a := range energySamples {
+		for b := range energySamples {
+			m := d.ggx.sample(v, (float64(a)+0.5)/energySamples, (float64(b)+0.5)/energySamples)
+			fr := fresnelDielectric(vec3.Dot(v, m), eta)
+			if l := reflectAbout(v, m); l.Z() > 0 {
+				sum += fr * d.ggx.g2(v, l) / d.ggx.g1(v)
+			}
+			if l, ok := refractAbout(v, m, eta); ok && l.Z() < 0 {
+				sum += (1 - fr) * d.ggx.g2(v, l) / d.ggx.g1(v)
+			}
+		}
+	}
+	return sum / (energySamples * energySamples)
+}
@@ -0,0 +1,92 @@
+// Package medium provides participating media, volumes such as fog and smoke that scatter
+// light throughout their interior rather than at a surface
+package medium
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/sendelivery/go-trace-rays/internal/aabb"
+	"github.com/sendelivery/go-trace-rays/internal/interval"
+	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
+	"github.com/sendelivery/go-trace-rays/internal/object/hittable"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// Constant is a medium of uniform density filling a closed, convex boundary. A ray passing
+// through it is scattered at a random distance drawn from the density, or passes straight
+// through if that distance lies beyond the far side of the boundary.
+type Constant struct {
+	boundary      hittable.Hittabler
+	negInvDensity float64
+	phase         hitrecord.Scatterer
+}
+
+// NewConstant returns a medium filling boundary, which must be closed and convex, such as a
+// sphere or a box. Only the first two crossings of the boundary along a ray are found, so a
+// ray leaving a concave shape and entering it again passes through the gap as if it were
+// filled, and misses the medium beyond. density is the chance of scattering per unit distance
+// travelled and must be greater than 0, and phase decides the colour and direction of the
+// scattered light, e.g. a material.Isotropic.
+func NewConstant(boundary hittable.Hittabler, density float64, phase hitrecord.Scatterer) (*Constant, error) {
+	if !(density > 0) {
+		return nil, fmt.Errorf("medium density must be greater than 0, got %g", density)
+	}
+	return &Constant{
+		boundary:      boundary,
+		negInvDensity: -1 / density,
+		phase:         phase,
+	}, nil
+}
+
+func (c *Constant) Hit(r ray.Ray, rt interval.Interval) (hitrecord.HitRecord, bool) {
+	// Find where the ray enters and leaves the boundary, searching the whole line so rays that
+	// start inside the medium are found too
+	enter, ok := c.boundary.Hit(r, interval.UniverseInterval)
+	if !ok {
+		return hitrecord.HitRecord{}, false
+	}
+	exit, ok := c.boundary.Hit(r, interval.New(enter.T()+0.0001, math.Inf(1)))
+	if !ok {
+		return hitrecord.HitRecord{}, false
+	}
+
+	tMin, tMax := max(enter.T(), rt.Min, 0), min(exit.T(), rt.Max)
+	if tMin >= tMax {
+		return hitrecord.HitRecord{}, false
+	}
+
+	rayLength := r.Direction().Length()
+	distanceInside := (tMax - tMin) * rayLength
+	hitDistance := c.negInvDensity * math.Log(1-rayRand(r).Float64())
+	if hitDistance > distanceInside {
+		return hitrecord.HitRecord{}, false
+	}
+
+	// The normal is arbitrary, phase functions don't depend on it
+	t := tMin + hitDistance/rayLength
+	return hitrecord.New(r, t, vec3.New(1, 0, 0), c.phase), true
+}
+
+func (c *Constant) BoundingBox() aabb.AABB {
+	return c.boundary.BoundingBox()
+}
+
+// rayRand returns a generator seeded from r. Hit has no generator of its own to draw from, so
+// the scattering distance is derived from the ray instead, which keeps renders deterministic
+// however the work is split between goroutines. Scattered rays start at new points in new
+// directions, so successive bounces still draw independent distances.
+func rayRand(r ray.Ray) *utility.Rand {
+	o, d := r.Origin(), r.Direction()
+	seed := mix(mix(mix(0, o.X()), o.Y()), o.Z())
+	stream := mix(mix(mix(mix(0, d.X()), d.Y()), d.Z()), r.Time())
+	return utility.NewRand(seed, stream)
+}
+
+// mix folds the bits of f into h
+func mix(h uint64, f float64) uint64 {
+	h ^= math.Float64bits(f)
+	return h*0x100000001b3 ^ h>>29
+}
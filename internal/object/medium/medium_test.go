@@ -0,0 +1,102 @@
+package medium_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/interval"
+	"github.com/sendelivery/go-trace-rays/internal/object/material"
+	"github.com/sendelivery/go-trace-rays/internal/object/medium"
+	"github.com/sendelivery/go-trace-rays/internal/object/sphere"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+func TestTransmittance(t *testing.T) {
+	t.Parallel()
+
+	// Rays pass through the middle of a unit sphere, 2 units of medium, and should get through
+	// unscattered with probability exp(-2 * density)
+	for _, density := range []float64{0.1, 0.5, 2} {
+		fog, err := medium.NewConstant(
+			sphere.New(vec3.New(0, 0, 0), 1, nil), density, material.NewIsotropic(color.White),
+		)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		const n = 20000
+		passed := 0
+		for i := range n {
+			x := 1e-3 * (float64(i)/n - 0.5)
+			r := ray.New(vec3.New(x, 0, 5), vec3.New(0, 0, -2))
+			hr, ok := fog.Hit(r, interval.New(1e-3, math.Inf(1)))
+			if !ok {
+				passed++
+				continue
+			}
+			if hr.T() < 2 || hr.T() > 3 {
+				t.Fatalf("scattered outside the boundary, got t=%v. want it in [2, 3].", hr.T())
+			}
+		}
+
+		got, want := float64(passed)/n, math.Exp(-2*density)
+		if math.Abs(got-want) > 0.015 {
+			t.Errorf("unexpected transmittance for density %v, got=%v. want=%v.", density, got, want)
+		}
+	}
+}
+
+func TestHit(t *testing.T) {
+	t.Parallel()
+
+	// Dense enough to scatter almost as soon as a ray is inside
+	fog, err := medium.NewConstant(sphere.New(vec3.New(0, 0, 0), 1, nil), 1e6, material.NewIsotropic(color.White))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rt := interval.New(1e-3, math.Inf(1))
+
+	tests := map[string]struct {
+		r      ray.Ray
+		hit    bool
+		t      float64
+		within float64
+	}{
+		"from outside":  {r: ray.New(vec3.New(0, 0, 5), vec3.New(0, 0, -1)), hit: true, t: 4, within: 1e-3},
+		"from inside":   {r: ray.New(vec3.New(0, 0, 0), vec3.New(1, 0, 0)), hit: true, t: 1e-3, within: 1e-3},
+		"pointing away": {r: ray.New(vec3.New(0, 0, 5), vec3.New(0, 0, 1))},
+		"missing":       {r: ray.New(vec3.New(0, 2, 5), vec3.New(0, 0, -1))},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			hr, ok := fog.Hit(tt.r, rt)
+			if ok != tt.hit {
+				t.Fatalf("unexpected hit, got=%v. want=%v.", ok, tt.hit)
+			}
+			if ok && math.Abs(hr.T()-tt.t) > tt.within {
+				t.Errorf("unexpected t, got=%v. want=%v.", hr.T(), tt.t)
+			}
+
+			// The same ray always scatters at the same place
+			again, _ := fog.Hit(tt.r, rt)
+			if again.T() != hr.T() {
+				t.Errorf("hit is not deterministic, got=%v then %v.", hr.T(), again.T())
+			}
+		})
+	}
+}
+
+func TestNewConstantInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, density := range []float64{0, -1, math.NaN()} {
+		if _, err := medium.NewConstant(sphere.New(vec3.New(0, 0, 0), 1, nil), density, material.NewIsotropic(color.White)); err == nil {
+			t.Errorf("expected an error for density %v", density)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package mesh
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/sendelivery/go-trace-rays/internal/aabb"
+	"github.com/sendelivery/go-trace-rays/internal/interval"
+	"github.com/sendelivery/go-trace-rays/internal/object/bvh"
+	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
+	"github.com/sendelivery/go-trace-rays/internal/object/hittable"
+	"github.com/sendelivery/go-trace-rays/internal/object/triangle"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// bboxPadding is the minimum thickness of a face's bounding box along any axis
+const bboxPadding = 1e-4
+
+// UV is a pair of texture coordinates.
+type UV struct {
+	U, V float64
+}
+
+// VertexBuffer holds per-vertex attributes that may be shared between several meshes.
+// Normals and UVs are optional, when present they must have one entry per position.
+type VertexBuffer struct {
+	Positions []vec3.Vector3
+	Normals   []vec3.Vector3
+	UVs       []UV
+}
+
+// Validate reports an error if the optional attributes don't line up with the positions.
+func (vb *VertexBuffer) Validate() error {
+	if vb.Normals != nil && len(vb.Normals) != len(vb.Positions) {
+		return fmt.Errorf("got %d normals for %d positions", len(vb.Normals), len(vb.Positions))
+	}
+	if vb.UVs != nil && len(vb.UVs) != len(vb.Positions) {
+		return fmt.Errorf("got %d uvs for %d positions", len(vb.UVs), len(vb.Positions))
+	}
+	return nil
+}
+
+// Mesh is an indexed triangle mesh. Every three entries in indices describe one triangle by
+// its position in the vertex buffer. The faces are held in a BVH of their own so a mesh can
+// be added to a world like any other object.
+//
+// A mesh whose material gives off light can be sampled as a light, points being picked
+// uniformly over its whole surface.
+type Mesh struct {
+	vb      *VertexBuffer
+	indices []int
+	mat     hitrecord.Scatterer
+	bvh     *bvh.Node
+
+	// The faces again, hit with their geometric normals, and the running total of their areas.
+	// These are only kept for meshes that give off light, for sampling them.
+	flat  *bvh.Node
+	areas []float64
+}
+
+func New(vb *VertexBuffer, indices []int, mat hitrecord.Scatterer) (*Mesh, error) {
+	if err := vb.Validate(); err != nil {
+		return nil, err
+	}
+	if len(indices) == 0 {
+		return nil, fmt.Errorf("mesh has no triangles")
+	}
+	if len(indices)%3 != 0 {
+		return nil, fmt.Errorf("index count %d is not a multiple of 3", len(indices))
+	}
+	for i, idx := range indices {
+		if idx < 0 || idx >= len(vb.Positions) {
+			return nil, fmt.Errorf("index %d at %d is outside of vertex buffer bounds", idx, i)
+		}
+	}
+
+	m := &Mesh{
+		vb:      vb,
+		indices: indices,
+		mat:     mat,
+	}
+
+	faces := make([]hittable.Hittabler, 0, len(indices)/3)
+	for i := range len(indices) / 3 {
+		faces = append(faces, newFace(m, i, false))
+	}
+	m.bvh = bvh.NewFromObjects(faces)
+
+	if _, ok := mat.(hitrecord.Emitter); ok {
+		faces = make([]hittable.Hittabler, 0, len(indices)/3)
+		total := 0.0
+		for i := range len(indices) / 3 {
+			f := newFace(m, i, true)
+			faces = append(faces, f)
+			total += f.area()
+			m.areas = append(m.areas, total)
+		}
+		m.flat = bvh.NewFromObjects(faces)
+	}
+
+	return m, nil
+}
+
+// TriangleCount returns the number of triangles in the mesh.
+func (m *Mesh) TriangleCount() int {
+	return len(m.indices) / 3
+}
+
+func (m *Mesh) Hit(r ray.Ray, rt interval.Interval) (hitrecord.HitRecord, bool) {
+	return m.bvh.Hit(r, rt)
+}
+
+func (m *Mesh) BoundingBox() aabb.AABB {
+	return m.bvh.BoundingBox()
+}
+
+func (m *Mesh) Material() hitrecord.Scatterer {
+	return m.mat
+}
+
+// Sample returns the direction from origin to a point picked uniformly over the surface of the
+// mesh. The mesh must give off light.
+func (m *Mesh) Sample(origin vec3.Vector3, time float64, rng *utility.Rand) vec3.Vector3 {
+	total := m.areas[len(m.areas)-1]
+	i := min(sort.SearchFloat64s(m.areas, rng.Float64()*total), len(m.areas)-1)
+	a, b, c := face{m: m, i0: i * 3}.positions()
+
+	s := math.Sqrt(rng.Float64())
+	v := rng.Float64()
+	p := vec3.Mulf(a, 1-s)
+	p.Add(vec3.Mulf(b, s*(1-v)))
+	p.Add(vec3.Mulf(c, s*v))
+	return vec3.Sub(p, origin)
+}
+
+// PDF returns the density of Sample choosing r's direction, the geometric normal of the face
+// hit deciding the angle at which r meets the surface. It is 0 for meshes that give off no
+// light.
+func (m *Mesh) PDF(r ray.Ray, rt interval.Interval) float64 {
+	if m.flat == nil {
+		return 0
+	}
+	hr, ok := m.flat.Hit(r, rt)
+	if !ok {
+		return 0
+	}
+	return hittable.AreaPDF(r, hr, m.areas[len(m.areas)-1])
+}
+
+// face is a single triangle of a Mesh, referring back to the mesh's vertex buffer. Flat faces
+// ignore the vertex normals, shading with the geometric normal.
+type face struct {
+	m    *Mesh
+	i0   int // Offset of the face's first index into the mesh indices
+	flat bool
+	bbox aabb.AABB
+}
+
+func newFace(m *Mesh, n int, flat bool) face {
+	f := face{m: m, i0: n * 3, flat: flat}
+	a, b, c := f.positions()
+	bbox := aabb.NewFromBoxes(aabb.NewFromPoints(a, b), aabb.NewFromPoints(a, c))
+	f.bbox = bbox.Pad(bboxPadding)
+	return f
+}
+
+func (f face) positions() (vec3.Vector3, vec3.Vector3, vec3.Vector3) {
+	p := f.m.vb.Positions
+	idx := f.m.indices[f.i0 : f.i0+3]
+	return p[idx[0]], p[idx[1]], p[idx[2]]
+}
+
+func (f face) area() float64 {
+	a, b, c := f.positions()
+	return vec3.Cross(vec3.Sub(b, a), vec3.Sub(c, a)).Length() / 2
+}
+
+func (f face) Hit(r ray.Ray, rt interval.Interval) (hitrecord.HitRecord, bool) {
+	a, b, c := f.positions()
+	t, u, v, ok := triangle.Intersect(r, a, b, c, rt)
+	if !ok {
+		return hitrecord.HitRecord{}, false
+	}
+
+	geometricNormal := vec3.UnitVector(vec3.Cross(vec3.Sub(b, a), vec3.Sub(c, a)))
+	hr := hitrecord.New(r, t, geometricNormal, f.m.mat)
+
+	idx := f.m.indices[f.i0 : f.i0+3]
+	w := 1 - u - v
+
+	if uvs := f.m.vb.UVs; uvs != nil {
+		uv0, uv1, uv2 := uvs[idx[0]], uvs[idx[1]], uvs[idx[2]]
+		hr.SetUV(
+			w*uv0.U+u*uv1.U+v*uv2.U,
+			w*uv0.V+u*uv1.V+v*uv2.V,
+		)
+	} else {
+		hr.SetUV(u, v)
+	}
+
+	if normals := f.m.vb.Normals; normals != nil && !f.flat {
+		n := vec3.Mulf(normals[idx[0]], w)
+		n.Add(vec3.Mulf(normals[idx[1]], u))
+		n.Add(vec3.Mulf(normals[idx[2]], v))
+		if !vec3.IsNearZero(n) {
+			hr.SetShadingNormal(vec3.UnitVector(n))
+		}
+	}
+
+	return hr, true
+}
+
+func (f face) BoundingBox() aabb.AABB {
+	return f.bbox
+}
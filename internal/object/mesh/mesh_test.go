@@ -0,0 +1,125 @@
+package mesh_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sendelivery/go-trace-rays/internal/interval"
+	"github.com/sendelivery/go-trace-rays/internal/object/mesh"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// newQuad returns a unit square in the z=0 plane, facing +z, made of two triangles
+func newQuad(t *testing.T) *mesh.Mesh {
+	t.Helper()
+
+	vb := &mesh.VertexBuffer{
+		Positions: []vec3.Vector3{
+			vec3.New(0, 0, 0), vec3.New(1, 0, 0), vec3.New(1, 1, 0), vec3.New(0, 1, 0),
+		},
+		UVs: []mesh.UV{{0, 0}, {1, 0}, {1, 1}, {0, 1}},
+	}
+	m, err := mesh.New(vb, []int{0, 1, 2, 0, 2, 3}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return m
+}
+
+func TestHit(t *testing.T) {
+	tests := []struct {
+		name      string
+		origin    vec3.Vector3
+		direction vec3.Vector3
+		frontFace bool
+		normal    vec3.Vector3
+	}{
+		{
+			name:      "front",
+			origin:    vec3.New(0.25, 0.75, 1),
+			direction: vec3.New(0, 0, -1),
+			frontFace: true,
+			normal:    vec3.New(0, 0, 1),
+		},
+		{
+			name:      "back",
+			origin:    vec3.New(0.25, 0.75, -1),
+			direction: vec3.New(0, 0, 1),
+			frontFace: false,
+			normal:    vec3.New(0, 0, -1),
+		},
+	}
+
+	m := newQuad(t)
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			hr, ok := m.Hit(ray.New(tc.origin, tc.direction), interval.New(1e-3, math.Inf(1)))
+			if !ok {
+				t.Fatal("expected the ray to hit the mesh")
+			}
+			if hr.FrontFace() != tc.frontFace {
+				t.Errorf("unexpected front face, got=%t. want=%t.", hr.FrontFace(), tc.frontFace)
+			}
+			if n := hr.Normal(); !vec3.Equal(n, tc.normal) {
+				t.Errorf("unexpected normal, got=%q. want=%q.", &n, &tc.normal)
+			}
+			if math.Abs(hr.U()-0.25) > 1e-9 || math.Abs(hr.V()-0.75) > 1e-9 {
+				t.Errorf("unexpected uv, got=%f,%f. want=0.25,0.75.", hr.U(), hr.V())
+			}
+		})
+	}
+}
+
+func TestMiss(t *testing.T) {
+	m := newQuad(t)
+	r := ray.New(vec3.New(1.5, 0.5, 1), vec3.New(0, 0, -1))
+
+	if _, ok := m.Hit(r, interval.New(1e-3, math.Inf(1))); ok {
+		t.Error("expected the ray to miss the mesh")
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		vb      mesh.VertexBuffer
+		indices []int
+	}{
+		{
+			name: "no triangles",
+			vb:   mesh.VertexBuffer{Positions: make([]vec3.Vector3, 3)},
+		},
+		{
+			name:    "partial triangle",
+			vb:      mesh.VertexBuffer{Positions: make([]vec3.Vector3, 3)},
+			indices: []int{0, 1},
+		},
+		{
+			name:    "index out of range",
+			vb:      mesh.VertexBuffer{Positions: make([]vec3.Vector3, 3)},
+			indices: []int{0, 1, 3},
+		},
+		{
+			name: "mismatched normals",
+			vb: mesh.VertexBuffer{
+				Positions: make([]vec3.Vector3, 3),
+				Normals:   make([]vec3.Vector3, 2),
+			},
+			indices: []int{0, 1, 2},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := mesh.New(&tc.vb, tc.indices, nil); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package planar
+
+import (
+	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
+	"github.com/sendelivery/go-trace-rays/internal/object/hittable"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// NewBox returns the axis-aligned box with opposite corners a and b, made of six outward
+// facing quads. a and b must differ in every coordinate, a flat box has sides whose normals
+// and UVs are NaN.
+func NewBox(a, b vec3.Vector3, mat hitrecord.Scatterer) *hittable.HittableList {
+	lo := vec3.New(min(a.X(), b.X()), min(a.Y(), b.Y()), min(a.Z(), b.Z()))
+	hi := vec3.New(max(a.X(), b.X()), max(a.Y(), b.Y()), max(a.Z(), b.Z()))
+
+	dx := vec3.New(hi.X()-lo.X(), 0, 0)
+	dy := vec3.New(0, hi.Y()-lo.Y(), 0)
+	dz := vec3.New(0, 0, hi.Z()-lo.Z())
+
+	var sides hittable.HittableList
+	sides.Add(
+		NewQuad(vec3.New(lo.X(), lo.Y(), hi.Z()), dx, dy, mat),                // front
+		NewQuad(vec3.New(hi.X(), lo.Y(), hi.Z()), vec3.Mulf(dz, -1), dy, mat), // right
+		NewQuad(vec3.New(hi.X(), lo.Y(), lo.Z()), vec3.Mulf(dx, -1), dy, mat), // back
+		NewQuad(vec3.New(lo.X(), lo.Y(), lo.Z()), dz, dy, mat),                // left
+		NewQuad(vec3.New(lo.X(), hi.Y(), hi.Z()), dx, vec3.Mulf(dz, -1), mat), // top
+		NewQuad(vec3.New(lo.X(), lo.Y(), lo.Z()), dx, dz, mat),                // bottom
+	)
+	return &sides
+}
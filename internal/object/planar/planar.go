@@ -0,0 +1,207 @@
+// Package planar provides flat primitives: parallelogram quads, discs and infinite planes, and
+// boxes built from quads. Each is defined by an origin and two edge vectors spanning its plane,
+// hit points are located by their coordinates alpha and beta along those edges.
+package planar
+
+import (
+	"math"
+
+	"github.com/sendelivery/go-trace-rays/internal/aabb"
+	"github.com/sendelivery/go-trace-rays/internal/interval"
+	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
+	"github.com/sendelivery/go-trace-rays/internal/object/hittable"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// bboxPadding is the minimum thickness of a flat primitive's bounding box
+const bboxPadding = 1e-4
+
+// plane is the plane through q spanned by u and v
+type plane struct {
+	q, u, v vec3.Vector3
+	normal  vec3.Vector3 // Unit normal, facing along u x v
+	d       float64      // Plane constant, normal . p = d for every point p on the plane
+	w       vec3.Vector3 // Used to find the alpha and beta coordinates of a point
+}
+
+// newPlane returns the plane through q spanned by u and v, which must not be zero or parallel
+func newPlane(q, u, v vec3.Vector3) plane {
+	n := vec3.Cross(u, v)
+	normal := vec3.UnitVector(n)
+	return plane{
+		q:      q,
+		u:      u,
+		v:      v,
+		normal: normal,
+		d:      vec3.Dot(normal, q),
+		w:      vec3.Div(n, vec3.Dot(n, n)),
+	}
+}
+
+// intersect returns the ray parameter t at which r crosses the plane, along with the crossing
+// point's coordinates such that it lies at q + alpha*u + beta*v. ok is false if r runs
+// parallel to the plane or crosses it outside rt.
+func (p plane) intersect(r ray.Ray, rt interval.Interval) (t, alpha, beta float64, ok bool) {
+	denom := vec3.Dot(p.normal, r.Direction())
+	if math.Abs(denom) < 1e-8 {
+		return 0, 0, 0, false
+	}
+
+	t = (p.d - vec3.Dot(p.normal, r.Origin())) / denom
+	if !rt.Surrounds(t) {
+		return 0, 0, 0, false
+	}
+
+	hp := vec3.Sub(r.At(t), p.q)
+	alpha = vec3.Dot(p.w, vec3.Cross(hp, p.v))
+	beta = vec3.Dot(p.w, vec3.Cross(p.u, hp))
+	return t, alpha, beta, true
+}
+
+// Quad is a parallelogram with corner Q and edges u and v. Its front face is on the side u x v
+// points towards. UVs run from 0 to 1 along each edge.
+type Quad struct {
+	plane
+	mat  hitrecord.Scatterer
+	bbox aabb.AABB
+}
+
+// NewQuad returns the quad with corner q and edges u and v. u and v must be non-zero and not
+// parallel, otherwise the quad has no plane and its normal and UVs are NaN.
+func NewQuad(q, u, v vec3.Vector3, mat hitrecord.Scatterer) Quad {
+	bbox := aabb.NewFromBoxes(
+		aabb.NewFromPoints(q, vec3.Add(vec3.Add(q, u), v)),
+		aabb.NewFromPoints(vec3.Add(q, u), vec3.Add(q, v)),
+	).Pad(bboxPadding)
+	return Quad{newPlane(q, u, v), mat, bbox}
+}
+
+func (qd Quad) Hit(r ray.Ray, rt interval.Interval) (hitrecord.HitRecord, bool) {
+	t, alpha, beta, ok := qd.intersect(r, rt)
+	if !ok || alpha < 0 || alpha > 1 || beta < 0 || beta > 1 {
+		return hitrecord.HitRecord{}, false
+	}
+
+	hr := hitrecord.New(r, t, qd.normal, qd.mat)
+	hr.SetUV(alpha, beta)
+	return hr, true
+}
+
+func (qd Quad) BoundingBox() aabb.AABB {
+	return qd.bbox
+}
+
+func (qd Quad) Material() hitrecord.Scatterer {
+	return qd.mat
+}
+
+// Sample returns the direction from origin to a point picked uniformly over the quad
+func (qd Quad) Sample(origin vec3.Vector3, time float64, rng *utility.Rand) vec3.Vector3 {
+	p := vec3.Add(qd.q, vec3.Mulf(qd.u, rng.Float64()))
+	p.Add(vec3.Mulf(qd.v, rng.Float64()))
+	return vec3.Sub(p, origin)
+}
+
+func (qd Quad) PDF(r ray.Ray, rt interval.Interval) float64 {
+	hr, ok := qd.Hit(r, rt)
+	if !ok {
+		return 0
+	}
+	return hittable.AreaPDF(r, hr, vec3.Cross(qd.u, qd.v).Length())
+}
+
+// Disk is a flat disc facing along normal. U runs from 0 to 1 around the disc, V from 0 at
+// the centre to 1 at the rim.
+type Disk struct {
+	plane
+	mat  hitrecord.Scatterer
+	bbox aabb.AABB
+}
+
+// NewDisk returns the disc of radius around centre facing along normal. normal must be non-zero
+// and radius greater than 0.
+func NewDisk(centre, normal vec3.Vector3, radius float64, mat hitrecord.Scatterer) Disk {
+	n := vec3.UnitVector(normal)
+	u, v := vec3.Basis(n)
+
+	// The disc extends radius * sin(angle between the axis and the normal) along each axis
+	extent := vec3.New(
+		radius*math.Sqrt(max(0, 1-n.X()*n.X())),
+		radius*math.Sqrt(max(0, 1-n.Y()*n.Y())),
+		radius*math.Sqrt(max(0, 1-n.Z()*n.Z())),
+	)
+	bbox := aabb.NewFromPoints(vec3.Sub(centre, extent), vec3.Add(centre, extent)).Pad(bboxPadding)
+
+	return Disk{newPlane(centre, vec3.Mulf(u, radius), vec3.Mulf(v, radius)), mat, bbox}
+}
+
+func (dk Disk) Hit(r ray.Ray, rt interval.Interval) (hitrecord.HitRecord, bool) {
+	t, alpha, beta, ok := dk.intersect(r, rt)
+	if !ok {
+		return hitrecord.HitRecord{}, false
+	}
+	rho := alpha*alpha + beta*beta
+	if rho > 1 {
+		return hitrecord.HitRecord{}, false
+	}
+
+	hr := hitrecord.New(r, t, dk.normal, dk.mat)
+	hr.SetUV(math.Atan2(beta, alpha)/(2*math.Pi)+0.5, math.Sqrt(rho))
+	return hr, true
+}
+
+func (dk Disk) BoundingBox() aabb.AABB {
+	return dk.bbox
+}
+
+func (dk Disk) Material() hitrecord.Scatterer {
+	return dk.mat
+}
+
+// Sample returns the direction from origin to a point picked uniformly over the disc
+func (dk Disk) Sample(origin vec3.Vector3, time float64, rng *utility.Rand) vec3.Vector3 {
+	rho := math.Sqrt(rng.Float64())
+	phi := 2 * math.Pi * rng.Float64()
+	p := vec3.Add(dk.q, vec3.Mulf(dk.u, rho*math.Cos(phi)))
+	p.Add(vec3.Mulf(dk.v, rho*math.Sin(phi)))
+	return vec3.Sub(p, origin)
+}
+
+func (dk Disk) PDF(r ray.Ray, rt interval.Interval) float64 {
+	hr, ok := dk.Hit(r, rt)
+	if !ok {
+		return 0
+	}
+	return hittable.AreaPDF(r, hr, math.Pi*vec3.Cross(dk.u, dk.v).Length())
+}
+
+// Plane is an infinite plane through a point, facing along normal. UVs repeat every unit of
+// distance across the plane, so image textures tile.
+type Plane struct {
+	plane
+	mat hitrecord.Scatterer
+}
+
+func NewPlane(point, normal vec3.Vector3, mat hitrecord.Scatterer) Plane {
+	u, v := vec3.Basis(vec3.UnitVector(normal))
+	return Plane{newPlane(point, u, v), mat}
+}
+
+func (pl Plane) Hit(r ray.Ray, rt interval.Interval) (hitrecord.HitRecord, bool) {
+	t, alpha, beta, ok := pl.intersect(r, rt)
+	if !ok {
+		return hitrecord.HitRecord{}, false
+	}
+
+	hr := hitrecord.New(r, t, pl.normal, pl.mat)
+	hr.SetUV(alpha-math.Floor(alpha), beta-math.Floor(beta))
+	return hr, true
+}
+
+// BoundingBox returns the universe, a plane has no bounds unless it is axis aligned and even
+// then only in one direction.
+func (pl Plane) BoundingBox() aabb.AABB {
+	return aabb.Universe
+}
@@ -0,0 +1,109 @@
+package planar_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sendelivery/go-trace-rays/internal/interval"
+	"github.com/sendelivery/go-trace-rays/internal/object/hittable"
+	"github.com/sendelivery/go-trace-rays/internal/object/planar"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestHit(t *testing.T) {
+	t.Parallel()
+
+	// A 2x1 quad in the z=0 plane facing +z, with its corner at the origin
+	quad := planar.NewQuad(vec3.New(0, 0, 0), vec3.New(2, 0, 0), vec3.New(0, 1, 0), nil)
+	// A disc of radius 2 in the y=1 plane facing up
+	disk := planar.NewDisk(vec3.New(0, 1, 0), vec3.New(0, 3, 0), 2, nil)
+	// The ground plane
+	plane := planar.NewPlane(vec3.New(0, -1, 0), vec3.New(0, 1, 0), nil)
+
+	tests := []struct {
+		name      string
+		object    hittable.Hittabler
+		origin    vec3.Vector3
+		direction vec3.Vector3
+		hit       bool
+		t         float64
+		frontFace bool
+		checkUV   bool
+		u, v      float64
+	}{
+		{name: "quad front", object: quad, origin: vec3.New(0.5, 0.25, 1), direction: vec3.New(0, 0, -1), hit: true, t: 1, frontFace: true, checkUV: true, u: 0.25, v: 0.25},
+		{name: "quad back", object: quad, origin: vec3.New(1.5, 0.75, -2), direction: vec3.New(0, 0, 1), hit: true, t: 2, frontFace: false, checkUV: true, u: 0.75, v: 0.75},
+		{name: "quad miss", object: quad, origin: vec3.New(2.5, 0.5, 1), direction: vec3.New(0, 0, -1)},
+		{name: "quad parallel", object: quad, origin: vec3.New(0.5, 0.5, 1), direction: vec3.New(1, 0, 0)},
+		{name: "quad behind", object: quad, origin: vec3.New(0.5, 0.5, 1), direction: vec3.New(0, 0, 1)},
+		{name: "disk centre", object: disk, origin: vec3.New(0, 3, 0), direction: vec3.New(0, -1, 0), hit: true, t: 2, frontFace: true, checkUV: true, u: 0.5, v: 0},
+		{name: "disk rim", object: disk, origin: vec3.New(1.2, 3, 1.2), direction: vec3.New(0, -1, 0), hit: true, t: 2, frontFace: true, checkUV: true, u: 0.375, v: math.Sqrt(2*1.2*1.2) / 2},
+		{name: "disk miss", object: disk, origin: vec3.New(1.5, 3, 1.5), direction: vec3.New(0, -1, 0)},
+		{name: "plane far away", object: plane, origin: vec3.New(1e4, 5, -3e4), direction: vec3.New(0, -2, 0), hit: true, t: 3, frontFace: true},
+		{name: "plane from below", object: plane, origin: vec3.New(0, -2, 0), direction: vec3.New(0, 1, 0), hit: true, t: 1, frontFace: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := ray.New(tc.origin, tc.direction)
+			hr, ok := tc.object.Hit(r, interval.New(1e-3, math.Inf(1)))
+			if ok != tc.hit {
+				t.Fatalf("unexpected result, got=%v. want=%v.", ok, tc.hit)
+			}
+			if !ok {
+				return
+			}
+
+			if !near(hr.T(), tc.t) {
+				t.Errorf("unexpected t, got=%v. want=%v.", hr.T(), tc.t)
+			}
+			if hr.FrontFace() != tc.frontFace {
+				t.Errorf("unexpected front face, got=%v. want=%v.", hr.FrontFace(), tc.frontFace)
+			}
+			if vec3.Dot(hr.Normal(), r.Direction()) >= 0 {
+				n := hr.Normal()
+				t.Errorf("normal %q does not face the ray", &n)
+			}
+			if tc.checkUV && (!near(hr.U(), tc.u) || !near(hr.V(), tc.v)) {
+				t.Errorf("unexpected uv, got=%v, %v. want=%v, %v.", hr.U(), hr.V(), tc.u, tc.v)
+			}
+			if !tc.object.BoundingBox().Hit(r, interval.New(1e-3, math.Inf(1))) {
+				t.Errorf("ray hit the object but missed its bounding box")
+			}
+		})
+	}
+}
+
+func TestBox(t *testing.T) {
+	t.Parallel()
+
+	box := planar.NewBox(vec3.New(1, 1, 1), vec3.New(-1, -1, -1), nil)
+
+	directions := []vec3.Vector3{
+		vec3.New(1, 0, 0), vec3.New(-1, 0, 0),
+		vec3.New(0, 1, 0), vec3.New(0, -1, 0),
+		vec3.New(0, 0, 1), vec3.New(0, 0, -1),
+	}
+
+	for _, d := range directions {
+		// From outside, looking back at the box, every side faces the ray
+		origin := vec3.Mulf(d, 3)
+		hr, ok := box.Hit(ray.New(origin, vec3.Mulf(d, -1)), interval.New(1e-3, math.Inf(1)))
+		if !ok || !near(hr.T(), 2) || !hr.FrontFace() {
+			t.Errorf("unexpected hit from %q, got ok=%v t=%v front=%v. want ok=true t=2 front=true.", &origin, ok, hr.T(), hr.FrontFace())
+		}
+
+		// From inside every side is a back face
+		hr, ok = box.Hit(ray.New(vec3.New(0, 0, 0), d), interval.New(1e-3, math.Inf(1)))
+		if !ok || !near(hr.T(), 1) || hr.FrontFace() {
+			t.Errorf("unexpected hit from inside towards %q, got ok=%v t=%v front=%v.", &d, ok, hr.T(), hr.FrontFace())
+		}
+	}
+}
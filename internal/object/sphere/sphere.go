@@ -3,24 +3,45 @@ package sphere
 import (
 	"math"
 
+	"github.com/sendelivery/go-trace-rays/internal/aabb"
 	"github.com/sendelivery/go-trace-rays/internal/interval"
 	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
+	"github.com/sendelivery/go-trace-rays/internal/object/hittable"
 	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
 	"github.com/sendelivery/go-trace-rays/internal/vec3"
 )
 
 type Sphere struct {
 	centre vec3.Vector3
+	motion vec3.Vector3 // Distance the centre moves from time 0 to time 1
 	radius float64
 	mat    hitrecord.Scatterer
+	bbox   aabb.AABB
 }
 
 func New(centre vec3.Vector3, radius float64, mat hitrecord.Scatterer) Sphere {
-	return Sphere{centre, math.Max(0, radius), mat}
+	return NewMoving(centre, centre, radius, mat)
+}
+
+// NewMoving returns a sphere moving in a straight line from centre0 at time 0 to centre1 at
+// time 1. It rests at centre0 before time 0 and at centre1 after time 1.
+func NewMoving(centre0, centre1 vec3.Vector3, radius float64, mat hitrecord.Scatterer) Sphere {
+	radius = math.Max(0, radius)
+	rvec := vec3.New(radius, radius, radius)
+	bbox0 := aabb.NewFromPoints(vec3.Sub(centre0, rvec), vec3.Add(centre0, rvec))
+	bbox1 := aabb.NewFromPoints(vec3.Sub(centre1, rvec), vec3.Add(centre1, rvec))
+	return Sphere{centre0, vec3.Sub(centre1, centre0), radius, mat, aabb.NewFromBoxes(bbox0, bbox1)}
+}
+
+// centreAt returns the centre of the sphere at the given time
+func (s Sphere) centreAt(time float64) vec3.Vector3 {
+	return vec3.Add(s.centre, vec3.Mulf(s.motion, max(0, min(1, time))))
 }
 
 func (s Sphere) Hit(r ray.Ray, rt interval.Interval) (hitrecord.HitRecord, bool) {
-	oc := vec3.Sub(s.centre, r.Origin())
+	centre := s.centreAt(r.Time())
+	oc := vec3.Sub(centre, r.Origin())
 	a := r.Direction().LengthSquared()
 	h := vec3.Dot(r.Direction(), oc)
 	c := oc.LengthSquared() - s.radius*s.radius
@@ -40,8 +61,66 @@ func (s Sphere) Hit(r ray.Ray, rt interval.Interval) (hitrecord.HitRecord, bool)
 			return hitrecord.HitRecord{}, false
 		}
 	}
-	outwardNormal := vec3.Div(vec3.Sub(r.At(root), s.centre), s.radius)
+	outwardNormal := vec3.Div(vec3.Sub(r.At(root), centre), s.radius)
 	hr := hitrecord.New(r, root, outwardNormal, s.mat)
+	hr.SetUV(sphereUV(outwardNormal))
 
 	return hr, true
 }
+
+// BoundingBox returns a box enclosing the sphere at every point of its motion
+func (s Sphere) BoundingBox() aabb.AABB {
+	return s.bbox
+}
+
+func (s Sphere) Material() hitrecord.Scatterer {
+	return s.mat
+}
+
+// Sample returns the direction from origin to a random point on the sphere. From outside, the
+// direction is picked uniformly from the cone of directions the sphere covers, so no samples
+// are wasted on its far side. From inside, a point is picked uniformly over the surface.
+func (s Sphere) Sample(origin vec3.Vector3, time float64, rng *utility.Rand) vec3.Vector3 {
+	toCentre := vec3.Sub(s.centreAt(time), origin)
+	distanceSquared := toCentre.LengthSquared()
+	if distanceSquared <= s.radius*s.radius {
+		p := vec3.Add(s.centreAt(time), vec3.Mulf(vec3.NewRandomUnitVector(rng), s.radius))
+		return vec3.Sub(p, origin)
+	}
+
+	cosThetaMax := math.Sqrt(1 - s.radius*s.radius/distanceSquared)
+	z := 1 + rng.Float64()*(cosThetaMax-1)
+	sinTheta := math.Sqrt(max(0, 1-z*z))
+	phi := 2 * math.Pi * rng.Float64()
+
+	w := vec3.UnitVector(toCentre)
+	u, v := vec3.Basis(w)
+	dir := vec3.Mulf(w, z)
+	dir.Add(vec3.Mulf(u, sinTheta*math.Cos(phi)))
+	dir.Add(vec3.Mulf(v, sinTheta*math.Sin(phi)))
+	return dir
+}
+
+func (s Sphere) PDF(r ray.Ray, rt interval.Interval) float64 {
+	hr, ok := s.Hit(r, rt)
+	if !ok {
+		return 0
+	}
+
+	distanceSquared := vec3.Sub(s.centreAt(r.Time()), r.Origin()).LengthSquared()
+	if distanceSquared <= s.radius*s.radius {
+		return hittable.AreaPDF(r, hr, 4*math.Pi*s.radius*s.radius)
+	}
+	cosThetaMax := math.Sqrt(1 - s.radius*s.radius/distanceSquared)
+	return 1 / (2 * math.Pi * (1 - cosThetaMax))
+}
+
+// sphereUV returns the surface coordinates of p, a point on the unit sphere centred at the
+// origin. u runs from 0 to 1 around the Y axis starting from -X, v from 0 to 1 going from
+// -Y to +Y.
+func sphereUV(p vec3.Vector3) (u, v float64) {
+	theta := math.Acos(max(-1, min(1, -p.Y())))
+	phi := math.Atan2(-p.Z(), p.X()) + math.Pi
+
+	return phi / (2 * math.Pi), theta / math.Pi
+}
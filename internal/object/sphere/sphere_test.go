@@ -0,0 +1,80 @@
+package sphere_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sendelivery/go-trace-rays/internal/interval"
+	"github.com/sendelivery/go-trace-rays/internal/object/sphere"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+func TestMoving(t *testing.T) {
+	t.Parallel()
+
+	s := sphere.NewMoving(vec3.New(0, 0, 0), vec3.New(0, 4, 0), 1, nil)
+
+	tests := []struct {
+		time float64
+		y    float64 // Height of the centre at time
+	}{
+		{time: -1, y: 0},
+		{time: 0, y: 0},
+		{time: 0.25, y: 1},
+		{time: 1, y: 4},
+		{time: 3, y: 4},
+	}
+
+	for _, tc := range tests {
+		r := ray.NewWithTime(vec3.New(0, tc.y, 5), vec3.New(0, 0, -1), tc.time)
+		hr, ok := s.Hit(r, interval.New(1e-3, math.Inf(1)))
+		if !ok {
+			t.Errorf("expected a hit at time %v", tc.time)
+			continue
+		}
+		if hr.T() != 4 {
+			t.Errorf("unexpected t at time %v, got=%v. want=%v.", tc.time, hr.T(), 4)
+		}
+		if !s.BoundingBox().Hit(r, interval.New(1e-3, math.Inf(1))) {
+			t.Errorf("ray at time %v hit the sphere but missed its bounding box", tc.time)
+		}
+	}
+}
+
+func TestUV(t *testing.T) {
+	t.Parallel()
+
+	s := sphere.New(vec3.New(0, 0, 0), 2, nil)
+
+	// Rays from outside the sphere aimed at its centre hit it at the point facing dir
+	tests := map[string]struct {
+		dir  vec3.Vector3
+		u, v float64
+	}{
+		"south pole":           {dir: vec3.New(0, -1, 0), u: 0.5, v: 0},
+		"north pole":           {dir: vec3.New(0, 1, 0), u: 0.5, v: 1},
+		"+X":                   {dir: vec3.New(1, 0, 0), u: 0.5, v: 0.5},
+		"+Z":                   {dir: vec3.New(0, 0, 1), u: 0.25, v: 0.5},
+		"-Z":                   {dir: vec3.New(0, 0, -1), u: 0.75, v: 0.5},
+		"seam, +Z side":        {dir: vec3.New(-1, 0, 1e-6), u: 0, v: 0.5},
+		"seam, -Z side":        {dir: vec3.New(-1, 0, -1e-6), u: 1, v: 0.5},
+		"half way to the pole": {dir: vec3.New(1, 1, 0), u: 0.5, v: 0.75},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := vec3.UnitVector(tt.dir)
+			r := ray.New(vec3.Mulf(dir, 5), vec3.Mulf(dir, -1))
+			hr, ok := s.Hit(r, interval.New(1e-3, math.Inf(1)))
+			if !ok {
+				t.Fatal("expected a hit")
+			}
+			if math.Abs(hr.U()-tt.u) > 1e-6 || math.Abs(hr.V()-tt.v) > 1e-6 {
+				t.Errorf("unexpected uv, got=(%v, %v). want=(%v, %v).", hr.U(), hr.V(), tt.u, tt.v)
+			}
+		})
+	}
+}
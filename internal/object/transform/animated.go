@@ -0,0 +1,140 @@
+package transform
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/sendelivery/go-trace-rays/internal/aabb"
+	"github.com/sendelivery/go-trace-rays/internal/interval"
+	"github.com/sendelivery/go-trace-rays/internal/mat4"
+	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
+	"github.com/sendelivery/go-trace-rays/internal/object/hittable"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// boundsSamples is the number of poses sampled between each pair of keyframes to find an
+// animated object's bounding box
+const boundsSamples = 32
+
+// Keyframe is the pose of an object at one moment. The object is scaled, then rotated, then
+// translated.
+type Keyframe struct {
+	Time      float64
+	Translate vec3.Vector3
+	Rotate    mat4.Quaternion
+	Scale     vec3.Vector3
+}
+
+// matrices returns the object to world transform of the pose and its inverse
+func (k Keyframe) matrices() (toWorld, toObject mat4.Matrix4) {
+	toWorld = mat4.TRS(k.Translate, k.Rotate, k.Scale)
+	toObject = mat4.Mul(
+		mat4.Scale(vec3.New(1/k.Scale.X(), 1/k.Scale.Y(), 1/k.Scale.Z())),
+		mat4.Mul(k.Rotate.Conjugate().Matrix(), mat4.Translate(vec3.Mulf(k.Translate, -1))),
+	)
+	return toWorld, toObject
+}
+
+// Animated is an object moving through a series of keyframes. Rays see it in the pose
+// interpolated at their time, translation and scale linearly and rotation spherically. Before
+// the first keyframe and after the last it holds still.
+type Animated struct {
+	object hittable.Hittabler
+	keys   []Keyframe // Sorted by time
+	bbox   aabb.AABB
+}
+
+// NewAnimated returns object animated through keys, which may be given in any order. Every
+// keyframe must have a distinct time and a scale with no zero component. Scale is interpolated
+// linearly, so each component must keep its sign from one keyframe to the next, or it would
+// pass through zero between them.
+func NewAnimated(object hittable.Hittabler, keys []Keyframe) (*Animated, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("animation has no keyframes")
+	}
+
+	keys = slices.Clone(keys)
+	slices.SortFunc(keys, func(a, b Keyframe) int { return cmp.Compare(a.Time, b.Time) })
+	for i := range keys {
+		s := keys[i].Scale
+		if s.X() == 0 || s.Y() == 0 || s.Z() == 0 {
+			return nil, fmt.Errorf("keyframe at time %v has a zero scale", keys[i].Time)
+		}
+		if i > 0 {
+			prev := keys[i-1]
+			if keys[i].Time == prev.Time {
+				return nil, fmt.Errorf("more than one keyframe at time %v", keys[i].Time)
+			}
+			if s.X()*prev.Scale.X() < 0 || s.Y()*prev.Scale.Y() < 0 || s.Z()*prev.Scale.Z() < 0 {
+				return nil, fmt.Errorf("keyframes at times %v and %v have scales of opposite sign", prev.Time, keys[i].Time)
+			}
+		}
+		keys[i].Rotate = keys[i].Rotate.Normalize()
+	}
+
+	a := &Animated{object: object, keys: keys}
+	a.bbox = a.bounds()
+	return a, nil
+}
+
+// pose returns the keyframe interpolated at time
+func (a *Animated) pose(time float64) Keyframe {
+	i, _ := slices.BinarySearchFunc(a.keys, time, func(k Keyframe, t float64) int {
+		return cmp.Compare(k.Time, t)
+	})
+	switch {
+	case i == 0:
+		return a.keys[0]
+	case i == len(a.keys):
+		return a.keys[len(a.keys)-1]
+	}
+
+	k0, k1 := a.keys[i-1], a.keys[i]
+	f := (time - k0.Time) / (k1.Time - k0.Time)
+	return Keyframe{
+		Time:      time,
+		Translate: lerp(k0.Translate, k1.Translate, f),
+		Rotate:    mat4.Slerp(k0.Rotate, k1.Rotate, f),
+		Scale:     lerp(k0.Scale, k1.Scale, f),
+	}
+}
+
+func (a *Animated) Hit(r ray.Ray, rt interval.Interval) (hitrecord.HitRecord, bool) {
+	toWorld, toObject := a.pose(r.Time()).matrices()
+	return hit(a.object, r, rt, toWorld, toObject)
+}
+
+func (a *Animated) BoundingBox() aabb.AABB {
+	return a.bbox
+}
+
+// bounds returns a box enclosing the object throughout its animation. Poses are sampled
+// between keyframes, and the box padded by a little more than a rotating corner can stray
+// outside the samples.
+func (a *Animated) bounds() aabb.AABB {
+	objectBox := a.object.BoundingBox()
+
+	box := Box(objectBox, mat4.TRS(a.keys[0].Translate, a.keys[0].Rotate, a.keys[0].Scale))
+	for i := 1; i < len(a.keys); i++ {
+		t0, t1 := a.keys[i-1].Time, a.keys[i].Time
+		for s := 1; s <= boundsSamples; s++ {
+			k := a.pose(t0 + (t1-t0)*float64(s)/boundsSamples)
+			box = aabb.NewFromBoxes(box, Box(objectBox, mat4.TRS(k.Translate, k.Rotate, k.Scale)))
+		}
+	}
+
+	if math.IsInf(box.X.Size(), 0) || box.X.Min > box.X.Max {
+		return box
+	}
+	size := vec3.New(box.X.Size(), box.Y.Size(), box.Z.Size())
+	pad := size.Length() * 0.01
+	return aabb.New(box.X.Expand(2*pad), box.Y.Expand(2*pad), box.Z.Expand(2*pad))
+}
+
+func lerp(a, b vec3.Vector3, f float64) vec3.Vector3 {
+	return vec3.Add(vec3.Mulf(a, 1-f), vec3.Mulf(b, f))
+}
@@ -0,0 +1,84 @@
+package transform
+
+import (
+	"math"
+
+	"github.com/sendelivery/go-trace-rays/internal/aabb"
+	"github.com/sendelivery/go-trace-rays/internal/interval"
+	"github.com/sendelivery/go-trace-rays/internal/mat4"
+	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
+	"github.com/sendelivery/go-trace-rays/internal/object/hittable"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// Lights returns the lights inside the transformed object, placed in the world.
+func (t *Transform) Lights() []hittable.Sampler {
+	return wrapLights(t.object, t.bbox, func(float64) (mat4.Matrix4, mat4.Matrix4) {
+		return t.toWorld, t.toObject
+	})
+}
+
+// Lights returns the lights inside the animated object, each posed by the time it is sampled at.
+func (a *Animated) Lights() []hittable.Sampler {
+	return wrapLights(a.object, a.bbox, func(time float64) (mat4.Matrix4, mat4.Matrix4) {
+		return a.pose(time).matrices()
+	})
+}
+
+func wrapLights(object hittable.Hittabler, bbox aabb.AABB, pose func(time float64) (toWorld, toObject mat4.Matrix4)) []hittable.Sampler {
+	inner := hittable.Lights(object)
+	lights := make([]hittable.Sampler, len(inner))
+	for i, light := range inner {
+		lights[i] = sampler{light: light, pose: pose, bbox: bbox}
+	}
+	return lights
+}
+
+// sampler is a light from inside a transformed object, sampled in the object's space with the
+// directions and densities carried over into the world
+type sampler struct {
+	light hittable.Sampler
+	pose  func(time float64) (toWorld, toObject mat4.Matrix4)
+	bbox  aabb.AABB // Encloses the whole transformed object
+}
+
+func (s sampler) Hit(r ray.Ray, rt interval.Interval) (hitrecord.HitRecord, bool) {
+	toWorld, toObject := s.pose(r.Time())
+	return hit(s.light, r, rt, toWorld, toObject)
+}
+
+func (s sampler) BoundingBox() aabb.AABB {
+	return s.bbox
+}
+
+func (s sampler) Material() hitrecord.Scatterer {
+	return s.light.Material()
+}
+
+func (s sampler) Sample(origin vec3.Vector3, time float64, rng *utility.Rand) vec3.Vector3 {
+	toWorld, toObject := s.pose(time)
+	return toWorld.Direction(s.light.Sample(toObject.Point(origin), time, rng))
+}
+
+// PDF returns the light's density for r in object space, scaled by how much the transform
+// squeezes or spreads directions around r. A linear map A takes the unit direction u onto the
+// sphere of directions with a Jacobian of |det A|/|Au|³.
+func (s sampler) PDF(r ray.Ray, rt interval.Interval) float64 {
+	toWorld, toObject := s.pose(r.Time())
+	local := ray.NewWithTime(toObject.Point(r.Origin()), toObject.Direction(r.Direction()), r.Time())
+
+	pdf := s.light.PDF(local, rt)
+	if pdf <= 0 {
+		return 0
+	}
+
+	x := toWorld.Direction(vec3.New(1, 0, 0))
+	y := toWorld.Direction(vec3.New(0, 1, 0))
+	z := toWorld.Direction(vec3.New(0, 0, 1))
+	det := math.Abs(vec3.Dot(x, vec3.Cross(y, z)))
+
+	stretch := r.Direction().Length() / local.Direction().Length()
+	return pdf * stretch * stretch * stretch / det
+}
@@ -0,0 +1,97 @@
+// Package transform places any hittable in the world with an affine transform, so one object
+// can be instanced in several poses without being duplicated.
+package transform
+
+import (
+	"errors"
+	"math"
+
+	"github.com/sendelivery/go-trace-rays/internal/aabb"
+	"github.com/sendelivery/go-trace-rays/internal/interval"
+	"github.com/sendelivery/go-trace-rays/internal/mat4"
+	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
+	"github.com/sendelivery/go-trace-rays/internal/object/hittable"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// Transform is an object transformed from its own space into the world. Rays are transformed
+// into object space to be tested against the object, and hits are transformed back.
+type Transform struct {
+	object   hittable.Hittabler
+	toWorld  mat4.Matrix4
+	toObject mat4.Matrix4
+	bbox     aabb.AABB
+}
+
+// New returns object transformed by m. m must be invertible.
+func New(object hittable.Hittabler, m mat4.Matrix4) (*Transform, error) {
+	inv, ok := m.Inverse()
+	if !ok {
+		return nil, errors.New("transform matrix is not invertible")
+	}
+	return &Transform{
+		object:   object,
+		toWorld:  m,
+		toObject: inv,
+		bbox:     Box(object.BoundingBox(), m),
+	}, nil
+}
+
+// Matrix returns the object to world transform
+func (t *Transform) Matrix() mat4.Matrix4 {
+	return t.toWorld
+}
+
+func (t *Transform) Hit(r ray.Ray, rt interval.Interval) (hitrecord.HitRecord, bool) {
+	return hit(t.object, r, rt, t.toWorld, t.toObject)
+}
+
+// hit tests object, transformed into the world by toWorld, against r
+func hit(object hittable.Hittabler, r ray.Ray, rt interval.Interval, toWorld, toObject mat4.Matrix4) (hitrecord.HitRecord, bool) {
+	// The direction is not normalised, so the ray parameter is the same in both spaces
+	local := ray.NewWithTime(toObject.Point(r.Origin()), toObject.Direction(r.Direction()), r.Time())
+
+	hr, ok := object.Hit(local, rt)
+	if !ok {
+		return hitrecord.HitRecord{}, false
+	}
+
+	hr.Transform(toWorld.Point(hr.Point()), toObject.Normal(hr.Normal()))
+	return hr, true
+}
+
+func (t *Transform) BoundingBox() aabb.AABB {
+	return t.bbox
+}
+
+// Box returns the axis-aligned box enclosing bb transformed by m. Unbounded boxes stay
+// unbounded and empty boxes stay empty.
+func Box(bb aabb.AABB, m mat4.Matrix4) aabb.AABB {
+	for axis := range 3 {
+		i := bb.AxisInterval(axis)
+		if i.Min > i.Max {
+			return aabb.Empty
+		}
+		if math.IsInf(i.Min, 0) || math.IsInf(i.Max, 0) {
+			return aabb.Universe
+		}
+	}
+
+	box := aabb.Empty
+	for corner := range 8 {
+		x := pick(bb.X, corner&1 != 0)
+		y := pick(bb.Y, corner&2 != 0)
+		z := pick(bb.Z, corner&4 != 0)
+		p := m.Point(vec3.New(x, y, z))
+		box = aabb.NewFromBoxes(box, aabb.NewFromPoints(p, p))
+	}
+	return box
+}
+
+func pick(i interval.Interval, max bool) float64 {
+	if max {
+		return i.Max
+	}
+	return i.Min
+}
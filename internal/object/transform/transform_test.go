@@ -0,0 +1,212 @@
+package transform_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sendelivery/go-trace-rays/internal/interval"
+	"github.com/sendelivery/go-trace-rays/internal/mat4"
+	"github.com/sendelivery/go-trace-rays/internal/object/bvh"
+	"github.com/sendelivery/go-trace-rays/internal/object/hittable"
+	"github.com/sendelivery/go-trace-rays/internal/object/planar"
+	"github.com/sendelivery/go-trace-rays/internal/object/sphere"
+	"github.com/sendelivery/go-trace-rays/internal/object/transform"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+func near(a, b vec3.Vector3) bool {
+	return vec3.Sub(a, b).Length() < 1e-9
+}
+
+func TestHit(t *testing.T) {
+	t.Parallel()
+
+	unit := sphere.New(vec3.New(0, 0, 0), 1, nil)
+	var list hittable.HittableList
+	list.Add(planar.NewBox(vec3.New(-1, -1, -1), vec3.New(1, 1, 1), nil))
+	boxBVH := bvh.New(list)
+
+	tests := []struct {
+		name      string
+		object    hittable.Hittabler
+		m         mat4.Matrix4
+		origin    vec3.Vector3
+		direction vec3.Vector3
+		hit       bool
+		point     vec3.Vector3
+		normal    vec3.Vector3
+	}{
+		{
+			name:      "translated sphere",
+			object:    unit,
+			m:         mat4.Translate(vec3.New(5, 0, 0)),
+			origin:    vec3.New(5, 0, 5),
+			direction: vec3.New(0, 0, -1),
+			hit:       true,
+			point:     vec3.New(5, 0, 1),
+			normal:    vec3.New(0, 0, 1),
+		},
+		{
+			name:      "translated sphere missed",
+			object:    unit,
+			m:         mat4.Translate(vec3.New(5, 0, 0)),
+			origin:    vec3.New(0, 0, 5),
+			direction: vec3.New(0, 0, -1),
+		},
+		{
+			name:      "stretched sphere",
+			object:    unit,
+			m:         mat4.Scale(vec3.New(3, 1, 1)),
+			origin:    vec3.New(10, 0, 0),
+			direction: vec3.New(-2, 0, 0),
+			hit:       true,
+			point:     vec3.New(3, 0, 0),
+			normal:    vec3.New(1, 0, 0),
+		},
+		{
+			name:      "rotated box in a bvh",
+			object:    boxBVH,
+			m:         mat4.Rotate(vec3.New(0, 1, 0), math.Pi/4),
+			origin:    vec3.New(5, 0, 5),
+			direction: vec3.New(-1, 0, -1),
+			hit:       true,
+			point:     vec3.New(math.Sqrt2/2, 0, math.Sqrt2/2),
+			normal:    vec3.New(math.Sqrt2/2, 0, math.Sqrt2/2),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			tr, err := transform.New(tc.object, tc.m)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			r := ray.New(tc.origin, tc.direction)
+			hr, ok := tr.Hit(r, interval.New(1e-3, math.Inf(1)))
+			if ok != tc.hit {
+				t.Fatalf("unexpected result, got=%v. want=%v.", ok, tc.hit)
+			}
+			if !ok {
+				return
+			}
+
+			if p := hr.Point(); !near(p, tc.point) {
+				t.Errorf("unexpected point, got=%q. want=%q.", &p, &tc.point)
+			}
+			if n := hr.Normal(); !near(n, tc.normal) {
+				t.Errorf("unexpected normal, got=%q. want=%q.", &n, &tc.normal)
+			}
+			if p := r.At(hr.T()); !near(p, tc.point) {
+				t.Errorf("t does not match the point, got=%q. want=%q.", &p, &tc.point)
+			}
+			if !tr.BoundingBox().Hit(r, interval.New(1e-3, math.Inf(1))) {
+				t.Errorf("ray hit the object but missed its bounding box")
+			}
+		})
+	}
+}
+
+func TestBoundingBox(t *testing.T) {
+	t.Parallel()
+
+	box := planar.NewBox(vec3.New(0, 0, 0), vec3.New(2, 1, 1), nil)
+	tr, err := transform.New(box, mat4.Mul(mat4.Translate(vec3.New(0, 5, 0)), mat4.Rotate(vec3.New(0, 0, 1), math.Pi/2)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	bb := tr.BoundingBox()
+	got := [][2]float64{{bb.X.Min, bb.X.Max}, {bb.Y.Min, bb.Y.Max}, {bb.Z.Min, bb.Z.Max}}
+	want := [][2]float64{{-1, 0}, {5, 7}, {0, 1}}
+	for axis := range 3 {
+		// The box's quads are padded, so allow a little slack
+		if math.Abs(got[axis][0]-want[axis][0]) > 1e-3 || math.Abs(got[axis][1]-want[axis][1]) > 1e-3 {
+			t.Errorf("unexpected bounds on axis %d, got=%v. want=%v.", axis, got[axis], want[axis])
+		}
+	}
+
+	plane, _ := transform.New(planar.NewPlane(vec3.New(0, 0, 0), vec3.New(0, 1, 0), nil), mat4.Translate(vec3.New(1, 1, 1)))
+	if pb := plane.BoundingBox(); !math.IsInf(pb.X.Max, 1) {
+		t.Errorf("unexpected result, got=%v. want an unbounded box.", pb)
+	}
+}
+
+func TestSingular(t *testing.T) {
+	t.Parallel()
+
+	_, err := transform.New(sphere.New(vec3.New(0, 0, 0), 1, nil), mat4.Scale(vec3.New(0, 1, 1)))
+	if err == nil {
+		t.Error("expected an error for a singular matrix")
+	}
+}
+
+func TestAnimated(t *testing.T) {
+	t.Parallel()
+
+	unit := sphere.New(vec3.New(0, 0, 0), 1, nil)
+	still := vec3.New(1, 1, 1)
+	anim, err := transform.NewAnimated(unit, []transform.Keyframe{
+		// Given out of order on purpose
+		{Time: 1, Translate: vec3.New(10, 0, 0), Rotate: mat4.FromAxisAngle(vec3.New(0, 1, 0), math.Pi), Scale: vec3.New(2, 2, 2)},
+		{Time: 0, Translate: vec3.New(0, 0, 0), Rotate: mat4.IdentityRotation(), Scale: still},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		time float64
+		// Where a ray travelling down -Z along the X axis first hits the sphere's surface
+		x, hitZ float64
+	}{
+		{time: -1, x: 0, hitZ: 1},
+		{time: 0, x: 0, hitZ: 1},
+		{time: 0.5, x: 5, hitZ: 1.5},
+		{time: 1, x: 10, hitZ: 2},
+		{time: 2, x: 10, hitZ: 2},
+	}
+
+	for _, tc := range tests {
+		r := ray.NewWithTime(vec3.New(tc.x, 0, 10), vec3.New(0, 0, -1), tc.time)
+		hr, ok := anim.Hit(r, interval.New(1e-3, math.Inf(1)))
+		if !ok {
+			t.Errorf("expected a hit at time %v", tc.time)
+			continue
+		}
+		if want := vec3.New(tc.x, 0, tc.hitZ); !near(hr.Point(), want) {
+			p := hr.Point()
+			t.Errorf("unexpected point at time %v, got=%q. want=%q.", tc.time, &p, &want)
+		}
+		if !anim.BoundingBox().Hit(r, interval.New(1e-3, math.Inf(1))) {
+			t.Errorf("ray at time %v hit the object but missed its bounding box", tc.time)
+		}
+	}
+
+	// At time 0 the sphere is still at the origin
+	if _, ok := anim.Hit(ray.NewWithTime(vec3.New(10, 0, 10), vec3.New(0, 0, -1), 0), interval.New(1e-3, math.Inf(1))); ok {
+		t.Errorf("unexpected hit at time 0 where the sphere only arrives at time 1")
+	}
+}
+
+func TestAnimatedInvalid(t *testing.T) {
+	t.Parallel()
+
+	unit := sphere.New(vec3.New(0, 0, 0), 1, nil)
+	one := vec3.New(1, 1, 1)
+
+	tests := map[string][]transform.Keyframe{
+		"no keyframes":   nil,
+		"zero scale":     {{Time: 0, Rotate: mat4.IdentityRotation(), Scale: vec3.New(1, 0, 1)}},
+		"duplicate time": {{Time: 1, Rotate: mat4.IdentityRotation(), Scale: one}, {Time: 1, Rotate: mat4.IdentityRotation(), Scale: one}},
+		"scale flips":    {{Time: 0, Rotate: mat4.IdentityRotation(), Scale: one}, {Time: 1, Rotate: mat4.IdentityRotation(), Scale: vec3.New(1, -1, 1)}},
+	}
+	for name, keys := range tests {
+		if _, err := transform.NewAnimated(unit, keys); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
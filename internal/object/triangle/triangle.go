@@ -0,0 +1,105 @@
+package triangle
+
+import (
+	"math"
+
+	"github.com/sendelivery/go-trace-rays/internal/aabb"
+	"github.com/sendelivery/go-trace-rays/internal/interval"
+	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
+	"github.com/sendelivery/go-trace-rays/internal/object/hittable"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// bboxPadding is the minimum thickness of a triangle's bounding box along any axis
+const bboxPadding = 1e-4
+
+// Triangle is a single flat triangle. Its front face is the side from which the vertices a, b, c
+// appear in counter-clockwise order.
+type Triangle struct {
+	a, b, c vec3.Vector3
+	normal  vec3.Vector3
+	mat     hitrecord.Scatterer
+	bbox    aabb.AABB
+}
+
+func New(a, b, c vec3.Vector3, mat hitrecord.Scatterer) Triangle {
+	normal := vec3.UnitVector(vec3.Cross(vec3.Sub(b, a), vec3.Sub(c, a)))
+	bbox := aabb.NewFromBoxes(aabb.NewFromPoints(a, b), aabb.NewFromPoints(a, c))
+	return Triangle{a, b, c, normal, mat, bbox.Pad(bboxPadding)}
+}
+
+func (tri Triangle) Hit(r ray.Ray, rt interval.Interval) (hitrecord.HitRecord, bool) {
+	t, u, v, ok := Intersect(r, tri.a, tri.b, tri.c, rt)
+	if !ok {
+		return hitrecord.HitRecord{}, false
+	}
+
+	hr := hitrecord.New(r, t, tri.normal, tri.mat)
+	hr.SetUV(u, v)
+	return hr, true
+}
+
+func (tri Triangle) BoundingBox() aabb.AABB {
+	return tri.bbox
+}
+
+func (tri Triangle) Material() hitrecord.Scatterer {
+	return tri.mat
+}
+
+// Sample returns the direction from origin to a point picked uniformly over the triangle
+func (tri Triangle) Sample(origin vec3.Vector3, time float64, rng *utility.Rand) vec3.Vector3 {
+	s := math.Sqrt(rng.Float64())
+	v := rng.Float64()
+	p := vec3.Mulf(tri.a, 1-s)
+	p.Add(vec3.Mulf(tri.b, s*(1-v)))
+	p.Add(vec3.Mulf(tri.c, s*v))
+	return vec3.Sub(p, origin)
+}
+
+func (tri Triangle) PDF(r ray.Ray, rt interval.Interval) float64 {
+	hr, ok := tri.Hit(r, rt)
+	if !ok {
+		return 0
+	}
+	area := vec3.Cross(vec3.Sub(tri.b, tri.a), vec3.Sub(tri.c, tri.a)).Length() / 2
+	return hittable.AreaPDF(r, hr, area)
+}
+
+// Intersect finds where the ray r crosses the triangle a, b, c using the Möller-Trumbore
+// algorithm. On a hit within rt it returns the ray parameter t along with the barycentric
+// coordinates u and v of the hit point, weighting b and c respectively.
+func Intersect(r ray.Ray, a, b, c vec3.Vector3, rt interval.Interval) (t, u, v float64, ok bool) {
+	edge1 := vec3.Sub(b, a)
+	edge2 := vec3.Sub(c, a)
+
+	pvec := vec3.Cross(r.Direction(), edge2)
+	det := vec3.Dot(edge1, pvec)
+
+	// The ray is parallel to the triangle's plane
+	if math.Abs(det) < 1e-12 {
+		return 0, 0, 0, false
+	}
+	invDet := 1 / det
+
+	tvec := vec3.Sub(r.Origin(), a)
+	u = vec3.Dot(tvec, pvec) * invDet
+	if u < 0 || u > 1 {
+		return 0, 0, 0, false
+	}
+
+	qvec := vec3.Cross(tvec, edge1)
+	v = vec3.Dot(r.Direction(), qvec) * invDet
+	if v < 0 || u+v > 1 {
+		return 0, 0, 0, false
+	}
+
+	t = vec3.Dot(edge2, qvec) * invDet
+	if !rt.Surrounds(t) {
+		return 0, 0, 0, false
+	}
+
+	return t, u, v, true
+}
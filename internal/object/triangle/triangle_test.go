@@ -0,0 +1,123 @@
+package triangle_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sendelivery/go-trace-rays/internal/interval"
+	"github.com/sendelivery/go-trace-rays/internal/object/triangle"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestHit(t *testing.T) {
+	t.Parallel()
+
+	// A right angled triangle in the z=0 plane facing +z, with its right angle at the origin.
+	// u weights the corner on the X axis and v the corner on the Y axis.
+	tri := triangle.New(vec3.New(0, 0, 0), vec3.New(2, 0, 0), vec3.New(0, 2, 0), nil)
+
+	tests := []struct {
+		name      string
+		origin    vec3.Vector3
+		direction vec3.Vector3
+		hit       bool
+		t         float64
+		frontFace bool
+		u, v      float64
+	}{
+		{name: "front", origin: vec3.New(0.5, 0.5, 1), direction: vec3.New(0, 0, -1), hit: true, t: 1, frontFace: true, u: 0.25, v: 0.25},
+		{name: "back", origin: vec3.New(1, 0.5, -2), direction: vec3.New(0, 0, 1), hit: true, t: 2, frontFace: false, u: 0.5, v: 0.25},
+		{name: "slanted", origin: vec3.New(-1, 1, 2), direction: vec3.New(1, 0, -1), hit: true, t: 2, frontFace: true, u: 0.5, v: 0.5},
+		{name: "edge", origin: vec3.New(1, 0, 1), direction: vec3.New(0, 0, -1), hit: true, t: 1, frontFace: true, u: 0.5, v: 0},
+		{name: "hypotenuse", origin: vec3.New(1, 1, 1), direction: vec3.New(0, 0, -1), hit: true, t: 1, frontFace: true, u: 0.5, v: 0.5},
+		{name: "right angle", origin: vec3.New(0, 0, 1), direction: vec3.New(0, 0, -1), hit: true, t: 1, frontFace: true, u: 0, v: 0},
+		{name: "corner", origin: vec3.New(0, 2, 1), direction: vec3.New(0, 0, -1), hit: true, t: 1, frontFace: true, u: 0, v: 1},
+		{name: "outside edge", origin: vec3.New(1, -0.01, 1), direction: vec3.New(0, 0, -1)},
+		{name: "outside hypotenuse", origin: vec3.New(1.01, 1, 1), direction: vec3.New(0, 0, -1)},
+		{name: "past corner", origin: vec3.New(2.01, 0, 1), direction: vec3.New(0, 0, -1)},
+		{name: "parallel", origin: vec3.New(-1, 0.5, 0), direction: vec3.New(1, 0, 0)},
+		{name: "behind", origin: vec3.New(0.5, 0.5, 1), direction: vec3.New(0, 0, 1)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := ray.New(tc.origin, tc.direction)
+			hr, ok := tri.Hit(r, interval.New(1e-3, math.Inf(1)))
+			if ok != tc.hit {
+				t.Fatalf("unexpected result, got=%v. want=%v.", ok, tc.hit)
+			}
+			if !ok {
+				return
+			}
+
+			if !near(hr.T(), tc.t) {
+				t.Errorf("unexpected t, got=%v. want=%v.", hr.T(), tc.t)
+			}
+			if hr.FrontFace() != tc.frontFace {
+				t.Errorf("unexpected front face, got=%v. want=%v.", hr.FrontFace(), tc.frontFace)
+			}
+			if n := hr.Normal(); !near(math.Abs(n.Z()), 1) || vec3.Dot(n, r.Direction()) >= 0 {
+				t.Errorf("normal %q does not face the ray along the Z axis", &n)
+			}
+			if !near(hr.U(), tc.u) || !near(hr.V(), tc.v) {
+				t.Errorf("unexpected uv, got=%v, %v. want=%v, %v.", hr.U(), hr.V(), tc.u, tc.v)
+			}
+			if !tri.BoundingBox().Hit(r, interval.New(1e-3, math.Inf(1))) {
+				t.Errorf("ray hit the triangle but missed its bounding box")
+			}
+		})
+	}
+}
+
+func TestBoundingBox(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		a, b, c vec3.Vector3
+		flat    int     // The axis the triangle has no extent along
+		at      float64 // Where its plane crosses that axis
+	}{
+		{name: "x", a: vec3.New(1, 0, 0), b: vec3.New(1, 1, 0), c: vec3.New(1, 0, 1), flat: 0, at: 1},
+		{name: "y", a: vec3.New(0, -2, 0), b: vec3.New(0, -2, 1), c: vec3.New(1, -2, 0), flat: 1, at: -2},
+		{name: "z", a: vec3.New(0, 0, 3), b: vec3.New(1, 0, 3), c: vec3.New(0, 1, 3), flat: 2, at: 3},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			tri := triangle.New(tc.a, tc.b, tc.c, nil)
+			bbox := tri.BoundingBox()
+
+			flat := bbox.AxisInterval(tc.flat)
+			// Padded to 1e-4, give or take rounding
+			if flat.Size() < 0.99e-4 {
+				t.Errorf("unexpected thickness, got=%v. want=%v.", flat.Size(), 1e-4)
+			}
+			if !flat.Surrounds(tc.at) {
+				t.Errorf("box %v to %v does not surround the triangle's plane at %v", flat.Min, flat.Max, tc.at)
+			}
+
+			// A ray striking the triangle head on must still hit its box
+			centre := vec3.Mulf(vec3.Add(tc.a, vec3.Add(tc.b, tc.c)), 1.0/3)
+			var dir [3]float64
+			dir[tc.flat] = -1
+			direction := vec3.New(dir[0], dir[1], dir[2])
+			r := ray.New(vec3.Sub(centre, direction), direction)
+			if !bbox.Hit(r, interval.New(1e-3, math.Inf(1))) {
+				t.Errorf("ray towards the centre missed the bounding box")
+			}
+			if _, ok := tri.Hit(r, interval.New(1e-3, math.Inf(1))); !ok {
+				t.Errorf("ray towards the centre missed the triangle")
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	barWidth       = 30
+	barMinInterval = 100 * time.Millisecond // Minimum time between redraws
+)
+
+// Bar draws a single line progress bar to an interactive terminal, redrawing it in place
+// with carriage returns.
+type Bar struct {
+	w        io.Writer
+	lastDraw time.Time
+}
+
+func NewBar(w io.Writer) *Bar {
+	return &Bar{w: w}
+}
+
+func (b *Bar) RenderStarted(e Start) {
+	if e.Tiles > 0 {
+		fmt.Fprintf(b.w, "Rendering %dx%d at %d spp, %d tiles across %d workers\n",
+			e.Width, e.Height, e.SamplesPerPixel, e.Tiles, e.Workers)
+	} else {
+		fmt.Fprintf(b.w, "Rendering %dx%d at %d spp\n", e.Width, e.Height, e.SamplesPerPixel)
+	}
+}
+
+func (b *Bar) TileDone(e TileDone) {}
+
+func (b *Bar) SamplesCompleted(e SamplesCompleted) {
+	if time.Since(b.lastDraw) < barMinInterval {
+		return
+	}
+	b.lastDraw = time.Now()
+
+	filled := int(e.Fraction() * barWidth)
+	fmt.Fprintf(b.w, "\r[%s%s] %5.1f%% %s rays/s ETA %s ",
+		strings.Repeat("=", filled),
+		strings.Repeat(" ", barWidth-filled),
+		100*e.Fraction(),
+		humanize(e.RaysPerSecond),
+		e.ETA.Round(time.Second),
+	)
+}
+
+func (b *Bar) RenderFinished(e Finish) {
+	status := "Done"
+	if e.Err != nil {
+		status = fmt.Sprintf("Stopped (%v)", e.Err)
+	}
+	fmt.Fprintf(b.w, "\r%s in %.2fs, %s rays at %s rays/s.%s\n",
+		status,
+		e.Elapsed.Seconds(),
+		humanize(float64(e.Rays)),
+		humanize(e.RaysPerSecond),
+		strings.Repeat(" ", barWidth),
+	)
+}
+
+// humanize formats n with a metric suffix, e.g. 1.5M
+func humanize(n float64) string {
+	switch {
+	case n >= 1e9:
+		return fmt.Sprintf("%.1fG", n/1e9)
+	case n >= 1e6:
+		return fmt.Sprintf("%.1fM", n/1e6)
+	case n >= 1e3:
+		return fmt.Sprintf("%.1fk", n/1e3)
+	}
+	return fmt.Sprintf("%.0f", n)
+}
@@ -0,0 +1,91 @@
+package progress
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// JSONLines writes every event as a single line JSON object, for consumption by log
+// collectors and other tools. Each object has an "event" field naming its type, one of
+// "start", "tile", "samples" or "finish". Durations are given in seconds.
+type JSONLines struct {
+	enc *json.Encoder
+}
+
+func NewJSONLines(w io.Writer) *JSONLines {
+	return &JSONLines{enc: json.NewEncoder(w)}
+}
+
+type jsonStats struct {
+	Elapsed       float64 `json:"elapsed"`
+	SamplesDone   uint64  `json:"samplesDone"`
+	SamplesTotal  uint64  `json:"samplesTotal"`
+	Fraction      float64 `json:"fraction"`
+	Rays          uint64  `json:"rays"`
+	RaysPerSecond float64 `json:"raysPerSecond"`
+	ETA           float64 `json:"eta"`
+}
+
+func newJSONStats(s Stats) jsonStats {
+	return jsonStats{
+		Elapsed:       s.Elapsed.Seconds(),
+		SamplesDone:   s.SamplesDone,
+		SamplesTotal:  s.SamplesTotal,
+		Fraction:      s.Fraction(),
+		Rays:          s.Rays,
+		RaysPerSecond: s.RaysPerSecond,
+		ETA:           s.ETA.Seconds(),
+	}
+}
+
+func (j *JSONLines) RenderStarted(e Start) {
+	j.enc.Encode(struct {
+		Event           string `json:"event"`
+		Time            string `json:"time"`
+		Width           int    `json:"width"`
+		Height          int    `json:"height"`
+		SamplesPerPixel int    `json:"samplesPerPixel"`
+		Tiles           int    `json:"tiles"`
+		Workers         int    `json:"workers"`
+	}{"start", e.Time.Format(time.RFC3339Nano), e.Width, e.Height, e.SamplesPerPixel, e.Tiles, e.Workers})
+}
+
+func (j *JSONLines) TileDone(e TileDone) {
+	j.enc.Encode(struct {
+		Event      string `json:"event"`
+		X0         int    `json:"x0"`
+		Y0         int    `json:"y0"`
+		X1         int    `json:"x1"`
+		Y1         int    `json:"y1"`
+		Worker     int    `json:"worker"`
+		TilesDone  int    `json:"tilesDone"`
+		TilesTotal int    `json:"tilesTotal"`
+		jsonStats
+	}{
+		"tile",
+		e.Tile.Start().X(), e.Tile.Start().Y(), e.Tile.End().X(), e.Tile.End().Y(),
+		e.Worker, e.TilesDone, e.TilesTotal,
+		newJSONStats(e.Stats),
+	})
+}
+
+func (j *JSONLines) SamplesCompleted(e SamplesCompleted) {
+	j.enc.Encode(struct {
+		Event   string `json:"event"`
+		Samples uint64 `json:"samples"`
+		jsonStats
+	}{"samples", e.Samples, newJSONStats(e.Stats)})
+}
+
+func (j *JSONLines) RenderFinished(e Finish) {
+	var errMsg string
+	if e.Err != nil {
+		errMsg = e.Err.Error()
+	}
+	j.enc.Encode(struct {
+		Event string `json:"event"`
+		Error string `json:"error,omitempty"`
+		jsonStats
+	}{"finish", errMsg, newJSONStats(e.Stats)})
+}
@@ -0,0 +1,168 @@
+package progress
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sendelivery/go-trace-rays/internal/image"
+)
+
+// Observer receives events as a render progresses. Calls are never made concurrently, so
+// implementations need no locking of their own, but they should return quickly as rendering
+// workers wait on them.
+type Observer interface {
+	RenderStarted(e Start)
+	TileDone(e TileDone)
+	SamplesCompleted(e SamplesCompleted)
+	RenderFinished(e Finish)
+}
+
+// Start is sent once before any pixels are rendered
+type Start struct {
+	Time            time.Time
+	Width, Height   int
+	SamplesPerPixel int
+	Tiles           int // Number of tiles, 0 when rendering scanline by scanline
+	Workers         int
+}
+
+// Stats is a snapshot of how far a render has got
+type Stats struct {
+	Elapsed       time.Duration
+	SamplesDone   uint64 // Camera samples taken so far, one per pixel per sample
+	SamplesTotal  uint64
+	Rays          uint64 // Rays traced so far, including every bounce
+	RaysPerSecond float64
+	ETA           time.Duration // Estimated time remaining, extrapolated from the rate so far
+}
+
+// Fraction returns the completed fraction of the render, from 0 to 1
+func (s Stats) Fraction() float64 {
+	if s.SamplesTotal == 0 {
+		return 0
+	}
+	return float64(s.SamplesDone) / float64(s.SamplesTotal)
+}
+
+// TileDone is sent by the parallel workflow each time a tile is completed
+type TileDone struct {
+	Stats
+	Tile       image.Chunk
+	Worker     int
+	TilesDone  int
+	TilesTotal int
+}
+
+// SamplesCompleted is sent each time a batch of work, a scanline or a tile, is completed
+type SamplesCompleted struct {
+	Stats
+	Samples uint64 // Samples taken in this batch
+}
+
+// Finish is sent once the render is over. Err is set if it stopped early.
+type Finish struct {
+	Stats
+	Err error
+}
+
+// Tracker keeps the running totals of a render and forwards events to an Observer. It is
+// safe for concurrent use, and a nil Observer makes every method a no-op.
+type Tracker struct {
+	obs   Observer
+	mu    sync.Mutex // Serialises calls to obs
+	start time.Time
+
+	samplesTotal uint64
+	samplesDone  atomic.Uint64
+	rays         atomic.Uint64
+	tilesTotal   int
+	tilesDone    atomic.Int64
+}
+
+// NewTracker returns a Tracker for a render of samplesTotal samples split into tilesTotal
+// tiles, reporting to obs.
+func NewTracker(obs Observer, samplesTotal uint64, tilesTotal int) *Tracker {
+	return &Tracker{obs: obs, samplesTotal: samplesTotal, tilesTotal: tilesTotal}
+}
+
+// Start records the start time and sends the Start event.
+func (t *Tracker) Start(e Start) {
+	t.start = time.Now()
+	if t.obs == nil {
+		return
+	}
+	e.Time = t.start
+	e.Tiles = t.tilesTotal
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.obs.RenderStarted(e)
+}
+
+// Done records a completed batch of samples and rays, sending SamplesCompleted.
+func (t *Tracker) Done(samples, rays uint64) {
+	t.samplesDone.Add(samples)
+	t.rays.Add(rays)
+	if t.obs == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.obs.SamplesCompleted(SamplesCompleted{Stats: t.stats(), Samples: samples})
+}
+
+// TileDone records a completed tile of samples and rays, sending TileDone followed by
+// SamplesCompleted.
+func (t *Tracker) TileDone(tile image.Chunk, worker int, samples, rays uint64) {
+	t.samplesDone.Add(samples)
+	t.rays.Add(rays)
+	tilesDone := int(t.tilesDone.Add(1))
+	if t.obs == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stats := t.stats()
+	t.obs.TileDone(TileDone{
+		Stats:      stats,
+		Tile:       tile,
+		Worker:     worker,
+		TilesDone:  tilesDone,
+		TilesTotal: t.tilesTotal,
+	})
+	t.obs.SamplesCompleted(SamplesCompleted{Stats: stats, Samples: samples})
+}
+
+// Finish sends the Finish event and returns the final statistics.
+func (t *Tracker) Finish(err error) Stats {
+	stats := t.stats()
+	if t.obs == nil {
+		return stats
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.obs.RenderFinished(Finish{Stats: stats, Err: err})
+	return stats
+}
+
+func (t *Tracker) stats() Stats {
+	s := Stats{
+		Elapsed:      time.Since(t.start),
+		SamplesDone:  t.samplesDone.Load(),
+		SamplesTotal: t.samplesTotal,
+		Rays:         t.rays.Load(),
+	}
+
+	if secs := s.Elapsed.Seconds(); secs > 0 {
+		s.RaysPerSecond = float64(s.Rays) / secs
+	}
+	if s.SamplesDone > 0 && s.SamplesDone < s.SamplesTotal {
+		remaining := float64(s.SamplesTotal-s.SamplesDone) / float64(s.SamplesDone)
+		s.ETA = time.Duration(float64(s.Elapsed) * remaining)
+	}
+	return s
+}
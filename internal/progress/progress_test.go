@@ -0,0 +1,136 @@
+package progress_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sendelivery/go-trace-rays/internal/image"
+	"github.com/sendelivery/go-trace-rays/internal/progress"
+)
+
+// recorder records the events it observes
+type recorder struct {
+	events []string
+	last   progress.Finish
+}
+
+func (r *recorder) RenderStarted(e progress.Start) { r.events = append(r.events, "start") }
+func (r *recorder) TileDone(e progress.TileDone)   { r.events = append(r.events, "tile") }
+func (r *recorder) SamplesCompleted(e progress.SamplesCompleted) {
+	r.events = append(r.events, "samples")
+}
+func (r *recorder) RenderFinished(e progress.Finish) {
+	r.events = append(r.events, "finish")
+	r.last = e
+}
+
+func TestTracker(t *testing.T) {
+	t.Parallel()
+
+	rec := &recorder{}
+	tr := progress.NewTracker(rec, 40, 4)
+	tr.Start(progress.Start{Width: 4, Height: 5, SamplesPerPixel: 2, Workers: 2})
+
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tile := image.NewChunk(image.NewPixelCoord(0, i), image.NewPixelCoord(4, i+1))
+			tr.TileDone(tile, i%2, 8, 100)
+		}()
+	}
+	wg.Wait()
+	tr.Done(8, 50)
+
+	stats := tr.Finish(nil)
+
+	want := []string{"start"}
+	for range 4 {
+		want = append(want, "tile", "samples")
+	}
+	want = append(want, "samples", "finish")
+	if diff := cmp.Diff(want, rec.events); diff != "" {
+		t.Errorf("unexpected events (-want +got):\n%s", diff)
+	}
+
+	if stats.SamplesDone != 40 || stats.Rays != 450 {
+		t.Errorf("unexpected result, got=%d samples, %d rays. want=40 samples, 450 rays.", stats.SamplesDone, stats.Rays)
+	}
+	if stats.Fraction() != 1 {
+		t.Errorf("unexpected result, got=%v. want=%v.", stats.Fraction(), 1)
+	}
+	if stats.ETA != 0 {
+		t.Errorf("unexpected result, got=%v. want=%v.", stats.ETA, 0)
+	}
+	if rec.last.Stats != stats {
+		t.Errorf("unexpected result, got=%v. want=%v.", rec.last.Stats, stats)
+	}
+}
+
+func TestTrackerNilObserver(t *testing.T) {
+	t.Parallel()
+
+	tr := progress.NewTracker(nil, 10, 0)
+	tr.Start(progress.Start{})
+	tr.Done(5, 7)
+
+	stats := tr.Finish(nil)
+	if stats.SamplesDone != 5 || stats.Rays != 7 {
+		t.Errorf("unexpected result, got=%d samples, %d rays. want=5 samples, 7 rays.", stats.SamplesDone, stats.Rays)
+	}
+}
+
+func TestJSONLines(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	tr := progress.NewTracker(progress.NewJSONLines(&buf), 16, 1)
+	tr.Start(progress.Start{Width: 4, Height: 4, SamplesPerPixel: 1, Workers: 1})
+	tr.TileDone(image.NewChunk(image.NewPixelCoord(0, 0), image.NewPixelCoord(4, 4)), 0, 16, 32)
+	tr.Finish(errors.New("stopped"))
+
+	var events []string
+	sc := bufio.NewScanner(&buf)
+	for sc.Scan() {
+		var obj map[string]any
+		if err := json.Unmarshal(sc.Bytes(), &obj); err != nil {
+			t.Fatalf("invalid JSON line %q: %v", sc.Text(), err)
+		}
+		events = append(events, obj["event"].(string))
+
+		if obj["event"] == "finish" {
+			if obj["error"] != "stopped" || obj["rays"] != 32.0 {
+				t.Errorf("unexpected finish event, got=%v.", obj)
+			}
+		}
+	}
+
+	want := []string{"start", "tile", "samples", "finish"}
+	if diff := cmp.Diff(want, events); diff != "" {
+		t.Errorf("unexpected events (-want +got):\n%s", diff)
+	}
+}
+
+func TestBar(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	tr := progress.NewTracker(progress.NewBar(&buf), 10, 0)
+	tr.Start(progress.Start{Width: 10, Height: 1, SamplesPerPixel: 1})
+	tr.Done(5, 5)
+	tr.Finish(nil)
+
+	out := buf.String()
+	for _, want := range []string{"Rendering 10x1 at 1 spp", "50.0%", "Done in"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("unexpected result, got=%q. want it to contain %q.", out, want)
+		}
+	}
+}
@@ -5,10 +5,16 @@ import "github.com/sendelivery/go-trace-rays/internal/vec3"
 type Ray struct {
 	origin    vec3.Vector3
 	direction vec3.Vector3
+	time      float64 // Moment during the exposure the ray was cast
 }
 
 func New(origin, direction vec3.Vector3) Ray {
-	return Ray{origin, direction}
+	return Ray{origin: origin, direction: direction}
+}
+
+// NewWithTime returns a ray cast at the given moment, for scenes with moving objects
+func NewWithTime(origin, direction vec3.Vector3, time float64) Ray {
+	return Ray{origin, direction, time}
 }
 
 func (r *Ray) At(t float64) vec3.Vector3 {
@@ -22,3 +28,7 @@ func (r *Ray) Origin() vec3.Vector3 {
 func (r *Ray) Direction() vec3.Vector3 {
 	return r.direction
 }
+
+func (r *Ray) Time() float64 {
+	return r.time
+}
@@ -0,0 +1,989 @@
+package scenefile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/sendelivery/go-trace-rays/internal/background"
+	"github.com/sendelivery/go-trace-rays/internal/camera"
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/gltf"
+	"github.com/sendelivery/go-trace-rays/internal/mat4"
+	"github.com/sendelivery/go-trace-rays/internal/obj"
+	"github.com/sendelivery/go-trace-rays/internal/object/bvh"
+	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
+	"github.com/sendelivery/go-trace-rays/internal/object/hittable"
+	"github.com/sendelivery/go-trace-rays/internal/object/material"
+	"github.com/sendelivery/go-trace-rays/internal/object/medium"
+	"github.com/sendelivery/go-trace-rays/internal/object/planar"
+	"github.com/sendelivery/go-trace-rays/internal/object/sphere"
+	"github.com/sendelivery/go-trace-rays/internal/object/transform"
+	"github.com/sendelivery/go-trace-rays/internal/object/triangle"
+	"github.com/sendelivery/go-trace-rays/internal/scheduler"
+	"github.com/sendelivery/go-trace-rays/internal/texture"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// File is the JSON scene description. Every field is optional apart from the objects, camera
+// fields left out keep the defaults from camera.New.
+type File struct {
+	Camera    Camera              `json:"camera"`
+	Render    Render              `json:"render"`
+	Textures  map[string]Texture  `json:"textures"`
+	Materials map[string]Material `json:"materials"`
+	Objects   []Object            `json:"objects"`
+}
+
+// Camera mirrors the public fields of camera.Camera
+type Camera struct {
+	AspectRatio     *float64 `json:"aspectRatio"`
+	ImageWidth      *int     `json:"imageWidth"`
+	SamplesPerPixel *int     `json:"samplesPerPixel"`
+	MaxDepth        *int     `json:"maxDepth"`
+
+	VerticalFov *float64 `json:"verticalFov"`
+	LookFrom    Vec      `json:"lookFrom"`
+	LookAt      Vec      `json:"lookAt"`
+	VUp         Vec      `json:"vUp"`
+
+	DefocusAngle  *float64 `json:"defocusAngle"`
+	FocusDistance *float64 `json:"focusDistance"`
+
+	ShutterOpen  *float64 `json:"shutterOpen"`
+	ShutterClose *float64 `json:"shutterClose"`
+
+	Background *Background `json:"background"`
+}
+
+// Background describes what rays that miss every object see. Type is one of:
+//   - "sky", the default gradient
+//   - "constant", which uses Color in every direction
+//   - "environment", which wraps the .hdr or .pfm image at Path around the scene, turned
+//     Rotation degrees about the Y axis
+//   - "sunSky", a daylight sky with the sun Elevation degrees above the horizon, turned Azimuth
+//     degrees anticlockwise from +X about the Y axis, through air of the given Turbidity,
+//     which defaults to 3
+//
+// Environments and sun skies are scaled by Intensity, which defaults to 1.
+type Background struct {
+	Type  string `json:"type"`
+	Color Vec    `json:"color"`
+
+	Path      string   `json:"path"`
+	Rotation  float64  `json:"rotation"`
+	Intensity *float64 `json:"intensity"`
+
+	Elevation *float64 `json:"elevation"`
+	Azimuth   float64  `json:"azimuth"`
+	Turbidity *float64 `json:"turbidity"`
+}
+
+// Render holds settings for how the scene is rendered rather than what is in it
+type Render struct {
+	Parallel bool    `json:"parallel"`
+	Exposure float64 `json:"exposure"`
+	ToneMap  string  `json:"toneMap"`
+	Seed     uint64  `json:"seed"`
+
+	TileSize  *int   `json:"tileSize"`
+	TileOrder string `json:"tileOrder"`
+
+	SampleLights *bool `json:"sampleLights"` // Aim shadow rays at lights, on unless set false
+}
+
+// Texture describes one named texture. Type is one of "solid", "checker", "noise" or "image".
+// Image paths are resolved like model paths.
+type Texture struct {
+	Type  string   `json:"type"`
+	Color Vec      `json:"color"`
+	Scale *float64 `json:"scale"`
+	Even  Vec      `json:"even"`
+	Odd   Vec      `json:"odd"`
+	Path  string   `json:"path"`
+}
+
+// Material describes one named material. Type is one of "lambertian", "metal", "dielectric",
+// "diffuseLight", "conductor", "roughDielectric" or "principled", or "isotropic" or
+// "henyeyGreenstein" for the inside of a medium. Texture names an entry of File.Textures and may
+// be given instead of Albedo or Emit.
+//
+// A conductor's refractive index is either a named Metal, one of material.ComplexIORNames, or
+// given by Eta and K.
+//
+// A principled material's base colour is Albedo or Texture, and its refractive index
+// RefractionIndex. Its other parameters, Roughness, Metallic and so on, are numbers from 0 to 1,
+// or are read from the red channel of the texture Maps names for them, keyed by their JSON
+// field names. Anything left out takes the defaults of material.PrincipledParams.
+type Material struct {
+	Type            string   `json:"type"`
+	Albedo          Vec      `json:"albedo"`
+	Emit            Vec      `json:"emit"`
+	Texture         string   `json:"texture"`
+	Fuzz            *float64 `json:"fuzz"`
+	RefractionIndex *float64 `json:"refractionIndex"`
+	Anisotropy      *float64 `json:"anisotropy"` // Henyey-Greenstein g, from -1 (back) to 1 (forward)
+
+	Roughness *float64 `json:"roughness"` // From 0 for smooth to 1
+	Metal     string   `json:"metal"`
+	Eta       Vec      `json:"eta"`
+	K         Vec      `json:"k"`
+
+	Metallic           *float64          `json:"metallic"`
+	Specular           *float64          `json:"specular"`
+	Clearcoat          *float64          `json:"clearcoat"`
+	ClearcoatRoughness *float64          `json:"clearcoatRoughness"`
+	Sheen              *float64          `json:"sheen"`
+	SheenTint          *float64          `json:"sheenTint"`
+	Transmission       *float64          `json:"transmission"`
+	Maps               map[string]string `json:"maps"`
+}
+
+// Object describes one object in the world. Type is one of "sphere", "triangle", "quad",
+// "box", "disk", "plane", "obj" or "gltf". Material names an entry of File.Materials, for obj
+// models it is only used for faces that don't select a material of their own. glTF models bring
+// their own materials and take none; their cameras are not used.
+type Object struct {
+	Type     string   `json:"type"`
+	Material string   `json:"material"`
+	Centre   Vec      `json:"centre"`
+	Radius   *float64 `json:"radius"`
+	Vertices []Vec    `json:"vertices"`
+	Path     string   `json:"path"`
+
+	Corner Vec `json:"corner"` // Quad corner, with edges U and V
+	U      Vec `json:"u"`
+	V      Vec `json:"v"`
+	Min    Vec `json:"min"` // Box corners
+	Max    Vec `json:"max"`
+	Point  Vec `json:"point"` // A point on a plane
+	Normal Vec `json:"normal"`
+
+	Centre1 Vec `json:"centre1"` // Where a moving sphere's centre is at time 1
+
+	// Density turns a closed, convex object into the boundary of a constant-density medium,
+	// such as fog or smoke, filled with its material. Rays only see the medium between where
+	// they first cross the boundary and where they next cross it, which is wrong for concave
+	// shapes.
+	Density *float64 `json:"density"`
+
+	Transform *Transform `json:"transform"`
+	Keyframes []Keyframe `json:"keyframes"` // Animates the object instead of Transform
+}
+
+// Transform places an object in the world. The object is scaled, then rotated about the X, Y
+// and Z axes in turn by the Rotate angles in degrees, then translated.
+type Transform struct {
+	Translate Vec `json:"translate"`
+	Rotate    Vec `json:"rotate"`
+	Scale     Vec `json:"scale"`
+}
+
+// Keyframe is an object's pose at one moment of an animation. Poses are interpolated between
+// keyframes, the object holds still before the first and after the last.
+type Keyframe struct {
+	Time float64 `json:"time"`
+	Transform
+}
+
+// Vec is a three component vector or colour, written as a JSON array
+type Vec []float64
+
+func (v Vec) vector() vec3.Vector3 {
+	return vec3.New(v[0], v[1], v[2])
+}
+
+// Scene is a scene file that has been built and is ready to render.
+type Scene struct {
+	World    hittable.Hittabler
+	Camera   *camera.Camera
+	Parallel bool
+	Display  color.Display
+	Warnings []string // Parts of the models loaded that had to be left out
+}
+
+// Load reads, validates and builds the scene file at path. Relative model paths are resolved
+// against the directory containing the scene file.
+func Load(path string) (*Scene, error) {
+	file, err := ParseFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := file.Validate(); err != nil {
+		return nil, err
+	}
+	return file.Build(filepath.Dir(path))
+}
+
+// ParseFile decodes the scene file at path without validating it, so that settings from
+// elsewhere can be laid over it before it is built
+func ParseFile(path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Parse(f)
+}
+
+// Decode reads, validates and builds a scene file from r. Relative model paths are resolved
+// against dir.
+func Decode(r io.Reader, dir string) (*Scene, error) {
+	file, err := Parse(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := file.Validate(); err != nil {
+		return nil, err
+	}
+	return file.Build(dir)
+}
+
+// Parse decodes a scene file without validating it. Unknown fields are rejected so typos don't
+// go unnoticed.
+func Parse(r io.Reader) (*File, error) {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+
+	var f File
+	if err := dec.Decode(&f); err != nil {
+		return nil, fmt.Errorf("decoding scene file: %w", err)
+	}
+	return &f, nil
+}
+
+// FieldError is a single problem found in a scene file, Path locates the offending value
+// using JSONPath syntax, e.g. $.objects[2].radius
+type FieldError struct {
+	Path string
+	Msg  string
+}
+
+func (e FieldError) Error() string {
+	return e.Path + ": " + e.Msg
+}
+
+// ValidationError holds every problem found while validating a scene file.
+type ValidationError []FieldError
+
+func (ve ValidationError) Error() string {
+	msgs := make([]string, len(ve))
+	for i, e := range ve {
+		msgs[i] = e.Error()
+	}
+	return "invalid scene file:\n\t" + strings.Join(msgs, "\n\t")
+}
+
+// validator accumulates FieldErrors
+type validator struct {
+	errs ValidationError
+}
+
+func (v *validator) errorf(path, format string, a ...any) {
+	v.errs = append(v.errs, FieldError{Path: path, Msg: fmt.Sprintf(format, a...)})
+}
+
+func (v *validator) vec(path string, vec Vec, required bool) {
+	if vec == nil {
+		if required {
+			v.errorf(path, "is required")
+		}
+		return
+	}
+	if len(vec) != 3 {
+		v.errorf(path, "must have 3 components, got %d", len(vec))
+	}
+}
+
+// normal checks a required direction vector, which must not be zero
+func (v *validator) normal(path string, vec Vec) {
+	v.vec(path, vec, true)
+	if len(vec) == 3 && vec3.IsNearZero(vec.vector()) {
+		v.errorf(path, "must not be zero")
+	}
+}
+
+func (v *validator) positive(path string, f *float64) {
+	if f != nil && *f <= 0 {
+		v.errorf(path, "must be greater than 0, got %g", *f)
+	}
+}
+
+func (v *validator) roughness(path string, f *float64) {
+	if f != nil && (*f < 0 || *f > 1) {
+		v.errorf(path, "must be between 0 and 1, got %g", *f)
+	}
+}
+
+func (v *validator) atLeast(path string, i *int, n int) {
+	if i != nil && *i < n {
+		v.errorf(path, "must be at least %d, got %d", n, *i)
+	}
+}
+
+// Validate checks the whole file and returns a ValidationError listing every problem found,
+// or nil if there are none.
+func (f *File) Validate() error {
+	var v validator
+
+	c := f.Camera
+	v.positive("$.camera.aspectRatio", c.AspectRatio)
+	v.atLeast("$.camera.imageWidth", c.ImageWidth, 1)
+	v.atLeast("$.camera.samplesPerPixel", c.SamplesPerPixel, 1)
+	v.atLeast("$.camera.maxDepth", c.MaxDepth, 1)
+	if c.VerticalFov != nil && (*c.VerticalFov <= 0 || *c.VerticalFov >= 180) {
+		v.errorf("$.camera.verticalFov", "must be between 0 and 180 exclusive, got %g", *c.VerticalFov)
+	}
+	v.vec("$.camera.lookFrom", c.LookFrom, false)
+	v.vec("$.camera.lookAt", c.LookAt, false)
+	if len(c.LookFrom) == 3 && len(c.LookAt) == 3 && vec3.IsNearZero(vec3.Sub(c.LookAt.vector(), c.LookFrom.vector())) {
+		v.errorf("$.camera.lookAt", "must differ from lookFrom, the camera would have no direction to look in")
+	}
+	v.vec("$.camera.vUp", c.VUp, false)
+	if c.DefocusAngle != nil && *c.DefocusAngle < 0 {
+		v.errorf("$.camera.defocusAngle", "must not be negative, got %g", *c.DefocusAngle)
+	}
+	v.positive("$.camera.focusDistance", c.FocusDistance)
+	if c.ShutterOpen != nil && c.ShutterClose != nil && *c.ShutterClose < *c.ShutterOpen {
+		v.errorf("$.camera.shutterClose", "must not be before shutterOpen, got %g", *c.ShutterClose)
+	}
+	if bg := c.Background; bg != nil {
+		switch bg.Type {
+		case "sky":
+		case "constant":
+			v.vec("$.camera.background.color", bg.Color, true)
+		case "environment":
+			if bg.Path == "" {
+				v.errorf("$.camera.background.path", "is required")
+			}
+			v.positive("$.camera.background.intensity", bg.Intensity)
+		case "sunSky":
+			if bg.Elevation == nil {
+				v.errorf("$.camera.background.elevation", "is required")
+			} else if *bg.Elevation < 0 || *bg.Elevation > 90 {
+				v.errorf("$.camera.background.elevation", "must be between 0 and 90, got %g", *bg.Elevation)
+			}
+			if bg.Turbidity != nil && (*bg.Turbidity < 1.7 || *bg.Turbidity > 10) {
+				v.errorf("$.camera.background.turbidity", "must be between 1.7 and 10, got %g", *bg.Turbidity)
+			}
+			v.positive("$.camera.background.intensity", bg.Intensity)
+		case "":
+			v.errorf("$.camera.background.type", "is required")
+		default:
+			v.errorf("$.camera.background.type", "unknown background type %q", bg.Type)
+		}
+	}
+
+	v.atLeast("$.render.tileSize", f.Render.TileSize, 1)
+	if f.Render.TileOrder != "" {
+		if _, err := scheduler.ParseOrder(f.Render.TileOrder); err != nil {
+			v.errorf("$.render.tileOrder", "%v", err)
+		}
+	}
+
+	if f.Render.ToneMap != "" {
+		if _, err := color.ToneMapperByName(f.Render.ToneMap); err != nil {
+			v.errorf("$.render.toneMap", "%v", err)
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(f.Textures)) {
+		f.Textures[name].validate(&v, fmt.Sprintf("$.textures[%q]", name))
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(f.Materials)) {
+		m := f.Materials[name]
+		path := fmt.Sprintf("$.materials[%q]", name)
+		m.validate(&v, path)
+
+		if m.Texture != "" {
+			if _, ok := f.Textures[m.Texture]; !ok {
+				v.errorf(path+".texture", "refers to undefined texture %q", m.Texture)
+			}
+		}
+		for _, param := range slices.Sorted(maps.Keys(m.Maps)) {
+			if _, ok := f.Textures[m.Maps[param]]; !ok {
+				v.errorf(fmt.Sprintf("%s.maps[%q]", path, param), "refers to undefined texture %q", m.Maps[param])
+			}
+		}
+	}
+
+	if len(f.Objects) == 0 {
+		v.errorf("$.objects", "must contain at least one object")
+	}
+	for i, o := range f.Objects {
+		path := fmt.Sprintf("$.objects[%d]", i)
+		o.validate(&v, path)
+
+		if o.Material == "" {
+			if o.Type != "obj" && o.Type != "gltf" {
+				v.errorf(path+".material", "is required")
+			}
+		} else if _, ok := f.Materials[o.Material]; !ok {
+			v.errorf(path+".material", "refers to undefined material %q", o.Material)
+		}
+	}
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+func (t Texture) validate(v *validator, path string) {
+	switch t.Type {
+	case "solid":
+		v.vec(path+".color", t.Color, true)
+	case "checker":
+		if t.Scale == nil {
+			v.errorf(path+".scale", "is required")
+		}
+		v.positive(path+".scale", t.Scale)
+		v.vec(path+".even", t.Even, true)
+		v.vec(path+".odd", t.Odd, true)
+	case "noise":
+		v.positive(path+".scale", t.Scale)
+	case "image":
+		if t.Path == "" {
+			v.errorf(path+".path", "is required")
+		}
+	case "":
+		v.errorf(path+".type", "is required")
+	default:
+		v.errorf(path+".type", "unknown texture type %q", t.Type)
+	}
+}
+
+// noTexture checks no texture is given for a material whose colour comes from its refractive
+// index rather than a texture, which would otherwise be dropped without a word
+func (v *validator) noTexture(path string, m Material) {
+	if m.Texture != "" {
+		v.errorf(path+".texture", "cannot be given for a %s material", m.Type)
+	}
+}
+
+// colorOrTexture checks exactly one of a colour field and the texture field is set
+func (v *validator) colorOrTexture(path, field string, col Vec, tex string) {
+	switch {
+	case col != nil && tex != "":
+		v.errorf(path+"."+field, "cannot be given alongside a texture")
+	case tex == "":
+		v.vec(path+"."+field, col, true)
+	}
+}
+
+func (m Material) validate(v *validator, path string) {
+	switch m.Type {
+	case "lambertian":
+		v.colorOrTexture(path, "albedo", m.Albedo, m.Texture)
+	case "metal":
+		v.colorOrTexture(path, "albedo", m.Albedo, m.Texture)
+		if m.Fuzz != nil && (*m.Fuzz < 0 || *m.Fuzz > 1) {
+			v.errorf(path+".fuzz", "must be between 0 and 1, got %g", *m.Fuzz)
+		}
+	case "dielectric":
+		if m.RefractionIndex == nil {
+			v.errorf(path+".refractionIndex", "is required")
+		}
+		v.positive(path+".refractionIndex", m.RefractionIndex)
+		v.noTexture(path, m)
+	case "conductor":
+		switch {
+		case m.Metal != "" && (m.Eta != nil || m.K != nil):
+			v.errorf(path+".metal", "cannot be given alongside eta and k")
+		case m.Metal != "":
+			if _, ok := material.ComplexIORByName(m.Metal); !ok {
+				v.errorf(path+".metal", "unknown metal %q, must be one of %s", m.Metal, strings.Join(material.ComplexIORNames(), ", "))
+			}
+		default:
+			v.vec(path+".eta", m.Eta, true)
+			v.vec(path+".k", m.K, true)
+		}
+		v.roughness(path+".roughness", m.Roughness)
+		v.noTexture(path, m)
+	case "roughDielectric":
+		if m.RefractionIndex == nil {
+			v.errorf(path+".refractionIndex", "is required")
+		}
+		v.positive(path+".refractionIndex", m.RefractionIndex)
+		v.roughness(path+".roughness", m.Roughness)
+		v.noTexture(path, m)
+	case "principled":
+		if m.Albedo != nil {
+			v.colorOrTexture(path, "albedo", m.Albedo, m.Texture)
+		}
+		if m.RefractionIndex != nil {
+			v.positive(path+".refractionIndex", m.RefractionIndex)
+		}
+		params := m.principledParams()
+		for _, name := range slices.Sorted(maps.Keys(params)) {
+			v.roughness(path+"."+name, params[name])
+		}
+		for _, name := range slices.Sorted(maps.Keys(m.Maps)) {
+			f, ok := params[name]
+			switch {
+			case !ok:
+				v.errorf(path+".maps", "unknown parameter %q", name)
+			case f != nil:
+				v.errorf(path+"."+name, "cannot be given alongside a map")
+			}
+		}
+	case "diffuseLight":
+		v.colorOrTexture(path, "emit", m.Emit, m.Texture)
+	case "isotropic":
+		v.colorOrTexture(path, "albedo", m.Albedo, m.Texture)
+	case "henyeyGreenstein":
+		v.colorOrTexture(path, "albedo", m.Albedo, m.Texture)
+		if m.Anisotropy != nil && (*m.Anisotropy <= -1 || *m.Anisotropy >= 1) {
+			v.errorf(path+".anisotropy", "must be between -1 and 1 exclusive, got %g", *m.Anisotropy)
+		}
+	case "":
+		v.errorf(path+".type", "is required")
+	default:
+		v.errorf(path+".type", "unknown material type %q", m.Type)
+	}
+}
+
+func (o Object) validate(v *validator, path string) {
+	switch o.Type {
+	case "sphere":
+		v.vec(path+".centre", o.Centre, true)
+		if o.Radius == nil {
+			v.errorf(path+".radius", "is required")
+		}
+		v.positive(path+".radius", o.Radius)
+		v.vec(path+".centre1", o.Centre1, false)
+	case "triangle":
+		if len(o.Vertices) != 3 {
+			v.errorf(path+".vertices", "must have 3 vertices, got %d", len(o.Vertices))
+		}
+		for i, vert := range o.Vertices {
+			v.vec(fmt.Sprintf("%s.vertices[%d]", path, i), vert, true)
+		}
+		if len(o.Vertices) == 3 && len(o.Vertices[0]) == 3 && len(o.Vertices[1]) == 3 && len(o.Vertices[2]) == 3 {
+			a, b, c := o.Vertices[0].vector(), o.Vertices[1].vector(), o.Vertices[2].vector()
+			if vec3.IsNearZero(vec3.Cross(vec3.Sub(b, a), vec3.Sub(c, a))) {
+				v.errorf(path+".vertices", "must not be collinear or coincident, the triangle would have no area")
+			}
+		}
+	case "quad":
+		v.vec(path+".corner", o.Corner, true)
+		v.vec(path+".u", o.U, true)
+		v.vec(path+".v", o.V, true)
+		if len(o.U) == 3 && len(o.V) == 3 && vec3.IsNearZero(vec3.Cross(o.U.vector(), o.V.vector())) {
+			v.errorf(path+".v", "must not be zero or parallel to u")
+		}
+	case "box":
+		v.vec(path+".min", o.Min, true)
+		v.vec(path+".max", o.Max, true)
+		if len(o.Min) == 3 && len(o.Max) == 3 {
+			d := vec3.Sub(o.Max.vector(), o.Min.vector())
+			if min(math.Abs(d.X()), math.Abs(d.Y()), math.Abs(d.Z())) < 1e-8 {
+				v.errorf(path+".max", "must differ from min in every coordinate, the box would be flat")
+			}
+		}
+	case "disk":
+		v.vec(path+".centre", o.Centre, true)
+		v.normal(path+".normal", o.Normal)
+		if o.Radius == nil {
+			v.errorf(path+".radius", "is required")
+		}
+		v.positive(path+".radius", o.Radius)
+	case "plane":
+		v.vec(path+".point", o.Point, true)
+		v.normal(path+".normal", o.Normal)
+	case "obj":
+		if o.Path == "" {
+			v.errorf(path+".path", "is required")
+		}
+	case "gltf":
+		if o.Path == "" {
+			v.errorf(path+".path", "is required")
+		}
+		if o.Material != "" {
+			v.errorf(path+".material", "cannot be given for a gltf model, it has materials of its own")
+		}
+	case "":
+		v.errorf(path+".type", "is required")
+	default:
+		v.errorf(path+".type", "unknown object type %q", o.Type)
+	}
+
+	if o.Density != nil {
+		v.positive(path+".density", o.Density)
+		switch o.Type {
+		case "triangle", "quad", "disk", "plane":
+			v.errorf(path+".density", "a %s can't hold a medium, it must be a closed shape", o.Type)
+		case "gltf":
+			v.errorf(path+".density", "a gltf model can't hold a medium, it has no material to fill it with")
+		}
+	}
+
+	if o.Transform != nil {
+		o.Transform.validate(v, path+".transform")
+	}
+	if o.Transform != nil && o.Keyframes != nil {
+		v.errorf(path+".keyframes", "must not be given along with transform")
+	}
+	for i, k := range o.Keyframes {
+		kpath := fmt.Sprintf("%s.keyframes[%d]", path, i)
+		k.validate(v, kpath)
+		for _, prev := range o.Keyframes[:i] {
+			if prev.Time == k.Time {
+				v.errorf(kpath+".time", "more than one keyframe at time %g", k.Time)
+				break
+			}
+		}
+	}
+}
+
+func (t Transform) validate(v *validator, path string) {
+	v.vec(path+".translate", t.Translate, false)
+	v.vec(path+".rotate", t.Rotate, false)
+	v.vec(path+".scale", t.Scale, false)
+	if len(t.Scale) == 3 && (t.Scale[0] == 0 || t.Scale[1] == 0 || t.Scale[2] == 0) {
+		v.errorf(path+".scale", "must not have a zero component")
+	}
+}
+
+// keyframe returns the pose described by t at the given time
+func (t Transform) keyframe(time float64) transform.Keyframe {
+	k := transform.Keyframe{
+		Time:      time,
+		Translate: vec3.New(0, 0, 0),
+		Rotate:    mat4.IdentityRotation(),
+		Scale:     vec3.New(1, 1, 1),
+	}
+	if t.Translate != nil {
+		k.Translate = t.Translate.vector()
+	}
+	if t.Rotate != nil {
+		k.Rotate = mat4.FromEuler(
+			utility.Deg2Rad(t.Rotate[0]), utility.Deg2Rad(t.Rotate[1]), utility.Deg2Rad(t.Rotate[2]),
+		)
+	}
+	if t.Scale != nil {
+		k.Scale = t.Scale.vector()
+	}
+	return k
+}
+
+// matrix returns the object to world matrix described by t
+func (t Transform) matrix() mat4.Matrix4 {
+	k := t.keyframe(0)
+	return mat4.TRS(k.Translate, k.Rotate, k.Scale)
+}
+
+// Build constructs the world and camera described by a validated file. Relative model paths
+// are resolved against dir.
+func (f *File) Build(dir string) (*Scene, error) {
+	// Textures are built in name order, each with its own random stream, so procedural
+	// textures come out the same every time the file is loaded
+	textures := make(map[string]texture.Texturer, len(f.Textures))
+	for i, name := range slices.Sorted(maps.Keys(f.Textures)) {
+		tex, err := f.Textures[name].build(dir, utility.NewRand(f.Render.Seed, uint64(i)))
+		if err != nil {
+			return nil, fmt.Errorf("$.textures[%q].path: %w", name, err)
+		}
+		textures[name] = tex
+	}
+
+	materials := make(map[string]hitrecord.Scatterer, len(f.Materials))
+	for name, m := range f.Materials {
+		materials[name] = m.build(textures)
+	}
+
+	// Models are loaded once for each path and material, objects using the same ones share
+	// the same geometry in whatever pose their transforms give them
+	type modelKey struct{ path, material string }
+	models := make(map[modelKey]hittable.Hittabler)
+	var warnings []string
+
+	var world hittable.HittableList
+	for i, o := range f.Objects {
+		mat := materials[o.Material]
+
+		var object hittable.Hittabler
+		switch o.Type {
+		case "sphere":
+			centre1 := o.Centre
+			if o.Centre1 != nil {
+				centre1 = o.Centre1
+			}
+			object = sphere.NewMoving(o.Centre.vector(), centre1.vector(), *o.Radius, mat)
+		case "triangle":
+			object = triangle.New(
+				o.Vertices[0].vector(), o.Vertices[1].vector(), o.Vertices[2].vector(), mat,
+			)
+		case "quad":
+			object = planar.NewQuad(o.Corner.vector(), o.U.vector(), o.V.vector(), mat)
+		case "box":
+			object = planar.NewBox(o.Min.vector(), o.Max.vector(), mat)
+		case "disk":
+			object = planar.NewDisk(o.Centre.vector(), o.Normal.vector(), *o.Radius, mat)
+		case "plane":
+			object = planar.NewPlane(o.Point.vector(), o.Normal.vector(), mat)
+		case "obj":
+			key := modelKey{resolvePath(dir, o.Path), o.Material}
+			if object = models[key]; object == nil {
+				model, err := obj.Load(key.path, mat)
+				if err != nil {
+					return nil, fmt.Errorf("$.objects[%d].path: %w", i, err)
+				}
+				object = model.Hittable()
+				models[key] = object
+			}
+		case "gltf":
+			key := modelKey{resolvePath(dir, o.Path), ""}
+			if object = models[key]; object == nil {
+				model, err := gltf.Load(key.path)
+				if err != nil {
+					return nil, fmt.Errorf("$.objects[%d].path: %w", i, err)
+				}
+				for _, w := range model.Warnings {
+					warnings = append(warnings, fmt.Sprintf("%s: %s", o.Path, w))
+				}
+				object = model.World
+				models[key] = object
+			}
+		}
+
+		if o.Transform != nil {
+			tr, err := transform.New(object, o.Transform.matrix())
+			if err != nil {
+				return nil, fmt.Errorf("$.objects[%d].transform: %w", i, err)
+			}
+			object = tr
+		}
+		if o.Keyframes != nil {
+			keys := make([]transform.Keyframe, len(o.Keyframes))
+			for j, k := range o.Keyframes {
+				keys[j] = k.keyframe(k.Time)
+			}
+			anim, err := transform.NewAnimated(object, keys)
+			if err != nil {
+				return nil, fmt.Errorf("$.objects[%d].keyframes: %w", i, err)
+			}
+			object = anim
+		}
+		if o.Density != nil {
+			fog, err := medium.NewConstant(object, *o.Density, mat)
+			if err != nil {
+				return nil, fmt.Errorf("$.objects[%d].density: %w", i, err)
+			}
+			object = fog
+		}
+		world.Add(object)
+	}
+
+	display := color.Display{Exposure: f.Render.Exposure}
+	if f.Render.ToneMap != "" {
+		display.ToneMap, _ = color.ToneMapperByName(f.Render.ToneMap)
+	}
+
+	cam, err := f.Camera.build(dir, f.Render)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Scene{
+		World:    bvh.New(world),
+		Camera:   cam,
+		Parallel: f.Render.Parallel,
+		Display:  display,
+		Warnings: warnings,
+	}, nil
+}
+
+// roughness returns the material's roughness, 0 if it isn't given
+func (m Material) roughness() float64 {
+	if m.Roughness == nil {
+		return 0
+	}
+	return *m.Roughness
+}
+
+// principledParams returns the numeric parameters of a principled material by JSON field name
+func (m Material) principledParams() map[string]*float64 {
+	return map[string]*float64{
+		"roughness":          m.Roughness,
+		"metallic":           m.Metallic,
+		"specular":           m.Specular,
+		"clearcoat":          m.Clearcoat,
+		"clearcoatRoughness": m.ClearcoatRoughness,
+		"sheen":              m.Sheen,
+		"sheenTint":          m.SheenTint,
+		"transmission":       m.Transmission,
+	}
+}
+
+// principled returns a principled material, with each parameter a solid value, a map, or nil
+// to take its default
+func (m Material) principled(base texture.Texturer, textures map[string]texture.Texturer) *material.Principled {
+	params := m.principledParams()
+	param := func(name string) texture.Texturer {
+		if tex, ok := m.Maps[name]; ok {
+			return textures[tex]
+		}
+		if f := params[name]; f != nil {
+			return texture.NewSolidValue(*f)
+		}
+		return nil
+	}
+
+	p := material.PrincipledParams{
+		BaseColor:          base,
+		Metallic:           param("metallic"),
+		Roughness:          param("roughness"),
+		Specular:           param("specular"),
+		Clearcoat:          param("clearcoat"),
+		ClearcoatRoughness: param("clearcoatRoughness"),
+		Sheen:              param("sheen"),
+		SheenTint:          param("sheenTint"),
+		Transmission:       param("transmission"),
+	}
+	if m.RefractionIndex != nil {
+		p.IOR = *m.RefractionIndex
+	}
+	return material.NewPrincipled(p)
+}
+
+func (t Texture) build(dir string, rng *utility.Rand) (texture.Texturer, error) {
+	switch t.Type {
+	case "checker":
+		even := color.Color(t.Even.vector())
+		odd := color.Color(t.Odd.vector())
+		return texture.NewCheckerColors(*t.Scale, even, odd), nil
+	case "noise":
+		scale := 1.0
+		if t.Scale != nil {
+			scale = *t.Scale
+		}
+		return texture.NewNoise(rng, scale), nil
+	case "image":
+		return texture.LoadImage(resolvePath(dir, t.Path))
+	default:
+		return texture.NewSolidColor(color.Color(t.Color.vector())), nil
+	}
+}
+
+func (m Material) build(textures map[string]texture.Texturer) hitrecord.Scatterer {
+	tex := textures[m.Texture]
+	if tex == nil {
+		col := m.Albedo
+		if m.Type == "diffuseLight" {
+			col = m.Emit
+		}
+		if col != nil {
+			tex = texture.NewSolidColor(color.Color(col.vector()))
+		}
+	}
+
+	switch m.Type {
+	case "metal":
+		fuzz := 0.0
+		if m.Fuzz != nil {
+			fuzz = *m.Fuzz
+		}
+		return material.NewMetalTexture(tex, fuzz)
+	case "dielectric":
+		return material.NewDielectric(*m.RefractionIndex)
+	case "conductor":
+		ior, ok := material.ComplexIORByName(m.Metal)
+		if !ok {
+			ior = material.ComplexIOR{Eta: color.Color(m.Eta.vector()), K: color.Color(m.K.vector())}
+		}
+		return material.NewConductor(ior, m.roughness())
+	case "roughDielectric":
+		return material.NewRoughDielectric(*m.RefractionIndex, m.roughness())
+	case "principled":
+		return m.principled(tex, textures)
+	case "diffuseLight":
+		return material.NewDiffuseLightTexture(tex)
+	case "isotropic":
+		return material.NewIsotropicTexture(tex)
+	case "henyeyGreenstein":
+		g := 0.0
+		if m.Anisotropy != nil {
+			g = *m.Anisotropy
+		}
+		return material.NewHenyeyGreensteinTexture(tex, g)
+	default:
+		return material.NewLambertianTexture(tex)
+	}
+}
+
+// resolvePath returns path joined to dir, unless it is already absolute
+func resolvePath(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+func (c Camera) build(dir string, r Render) (*camera.Camera, error) {
+	cam := camera.New()
+	cam.Seed = r.Seed
+	setIf(&cam.TileSize, r.TileSize)
+	setIf(&cam.SampleLights, r.SampleLights)
+	if r.TileOrder != "" {
+		cam.TileOrder, _ = scheduler.ParseOrder(r.TileOrder)
+	}
+
+	setIf(&cam.AspectRatio, c.AspectRatio)
+	setIf(&cam.ImageWidth, c.ImageWidth)
+	setIf(&cam.SamplesPerPixel, c.SamplesPerPixel)
+	setIf(&cam.MaxDepth, c.MaxDepth)
+	setIf(&cam.VerticalFov, c.VerticalFov)
+	setIf(&cam.DefocusAngle, c.DefocusAngle)
+	setIf(&cam.FocusDistance, c.FocusDistance)
+	setIf(&cam.ShutterOpen, c.ShutterOpen)
+	setIf(&cam.ShutterClose, c.ShutterClose)
+
+	if c.LookFrom != nil {
+		cam.LookFrom = c.LookFrom.vector()
+	}
+	if c.LookAt != nil {
+		cam.LookAt = c.LookAt.vector()
+	}
+	if c.VUp != nil {
+		cam.VUp = c.VUp.vector()
+	}
+	if bg := c.Background; bg != nil {
+		intensity := 1.0
+		setIf(&intensity, bg.Intensity)
+
+		switch bg.Type {
+		case "constant":
+			cam.Background = background.NewConstant(color.Color(bg.Color.vector()))
+		case "sunSky":
+			turbidity := 3.0
+			setIf(&turbidity, bg.Turbidity)
+			cam.Background = background.NewSunSky(
+				utility.Deg2Rad(*bg.Elevation), utility.Deg2Rad(bg.Azimuth), turbidity, intensity,
+			)
+		case "environment":
+			env, err := background.LoadEnvironment(resolvePath(dir, bg.Path), utility.Deg2Rad(bg.Rotation), intensity)
+			if err != nil {
+				return nil, fmt.Errorf("$.camera.background.path: %w", err)
+			}
+			cam.Background = env
+		}
+	}
+
+	return cam, nil
+}
+
+func setIf[T any](dst *T, src *T) {
+	if src != nil {
+		*dst = *src
+	}
+}
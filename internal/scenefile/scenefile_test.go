@@ -0,0 +1,503 @@
+package scenefile_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sendelivery/go-trace-rays/internal/background"
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/encode"
+	"github.com/sendelivery/go-trace-rays/internal/image"
+	"github.com/sendelivery/go-trace-rays/internal/interval"
+	"github.com/sendelivery/go-trace-rays/internal/object/material"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/scenefile"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+func TestLoadExample(t *testing.T) {
+	s, err := scenefile.Load("../../examples/simple.json")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if s.Camera.ImageWidth != 400 {
+		t.Errorf("unexpected image width, got=%d. want=400.", s.Camera.ImageWidth)
+	}
+	if !s.Parallel {
+		t.Error("expected parallel rendering to be enabled")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	src := `{
+		"camera": {"aspectRatio": 0, "samplesPerPixel": -1, "lookAt": [0, 1], "shutterOpen": 1, "shutterClose": 0.5,
+			"background": {"type": "environment", "intensity": 0}},
+		"materials": {
+			"a": {"type": "plastic"},
+			"b": {"type": "metal", "fuzz": 2},
+			"c": {"type": "henyeyGreenstein", "albedo": [1, 1, 1], "anisotropy": 1},
+			"d": {"type": "conductor", "metal": "brass", "roughness": 0.5},
+			"e": {"type": "roughDielectric", "roughness": 2},
+			"f": {"type": "conductor", "eta": [1, 1, 1]},
+			"g": {"type": "principled", "metallic": 2, "sheen": 0.5, "maps": {"sheen": "missing", "gloss": "missing"}},
+			"h": {"type": "dielectric", "refractionIndex": 1.5, "texture": "grey"},
+			"i": {"type": "conductor", "metal": "gold", "texture": "grey"}
+		},
+		"textures": {"grey": {"type": "solid", "color": [0.5, 0.5, 0.5]}},
+		"objects": [
+			{"type": "sphere", "centre": [0, 0, 0], "radius": 1, "material": "a"},
+			{"type": "sphere", "centre": [0, 0, 0], "material": "missing"},
+			{"type": "triangle", "vertices": [[0, 0, 0], [1, 0, 0]], "material": "b"},
+			{"type": "quad", "corner": [0, 0, 0], "u": [1, 0, 0], "v": [2, 0, 0], "material": "a"},
+			{"type": "disk", "centre": [0, 0, 0], "normal": [0, 0, 0], "radius": 1, "material": "a"},
+			{"type": "plane", "normal": [0, 1, 0], "material": "a"},
+			{"type": "box", "min": [0, 0, 0], "max": [1, 1, 1], "material": "a"},
+			{"type": "sphere", "centre": [0, 0, 0], "radius": 1, "material": "a", "transform": {"rotate": [0, 90], "scale": [1, 0, 1]}},
+			{"type": "sphere", "centre": [0, 0, 0], "radius": 1, "material": "a", "keyframes": [{"time": 0}, {"time": 0, "scale": [0, 1, 1]}]},
+			{"type": "box", "min": [0, 0, 0], "max": [1, 1, 1], "material": "c", "density": 0},
+			{"type": "quad", "corner": [0, 0, 0], "u": [1, 0, 0], "v": [0, 1, 0], "material": "c", "density": 1},
+			{"type": "gltf", "material": "a"},
+			{"type": "quad", "corner": [0, 0, 0], "u": [0, 0, 0], "v": [0, 1, 0], "material": "a"},
+			{"type": "box", "min": [0, 0, 0], "max": [1, 0, 1], "material": "a"},
+			{"type": "triangle", "vertices": [[0, 0, 0], [1, 1, 1], [2, 2, 2]], "material": "a"},
+			{"type": "triangle", "vertices": [[0, 0, 0], [0, 0, 0], [0, 1, 0]], "material": "a"}
+		]
+	}`
+
+	f, err := scenefile.Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+
+	var ve scenefile.ValidationError
+	if err := f.Validate(); !errors.As(err, &ve) {
+		t.Fatalf("expected a ValidationError, got=%v.", err)
+	}
+
+	var got []string
+	for _, fe := range ve {
+		got = append(got, fe.Path)
+	}
+	want := []string{
+		"$.camera.aspectRatio",
+		"$.camera.samplesPerPixel",
+		"$.camera.lookAt",
+		"$.camera.shutterClose",
+		"$.camera.background.path",
+		"$.camera.background.intensity",
+		`$.materials["a"].type`,
+		`$.materials["b"].albedo`,
+		`$.materials["b"].fuzz`,
+		`$.materials["c"].anisotropy`,
+		`$.materials["d"].metal`,
+		`$.materials["e"].refractionIndex`,
+		`$.materials["e"].roughness`,
+		`$.materials["f"].k`,
+		`$.materials["g"].metallic`,
+		`$.materials["g"].maps`,
+		`$.materials["g"].sheen`,
+		`$.materials["g"].maps["gloss"]`,
+		`$.materials["g"].maps["sheen"]`,
+		`$.materials["h"].texture`,
+		`$.materials["i"].texture`,
+		"$.objects[1].radius",
+		"$.objects[1].material",
+		"$.objects[2].vertices",
+		"$.objects[3].v",
+		"$.objects[4].normal",
+		"$.objects[5].point",
+		"$.objects[7].transform.rotate",
+		"$.objects[7].transform.scale",
+		"$.objects[8].keyframes[1].scale",
+		"$.objects[8].keyframes[1].time",
+		"$.objects[9].density",
+		"$.objects[10].density",
+		"$.objects[11].path",
+		"$.objects[11].material",
+		"$.objects[12].v",
+		"$.objects[13].max",
+		"$.objects[14].vertices",
+		"$.objects[15].vertices",
+	}
+
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected error paths (-want +got):\n%s", diff)
+	}
+}
+
+func TestValidateLookAt(t *testing.T) {
+	src := `{
+		"camera": {"lookFrom": [1, 2, 3], "lookAt": [1, 2, 3]},
+		"materials": {"white": {"type": "lambertian", "albedo": [1, 1, 1]}},
+		"objects": [{"type": "sphere", "centre": [0, 0, 0], "radius": 1, "material": "white"}]
+	}`
+	f, err := scenefile.Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+
+	var ve scenefile.ValidationError
+	if err := f.Validate(); !errors.As(err, &ve) || len(ve) != 1 || ve[0].Path != "$.camera.lookAt" {
+		t.Errorf("unexpected error, got=%v. want one for $.camera.lookAt.", err)
+	}
+}
+
+func TestParseUnknownField(t *testing.T) {
+	_, err := scenefile.Parse(strings.NewReader(`{"camera": {"imageWdith": 10}}`))
+	if err == nil {
+		t.Error("expected an error for an unknown field")
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	_, err := scenefile.Load("does-not-exist.json")
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("unexpected error, got=%v. want=%v.", err, os.ErrNotExist)
+	}
+}
+
+func TestTransform(t *testing.T) {
+	src := `{
+		"materials": {"white": {"type": "lambertian", "albedo": [1, 1, 1]}},
+		"objects": [
+			{
+				"type": "box", "min": [0, 0, 0], "max": [1, 1, 1], "material": "white",
+				"transform": {"translate": [10, 0, 0], "rotate": [0, 0, 90], "scale": [2, 1, 1]}
+			}
+		]
+	}`
+
+	s, err := scenefile.Decode(strings.NewReader(src), ".")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Scaled to 2 along X then rotated onto Y, the box spans x 9 to 10 and y 0 to 2
+	bb := s.World.BoundingBox()
+	if bb.X.Min > 9 || bb.X.Max < 10 || bb.X.Min < 8.99 || bb.X.Max > 10.01 {
+		t.Errorf("unexpected x extent, got=%v.", bb.X)
+	}
+	if bb.Y.Min > 0 || bb.Y.Max < 2 || bb.Y.Min < -0.01 || bb.Y.Max > 2.01 {
+		t.Errorf("unexpected y extent, got=%v.", bb.Y)
+	}
+}
+
+func TestMotion(t *testing.T) {
+	src := `{
+		"camera": {"shutterOpen": 0, "shutterClose": 1},
+		"materials": {"white": {"type": "lambertian", "albedo": [1, 1, 1]}},
+		"objects": [
+			{"type": "sphere", "centre": [0, 0, 0], "centre1": [0, 3, 0], "radius": 1, "material": "white"},
+			{
+				"type": "box", "min": [0, 0, 0], "max": [1, 1, 1], "material": "white",
+				"keyframes": [{"time": 0, "translate": [10, 0, 0]}, {"time": 1, "translate": [20, 0, 0]}]
+			}
+		]
+	}`
+
+	s, err := scenefile.Decode(strings.NewReader(src), ".")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Camera.ShutterClose != 1 {
+		t.Errorf("unexpected shutter close, got=%v. want=%v.", s.Camera.ShutterClose, 1)
+	}
+
+	// The world's bounds cover both objects over their whole motion
+	bb := s.World.BoundingBox()
+	if bb.Y.Max < 4 || bb.X.Max < 21 {
+		t.Errorf("unexpected bounds, got x=%v y=%v. want to reach x=21 and y=4.", bb.X, bb.Y)
+	}
+}
+
+func TestMedium(t *testing.T) {
+	src := `{
+		"materials": {"fog": {"type": "henyeyGreenstein", "albedo": [1, 1, 1], "anisotropy": 0.5}},
+		"objects": [
+			{"type": "sphere", "centre": [0, 0, 0], "radius": 1, "material": "fog", "density": 1e6}
+		]
+	}`
+
+	s, err := scenefile.Decode(strings.NewReader(src), ".")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The medium is so dense a ray scatters almost as soon as it enters
+	r := ray.New(vec3.New(0, 0, 5), vec3.New(0, 0, -1))
+	hr, ok := s.World.Hit(r, interval.New(1e-3, math.Inf(1)))
+	if !ok || math.Abs(hr.T()-4) > 1e-3 {
+		t.Errorf("unexpected hit, got=%v at t=%v. want=true at t=4.", ok, hr.T())
+	}
+	if _, ok := hr.Material().(*material.HenyeyGreenstein); !ok {
+		t.Errorf("unexpected material, got=%T. want=*material.HenyeyGreenstein.", hr.Material())
+	}
+}
+
+func TestGLTF(t *testing.T) {
+	// One triangle in the XY plane, with a spot light the loader can't read
+	var positions bytes.Buffer
+	binary.Write(&positions, binary.LittleEndian, []float32{0, 0, 0, 1, 0, 0, 0, 1, 0})
+	model := fmt.Sprintf(`{
+		"asset": {"version": "2.0"},
+		"extensionsUsed": ["KHR_lights_punctual"],
+		"nodes": [{"mesh": 0}],
+		"meshes": [{"primitives": [{"attributes": {"POSITION": 0}}]}],
+		"accessors": [{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"}],
+		"bufferViews": [{"buffer": 0, "byteLength": 36}],
+		"buffers": [{"byteLength": 36, "uri": "data:application/octet-stream;base64,%s"}]
+	}`, base64.StdEncoding.EncodeToString(positions.Bytes()))
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "triangle.gltf"), []byte(model), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	src := `{
+		"objects": [
+			{"type": "gltf", "path": "triangle.gltf", "transform": {"translate": [0, 0, -2]}},
+			{"type": "gltf", "path": "triangle.gltf", "transform": {"translate": [5, 0, -2]}}
+		]
+	}`
+	s, err := scenefile.Decode(strings.NewReader(src), dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, x := range []float64{0.25, 5.25} {
+		r := ray.New(vec3.New(x, 0.25, 5), vec3.New(0, 0, -1))
+		hr, ok := s.World.Hit(r, interval.New(1e-3, math.Inf(1)))
+		if !ok || math.Abs(hr.T()-7) > 1e-6 {
+			t.Errorf("unexpected hit at x=%v, got=%v at t=%v. want=true at t=7.", x, ok, hr.T())
+		}
+	}
+
+	// The model is loaded once, so it only warns once
+	want := []string{"triangle.gltf: extension KHR_lights_punctual is not supported and is ignored"}
+	if diff := cmp.Diff(want, s.Warnings); diff != "" {
+		t.Errorf("unexpected warnings (-want +got):\n%s", diff)
+	}
+}
+
+// backgroundScene returns a scene file of a grey sphere in front of the background bg, given
+// as JSON
+func backgroundScene(bg string) string {
+	return `{
+		"camera": {"background": ` + bg + `},
+		"materials": {"grey": {"type": "lambertian", "albedo": [0.5, 0.5, 0.5]}},
+		"objects": [{"type": "sphere", "centre": [0, 0, 0], "radius": 1, "material": "grey"}]
+	}`
+}
+
+func TestEnvironment(t *testing.T) {
+	// A 2x1 map, red on the half facing +Z and blue on the half facing -Z
+	img := image.New(2, 1)
+	img.Add(image.NewPixelCoord(0, 0), color.New(1, 0, 0))
+	img.Add(image.NewPixelCoord(1, 0), color.New(0, 0, 1))
+
+	dir := t.TempDir()
+	out, err := os.Create(filepath.Join(dir, "sky.pfm"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := (encode.PFM{}).Encode(out, &img); err != nil {
+		t.Fatal(err)
+	}
+	out.Close()
+
+	src := backgroundScene(`{"type": "environment", "path": "sky.pfm", "rotation": 180, "intensity": 2}`)
+	s, err := scenefile.Decode(strings.NewReader(src), dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Turned half way round, the red half faces -Z
+	got := s.Camera.Background.Value(ray.New(vec3.New(0, 0, 0), vec3.New(0, 0, -1)))
+	if want := color.New(2, 0, 0); vec3.Sub(got, want).Length() > 1e-6 {
+		t.Errorf("unexpected colour towards -Z, got=%q. want=%q.", &got, &want)
+	}
+
+	src = backgroundScene(`{"type": "environment", "path": "missing.hdr"}`)
+	if _, err := scenefile.Decode(strings.NewReader(src), dir); err == nil || !strings.Contains(err.Error(), "$.camera.background.path") {
+		t.Errorf("unexpected error, got=%v. want an error at $.camera.background.path.", err)
+	}
+}
+
+func TestSunSky(t *testing.T) {
+	s, err := scenefile.Decode(strings.NewReader(backgroundScene(`{"type": "sunSky", "elevation": 90, "azimuth": 45}`)), ".")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := s.Camera.Background.(*background.SunSky); !ok {
+		t.Fatalf("unexpected background, got=%T. want=*background.SunSky.", s.Camera.Background)
+	}
+
+	// With the sun straight up, looking at it means looking at the sun
+	sun := s.Camera.Background.Value(ray.New(vec3.New(0, 0, 0), vec3.New(0, 1, 0)))
+	if sun.Y() < 1000 {
+		t.Errorf("unexpected colour of the sun, got=%q. want it brighter than 1000.", &sun)
+	}
+
+	f, err := scenefile.Parse(strings.NewReader(backgroundScene(`{"type": "sunSky", "elevation": 95, "turbidity": 1, "intensity": -1}`)))
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	var ve scenefile.ValidationError
+	if err := f.Validate(); !errors.As(err, &ve) {
+		t.Fatalf("expected a ValidationError, got=%v.", err)
+	}
+	var got []string
+	for _, fe := range ve {
+		got = append(got, fe.Path)
+	}
+	want := []string{
+		"$.camera.background.elevation",
+		"$.camera.background.turbidity",
+		"$.camera.background.intensity",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected error paths (-want +got):\n%s", diff)
+	}
+}
+
+// Every kind of texture is read from the file and shows on the surface it is given to
+func TestTextures(t *testing.T) {
+	// A 1x2 image, red on top and blue below
+	img := image.New(1, 2)
+	img.Add(image.NewPixelCoord(0, 0), color.New(1, 0, 0))
+	img.Add(image.NewPixelCoord(0, 1), color.New(0, 0, 1))
+
+	dir := t.TempDir()
+	out, err := os.Create(filepath.Join(dir, "stripes.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := (encode.PNG{}).Encode(out, &img); err != nil {
+		t.Fatal(err)
+	}
+	out.Close()
+
+	scene := func(seed int) string {
+		return fmt.Sprintf(`{
+			"render": {"seed": %d},
+			"textures": {
+				"solid": {"type": "solid", "color": [0.2, 0.4, 0.6]},
+				"checker": {"type": "checker", "scale": 1, "even": [1, 1, 1], "odd": [0, 0, 0]},
+				"image": {"type": "image", "path": "stripes.png"},
+				"marble": {"type": "noise", "scale": 4}
+			},
+			"materials": {
+				"solid": {"type": "lambertian", "texture": "solid"},
+				"checker": {"type": "lambertian", "texture": "checker"},
+				"image": {"type": "lambertian", "texture": "image"},
+				"marble": {"type": "lambertian", "texture": "marble"}
+			},
+			"objects": [
+				{"type": "sphere", "centre": [0, 0, 0], "radius": 1, "material": "solid"},
+				{"type": "sphere", "centre": [10, 0, 0], "radius": 1, "material": "checker"},
+				{"type": "sphere", "centre": [20, 0, 0], "radius": 1, "material": "image"},
+				{"type": "sphere", "centre": [30, 0, 0], "radius": 1, "material": "marble"}
+			]
+		}`, seed)
+	}
+
+	// albedo returns the colour of the surface r hits in the scene built with seed
+	albedo := func(seed int, r ray.Ray) color.Color {
+		s, err := scenefile.Decode(strings.NewReader(scene(seed)), dir)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		hr, ok := s.World.Hit(r, interval.New(1e-3, math.Inf(1)))
+		if !ok {
+			origin := r.Origin()
+			t.Fatalf("expected the ray from %q to hit a sphere", &origin)
+		}
+		f, _ := hr.Material().Evaluate(r, hr, hr.Normal())
+		return vec3.Mulf(f, math.Pi)
+	}
+	front := func(x float64) ray.Ray { return ray.New(vec3.New(x, 0.5, 5), vec3.New(0, 0, -1)) }
+
+	tests := map[string]struct {
+		r    ray.Ray
+		want color.Color
+	}{
+		"solid":        {r: front(0.5), want: color.New(0.2, 0.4, 0.6)},
+		"checker even": {r: front(10.5), want: color.New(1, 1, 1)},
+		"checker odd":  {r: front(9.5), want: color.New(0, 0, 0)},
+		"image top":    {r: ray.New(vec3.New(20, 5, 0), vec3.New(0, -1, 0)), want: color.New(1, 0, 0)},
+		"image bottom": {r: ray.New(vec3.New(20, -5, 0), vec3.New(0, 1, 0)), want: color.New(0, 0, 1)},
+	}
+	for name, tt := range tests {
+		if got := albedo(1, tt.r); vec3.Sub(got, tt.want).Length() > 1e-9 {
+			t.Errorf("%s: unexpected colour, got=%q. want=%q.", name, &got, &tt.want)
+		}
+	}
+
+	// The marble is drawn from the render seed, the same every time the file is loaded
+	marble := front(30.5)
+	first, again, other := albedo(1, marble), albedo(1, marble), albedo(2, marble)
+	if !vec3.Equal(first, again) {
+		t.Errorf("unexpected marble with the same seed, got=%q. want=%q.", &again, &first)
+	}
+	if vec3.Equal(first, other) {
+		t.Errorf("expected a different seed to give a different marble, got=%q for both.", &first)
+	}
+}
+
+func TestMicrofacetMaterials(t *testing.T) {
+	src := `{
+		"materials": {
+			"gold": {"type": "conductor", "metal": "gold", "roughness": 0.3},
+			"custom": {"type": "conductor", "eta": [0.2, 0.9, 1.1], "k": [3.9, 2.4, 2.1]},
+			"frosted": {"type": "roughDielectric", "refractionIndex": 1.5, "roughness": 0.4},
+			"plastic": {"type": "principled", "albedo": [0.8, 0.1, 0.1], "clearcoat": 1, "maps": {"roughness": "scratches"}},
+			"velvet": {"type": "principled", "texture": "scratches", "sheen": 1}
+		},
+		"textures": {
+			"scratches": {"type": "checker", "scale": 0.1, "even": [0.2, 0.2, 0.2], "odd": [0.6, 0.6, 0.6]}
+		},
+		"objects": [
+			{"type": "sphere", "centre": [0, 0, 0], "radius": 1, "material": "gold"},
+			{"type": "sphere", "centre": [3, 0, 0], "radius": 1, "material": "custom"},
+			{"type": "sphere", "centre": [6, 0, 0], "radius": 1, "material": "frosted"},
+			{"type": "sphere", "centre": [9, 0, 0], "radius": 1, "material": "plastic"},
+			{"type": "sphere", "centre": [12, 0, 0], "radius": 1, "material": "velvet"}
+		]
+	}`
+
+	s, err := scenefile.Decode(strings.NewReader(src), ".")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		x    float64
+		want string
+	}{
+		{x: 0, want: "*material.Conductor"},
+		{x: 3, want: "*material.Conductor"},
+		{x: 6, want: "*material.RoughDielectric"},
+		{x: 9, want: "*material.Principled"},
+		{x: 12, want: "*material.Principled"},
+	}
+	for _, tt := range tests {
+		r := ray.New(vec3.New(tt.x, 0, 5), vec3.New(0, 0, -1))
+		hr, ok := s.World.Hit(r, interval.New(1e-3, math.Inf(1)))
+		if !ok {
+			t.Fatalf("expected a hit at x=%v", tt.x)
+		}
+		if got := fmt.Sprintf("%T", hr.Material()); got != tt.want {
+			t.Errorf("unexpected material at x=%v, got=%s. want=%s.", tt.x, got, tt.want)
+		}
+	}
+}
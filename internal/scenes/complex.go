@@ -2,38 +2,37 @@ package scenes
 
 import (
 	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/object/bvh"
 	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
 	"github.com/sendelivery/go-trace-rays/internal/object/hittable"
 	"github.com/sendelivery/go-trace-rays/internal/object/material"
+	"github.com/sendelivery/go-trace-rays/internal/object/planar"
 	"github.com/sendelivery/go-trace-rays/internal/object/sphere"
 	"github.com/sendelivery/go-trace-rays/internal/utility"
 	"github.com/sendelivery/go-trace-rays/internal/vec3"
 )
 
-func NewComplex() hittable.Hittabler {
+// NewComplex returns a field of randomly placed small spheres around three large ones. The
+// layout is drawn from rng, so the same seed always gives the same scene.
+func NewComplex(rng *utility.Rand) hittable.Hittabler {
 	var world hittable.HittableList
 
-	groundMaterial := material.NewLambertian(color.New(0.5, 0.5, 0.5))
-	groundSphere := sphere.New(vec3.New(0, -1000, 0), 1000, groundMaterial)
-
-	world.Add(groundSphere)
-
 	for a := -11; a < 11; a++ {
 		for b := -11; b < 11; b++ {
-			chooseMat := utility.Random()
-			centre := vec3.New(float64(a)+0.9*utility.Random(), 0.2, float64(b)+0.9*utility.Random())
+			chooseMat := rng.Float64()
+			centre := vec3.New(float64(a)+0.9*rng.Float64(), 0.2, float64(b)+0.9*rng.Float64())
 
 			if vec3.Sub(centre, vec3.New(4, 0.2, 0)).Length() > 0.9 {
 				var sphereMat hitrecord.Scatterer
 
 				if chooseMat < 0.8 {
 					// diffuse
-					albedo := color.NewRandom(0, 1)
+					albedo := color.NewRandom(rng, 0, 1)
 					sphereMat = material.NewLambertian(albedo)
 				} else if chooseMat < 0.95 {
 					// metal
-					albedo := color.NewRandom(0.5, 1)
-					fuzz := utility.RandomN(0, 0.5)
+					albedo := color.NewRandom(rng, 0.5, 1)
+					fuzz := rng.Range(0, 0.5)
 					sphereMat = material.NewMetal(albedo, fuzz)
 				} else {
 					// glass
@@ -55,5 +54,12 @@ func NewComplex() hittable.Hittabler {
 		sphere.New(vec3.New(4, 1, 0), 1, mat3),
 	)
 
-	return world
+	// The ground plane is unbounded, so it is kept out of the BVH
+	var scene hittable.HittableList
+	groundMaterial := material.NewLambertian(color.New(0.5, 0.5, 0.5))
+	scene.Add(
+		planar.NewPlane(vec3.New(0, 0, 0), vec3.New(0, 1, 0), groundMaterial),
+		bvh.New(world),
+	)
+	return scene
 }
@@ -0,0 +1,88 @@
+package scenes
+
+import (
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/mat4"
+	"github.com/sendelivery/go-trace-rays/internal/object/bvh"
+	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
+	"github.com/sendelivery/go-trace-rays/internal/object/hittable"
+	"github.com/sendelivery/go-trace-rays/internal/object/material"
+	"github.com/sendelivery/go-trace-rays/internal/object/medium"
+	"github.com/sendelivery/go-trace-rays/internal/object/planar"
+	"github.com/sendelivery/go-trace-rays/internal/object/transform"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// NewCornellBox returns the classic Cornell box, lit only by the light in its ceiling. It is
+// intended to be viewed from (278, 278, -800) looking at (278, 278, 0) with a 40 degree field
+// of view and a black background.
+func NewCornellBox() (hittable.Hittabler, error) {
+	world := cornellRoom()
+
+	white := material.NewLambertian(color.New(0.73, 0.73, 0.73))
+	tall, err := turnedBox(vec3.New(165, 330, 165), 15, vec3.New(265, 0, 295), white)
+	if err != nil {
+		return nil, err
+	}
+	short, err := turnedBox(vec3.New(165, 165, 165), -18, vec3.New(130, 0, 65), white)
+	if err != nil {
+		return nil, err
+	}
+	world.Add(tall, short)
+
+	return bvh.New(world), nil
+}
+
+// NewCornellSmoke returns the Cornell box with its two boxes replaced by blocks of dark smoke
+// and white fog. It is viewed the same way as NewCornellBox.
+func NewCornellSmoke() (hittable.Hittabler, error) {
+	world := cornellRoom()
+
+	smoke := material.NewIsotropic(color.Black)
+	fog := material.NewIsotropic(color.White)
+	tall, err := turnedBox(vec3.New(165, 330, 165), 15, vec3.New(265, 0, 295), nil)
+	if err != nil {
+		return nil, err
+	}
+	short, err := turnedBox(vec3.New(165, 165, 165), -18, vec3.New(130, 0, 65), nil)
+	if err != nil {
+		return nil, err
+	}
+	tallSmoke, err := medium.NewConstant(tall, 0.01, smoke)
+	if err != nil {
+		return nil, err
+	}
+	shortFog, err := medium.NewConstant(short, 0.01, fog)
+	if err != nil {
+		return nil, err
+	}
+	world.Add(tallSmoke, shortFog)
+
+	return bvh.New(world), nil
+}
+
+// cornellRoom returns the walls, floor and ceiling light of the Cornell box
+func cornellRoom() hittable.HittableList {
+	var world hittable.HittableList
+
+	red := material.NewLambertian(color.New(0.65, 0.05, 0.05))
+	white := material.NewLambertian(color.New(0.73, 0.73, 0.73))
+	green := material.NewLambertian(color.New(0.12, 0.45, 0.15))
+	light := material.NewDiffuseLight(color.New(15, 15, 15))
+
+	world.Add(planar.NewQuad(vec3.New(555, 0, 0), vec3.New(0, 555, 0), vec3.New(0, 0, 555), green))
+	world.Add(planar.NewQuad(vec3.New(0, 0, 0), vec3.New(0, 555, 0), vec3.New(0, 0, 555), red))
+	world.Add(planar.NewQuad(vec3.New(343, 554, 332), vec3.New(-130, 0, 0), vec3.New(0, 0, -105), light))
+	world.Add(planar.NewQuad(vec3.New(0, 0, 0), vec3.New(555, 0, 0), vec3.New(0, 0, 555), white))
+	world.Add(planar.NewQuad(vec3.New(555, 555, 555), vec3.New(-555, 0, 0), vec3.New(0, 0, -555), white))
+	world.Add(planar.NewQuad(vec3.New(0, 0, 555), vec3.New(555, 0, 0), vec3.New(0, 555, 0), white))
+	return world
+}
+
+// turnedBox returns a box with one corner at the origin and the other at size, turned degrees
+// about the Y axis and then moved by offset
+func turnedBox(size vec3.Vector3, degrees float64, offset vec3.Vector3, mat hitrecord.Scatterer) (hittable.Hittabler, error) {
+	m := mat4.Mul(mat4.Translate(offset), mat4.Rotate(vec3.New(0, 1, 0), utility.Deg2Rad(degrees)))
+	return transform.New(planar.NewBox(vec3.New(0, 0, 0), size, mat), m)
+}
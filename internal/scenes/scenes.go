@@ -0,0 +1,96 @@
+package scenes
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sendelivery/go-trace-rays/internal/background"
+	"github.com/sendelivery/go-trace-rays/internal/camera"
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/object/hittable"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// builtins maps each built-in scene name to a function building its world and camera
+var builtins = map[string]func(seed uint64) (hittable.Hittabler, *camera.Camera, error){
+	"simple":  simpleScene,
+	"complex": complexScene,
+	"cornell": cornellScene,
+	"smoke":   smokeScene,
+}
+
+// Names returns the names of the built-in scenes, sorted
+func Names() []string {
+	names := make([]string, 0, len(builtins))
+	for name := range builtins {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ByName returns the world of the built-in scene name, along with a camera set up to view it.
+// Scenes with randomly generated content draw it from seed.
+func ByName(name string, seed uint64) (hittable.Hittabler, *camera.Camera, error) {
+	build, ok := builtins[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown scene %q, must be one of %s", name, strings.Join(Names(), ", "))
+	}
+	return build(seed)
+}
+
+func simpleScene(seed uint64) (hittable.Hittabler, *camera.Camera, error) {
+	return NewSimple(), outdoorCamera(), nil
+}
+
+func complexScene(seed uint64) (hittable.Hittabler, *camera.Camera, error) {
+	return NewComplex(utility.NewRand(seed, 0)), outdoorCamera(), nil
+}
+
+// outdoorCamera views the sphere scenes from a little above the ground, focused on the centre
+func outdoorCamera() *camera.Camera {
+	cam := camera.New()
+	cam.AspectRatio = 16.0 / 9.0
+	cam.ImageWidth = 1200
+	cam.SamplesPerPixel = 500
+	cam.MaxDepth = 50
+
+	cam.VerticalFov = 20
+	cam.LookFrom = vec3.New(13, 2, 3)
+	cam.LookAt = vec3.New(0, 0, 0)
+	cam.VUp = vec3.New(0, 1, 0)
+
+	cam.DefocusAngle = 0.6
+	cam.FocusDistance = 10.0
+	return cam
+}
+
+func cornellScene(seed uint64) (hittable.Hittabler, *camera.Camera, error) {
+	world, err := NewCornellBox()
+	return world, cornellCamera(), err
+}
+
+func smokeScene(seed uint64) (hittable.Hittabler, *camera.Camera, error) {
+	world, err := NewCornellSmoke()
+	return world, cornellCamera(), err
+}
+
+// cornellCamera looks into the Cornell box through its open side
+func cornellCamera() *camera.Camera {
+	cam := camera.New()
+	cam.AspectRatio = 1.0
+	cam.ImageWidth = 600
+	cam.SamplesPerPixel = 200
+	cam.MaxDepth = 50
+	cam.Background = background.NewConstant(color.Black)
+
+	cam.VerticalFov = 40
+	cam.LookFrom = vec3.New(278, 278, -800)
+	cam.LookAt = vec3.New(278, 278, 0)
+	cam.VUp = vec3.New(0, 1, 0)
+
+	cam.DefocusAngle = 0
+	return cam
+}
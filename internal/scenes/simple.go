@@ -2,8 +2,10 @@ package scenes
 
 import (
 	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/object/bvh"
 	"github.com/sendelivery/go-trace-rays/internal/object/hittable"
 	"github.com/sendelivery/go-trace-rays/internal/object/material"
+	"github.com/sendelivery/go-trace-rays/internal/object/planar"
 	"github.com/sendelivery/go-trace-rays/internal/object/sphere"
 	"github.com/sendelivery/go-trace-rays/internal/vec3"
 )
@@ -17,7 +19,7 @@ func NewSimple() hittable.Hittabler {
 	materialBubble := material.NewDielectric(1 / 1.5)
 	materialRight := material.NewMetal(color.New(0.8, 0.6, 0.2), 0.1)
 
-	ground := sphere.New(vec3.New(0, -100.5, -1), 100, materialGround)
+	ground := planar.NewPlane(vec3.New(0, -0.5, 0), vec3.New(0, 1, 0), materialGround)
 	centre := sphere.New(vec3.New(4, 0, 1), 0.5, materialCentre)
 	left := sphere.New(vec3.New(3, 0, 2), 0.5, materialLeft)
 	bubble := sphere.New(vec3.New(3, 0, 2), 0.4, materialBubble)
@@ -25,5 +27,5 @@ func NewSimple() hittable.Hittabler {
 
 	world.Add(ground, centre, left, bubble, right)
 
-	return world
+	return bvh.New(world)
 }
@@ -0,0 +1,132 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sendelivery/go-trace-rays/internal/image"
+)
+
+// Order is the sequence in which tiles are handed out to workers
+type Order int
+
+const (
+	Scanline Order = iota // Left to right, top to bottom
+	Spiral                // Outwards from the centre of the image
+	Hilbert               // Along a Hilbert curve, keeping consecutive tiles close together
+)
+
+var orderNames = []string{"scanline", "spiral", "hilbert"}
+
+func (o Order) String() string {
+	if o < 0 || int(o) >= len(orderNames) {
+		return fmt.Sprintf("Order(%d)", int(o))
+	}
+	return orderNames[o]
+}
+
+// ParseOrder returns the Order with the given name.
+func ParseOrder(name string) (Order, error) {
+	for i, n := range orderNames {
+		if strings.EqualFold(name, n) {
+			return Order(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown tile order %q, expected one of %s", name, strings.Join(orderNames, ", "))
+}
+
+// Tiles splits a width by height image into square tiles of side size, returned in the given
+// order. Tiles along the right and bottom edges are cropped to the image.
+func Tiles(width, height, size int, order Order) []image.Chunk {
+	size = max(size, 1)
+	cols := (width + size - 1) / size
+	rows := (height + size - 1) / size
+
+	tile := func(col, row int) image.Chunk {
+		return image.NewChunk(
+			image.NewPixelCoord(col*size, row*size),
+			image.NewPixelCoord(min((col+1)*size, width), min((row+1)*size, height)),
+		)
+	}
+
+	tiles := make([]image.Chunk, 0, cols*rows)
+	add := func(col, row int) {
+		if col >= 0 && col < cols && row >= 0 && row < rows {
+			tiles = append(tiles, tile(col, row))
+		}
+	}
+
+	switch order {
+	case Spiral:
+		spiral(cols, rows, add)
+	case Hilbert:
+		hilbert(cols, rows, add)
+	default:
+		for row := range rows {
+			for col := range cols {
+				add(col, row)
+			}
+		}
+	}
+
+	return tiles
+}
+
+// spiral walks a square spiral out from the centre of a cols by rows grid, calling visit for
+// every cell on the way, including those outside the grid. It stops once every cell in the
+// grid has been visited.
+func spiral(cols, rows int, visit func(col, row int)) {
+	col, row := (cols-1)/2, (rows-1)/2
+	dirs := [4][2]int{{1, 0}, {0, 1}, {-1, 0}, {0, -1}} // right, down, left, up
+
+	// Enough steps for the spiral to cover the larger dimension from the centre
+	extent := max(cols, rows) + 1
+
+	visit(col, row)
+	for leg := 0; leg/2 < extent; leg++ {
+		d := dirs[leg%4]
+		for range leg/2 + 1 {
+			col += d[0]
+			row += d[1]
+			visit(col, row)
+		}
+	}
+}
+
+// hilbert walks a Hilbert curve over the smallest power of two square covering a cols by rows
+// grid, calling visit for every cell.
+func hilbert(cols, rows int, visit func(col, row int)) {
+	n := 1
+	for n < cols || n < rows {
+		n *= 2
+	}
+
+	for d := range n * n {
+		x, y := hilbertD2XY(n, d)
+		visit(x, y)
+	}
+}
+
+// hilbertD2XY converts a distance d along a Hilbert curve filling an n by n square, n being a
+// power of two, into grid coordinates.
+func hilbertD2XY(n, d int) (x, y int) {
+	t := d
+	for s := 1; s < n; s *= 2 {
+		rx := 1 & (t / 2)
+		ry := 1 & (t ^ rx)
+
+		// Rotate the quadrant
+		if ry == 0 {
+			if rx == 1 {
+				x = s - 1 - x
+				y = s - 1 - y
+			}
+			x, y = y, x
+		}
+
+		x += s * rx
+		y += s * ry
+		t /= 4
+	}
+	return x, y
+}
@@ -0,0 +1,96 @@
+package scheduler
+
+import (
+	"sync"
+
+	"github.com/sendelivery/go-trace-rays/internal/image"
+)
+
+// Scheduler hands out tiles to a fixed set of workers. Each worker has its own queue, and a
+// worker that empties its queue steals from the back of the fullest one, so workers that
+// draw cheap tiles pick up the slack from those stuck on expensive ones.
+type Scheduler struct {
+	queues []queue
+}
+
+// queue is a double-ended queue of tiles. Its owner takes from the front, keeping to the
+// requested order, while thieves take from the back.
+type queue struct {
+	mu    sync.Mutex
+	tiles []image.Chunk
+}
+
+// New returns a Scheduler dealing tiles, in order, round robin to workers queues. Tiles near
+// the start of the order are therefore rendered first whichever worker gets them.
+func New(tiles []image.Chunk, workers int) *Scheduler {
+	s := Scheduler{queues: make([]queue, max(workers, 1))}
+	for i, t := range tiles {
+		q := &s.queues[i%len(s.queues)]
+		q.tiles = append(q.tiles, t)
+	}
+	return &s
+}
+
+// Workers returns the number of workers the Scheduler was created for.
+func (s *Scheduler) Workers() int {
+	return len(s.queues)
+}
+
+// Next returns the next tile for worker, or false once every tile has been handed out. It is
+// safe to call from several goroutines, each worker using its own index.
+func (s *Scheduler) Next(worker int) (image.Chunk, bool) {
+	if t, ok := s.queues[worker].popFront(); ok {
+		return t, true
+	}
+
+	for {
+		victim := s.fullest()
+		if victim < 0 {
+			return image.Chunk{}, false
+		}
+		if t, ok := s.queues[victim].popBack(); ok {
+			return t, true
+		}
+		// Another worker emptied the victim first, look again
+	}
+}
+
+// fullest returns the index of the queue with the most tiles left, or -1 if all are empty
+func (s *Scheduler) fullest() int {
+	best, bestLen := -1, 0
+	for i := range s.queues {
+		q := &s.queues[i]
+		q.mu.Lock()
+		n := len(q.tiles)
+		q.mu.Unlock()
+
+		if n > bestLen {
+			best, bestLen = i, n
+		}
+	}
+	return best
+}
+
+func (q *queue) popFront() (image.Chunk, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.tiles) == 0 {
+		return image.Chunk{}, false
+	}
+	t := q.tiles[0]
+	q.tiles = q.tiles[1:]
+	return t, true
+}
+
+func (q *queue) popBack() (image.Chunk, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.tiles) == 0 {
+		return image.Chunk{}, false
+	}
+	t := q.tiles[len(q.tiles)-1]
+	q.tiles = q.tiles[:len(q.tiles)-1]
+	return t, true
+}
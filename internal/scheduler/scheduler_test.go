@@ -0,0 +1,96 @@
+package scheduler_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sendelivery/go-trace-rays/internal/image"
+	"github.com/sendelivery/go-trace-rays/internal/scheduler"
+)
+
+// coverage counts how many tiles cover each pixel of a width by height image
+func coverage(t *testing.T, width, height int, tiles []image.Chunk) {
+	t.Helper()
+
+	counts := make([]int, width*height)
+	for _, tile := range tiles {
+		for y := tile.Start().Y(); y < tile.End().Y(); y++ {
+			for x := tile.Start().X(); x < tile.End().X(); x++ {
+				counts[y*width+x]++
+			}
+		}
+	}
+	for i, n := range counts {
+		if n != 1 {
+			t.Fatalf("pixel %d, %d covered %d times, want=1.", i%width, i/width, n)
+		}
+	}
+}
+
+func TestTiles(t *testing.T) {
+	for _, order := range []scheduler.Order{scheduler.Scanline, scheduler.Spiral, scheduler.Hilbert} {
+		for _, size := range []int{1, 7, 16, 500} {
+			t.Run(order.String(), func(t *testing.T) {
+				t.Parallel()
+
+				coverage(t, 100, 37, scheduler.Tiles(100, 37, size, order))
+			})
+		}
+	}
+}
+
+func TestSpiralStartsInCentre(t *testing.T) {
+	tiles := scheduler.Tiles(90, 90, 10, scheduler.Spiral)
+
+	if first := tiles[0].Start(); first.X() != 40 || first.Y() != 40 {
+		t.Errorf("unexpected first tile, got=%d,%d. want=40,40.", first.X(), first.Y())
+	}
+}
+
+func TestParseOrder(t *testing.T) {
+	o, err := scheduler.ParseOrder("Hilbert")
+	if err != nil || o != scheduler.Hilbert {
+		t.Errorf("unexpected result, got=%v, %v. want=%v.", o, err, scheduler.Hilbert)
+	}
+	if _, err := scheduler.ParseOrder("zigzag"); err == nil {
+		t.Error("expected an error for an unknown order")
+	}
+}
+
+// Every tile must be handed out exactly once, even when workers steal from each other
+func TestSchedulerNext(t *testing.T) {
+	tiles := scheduler.Tiles(200, 100, 8, scheduler.Scanline)
+	s := scheduler.New(tiles, 6)
+
+	var mu sync.Mutex
+	var got []image.Chunk
+
+	var wg sync.WaitGroup
+	for w := range s.Workers() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				tile, ok := s.Next(w)
+				if !ok {
+					return
+				}
+				mu.Lock()
+				got = append(got, tile)
+				mu.Unlock()
+
+				// The first worker is slow, the others will steal its tiles
+				if w == 0 {
+					time.Sleep(100 * time.Microsecond)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(got) != len(tiles) {
+		t.Fatalf("unexpected tile count, got=%d. want=%d.", len(got), len(tiles))
+	}
+	coverage(t, 200, 100, got)
+}
@@ -0,0 +1,455 @@
+// Package settings gathers the renderer's configuration from command line flags and an
+// optional settings file. Anything given explicitly overrides what the chosen scene sets up,
+// and flags on the command line take precedence over the settings file.
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sendelivery/go-trace-rays/internal/background"
+	"github.com/sendelivery/go-trace-rays/internal/camera"
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/encode"
+	"github.com/sendelivery/go-trace-rays/internal/gltf"
+	"github.com/sendelivery/go-trace-rays/internal/hdr"
+	"github.com/sendelivery/go-trace-rays/internal/progress"
+	"github.com/sendelivery/go-trace-rays/internal/scenefile"
+	"github.com/sendelivery/go-trace-rays/internal/scenes"
+	"github.com/sendelivery/go-trace-rays/internal/scheduler"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// progressModes are the accepted values of the progress setting
+var progressModes = []string{"auto", "bar", "json", "none"}
+
+type Settings struct {
+	Config string // Path to a settings file
+	Scene  string // Name of a built-in scene or path to a JSON scene file or glTF model
+
+	// Complex is the old way of choosing the complex scene, kept working for existing scripts.
+	//
+	// Deprecated: set Scene to "complex" instead.
+	Complex bool
+
+	Output   string // File to write the image to, stdout if empty
+	Format   string // Output image format, inferred from Output if empty
+	Progress string // How progress is reported, one of progressModes, auto for a bar on terminals
+
+	Parallel  bool
+	Workers   int
+	Seed      uint64
+	TileSize  int
+	TileOrder scheduler.Order
+
+	SampleLights bool
+
+	Exposure float64
+	ToneMap  color.ToneMapper
+
+	ImageWidth      int
+	AspectRatio     float64
+	SamplesPerPixel int
+	MaxDepth        int
+	VerticalFov     float64
+	LookFrom        vec3.Vector3
+	LookAt          vec3.Vector3
+	VUp             vec3.Vector3
+	DefocusAngle    float64
+	FocusDistance   float64
+	ShutterOpen     float64
+	ShutterClose    float64
+	Background      background.Backgrounder
+
+	// BackgroundPath is an environment map given as the background, loaded by Build and turned
+	// BackgroundRotation degrees about the Y axis, with its radiance scaled by BackgroundIntensity
+	BackgroundPath      string
+	BackgroundRotation  float64
+	BackgroundIntensity float64
+
+	// BackgroundSun is set when the background is a daylight sky, built by Build with the sun
+	// SunElevation degrees above the horizon and SunAzimuth degrees round from +X, through air
+	// of the given Turbidity. It is also scaled by BackgroundIntensity.
+	BackgroundSun bool
+	SunElevation  float64
+	SunAzimuth    float64
+	Turbidity     float64
+
+	fs  *flag.FlagSet
+	set map[string]bool // Names of the settings given explicitly
+}
+
+// New registers every setting as a flag on fs and returns the Settings they are parsed into.
+func New(fs *flag.FlagSet) *Settings {
+	s := &Settings{fs: fs, set: make(map[string]bool)}
+
+	fs.StringVar(&s.Config, "config", "", "path to a JSON settings file, keyed by flag name")
+	fs.StringVar(&s.Scene, "scene", "simple", fmt.Sprintf(
+		"built-in scene, one of %s, or path to a JSON scene file or glTF model", strings.Join(scenes.Names(), ", "),
+	))
+	fs.BoolVar(&s.Complex, "complex", false, "deprecated, the same as -scene complex")
+
+	fs.StringVar(&s.Output, "o", "", "file to write the image to, stdout if empty")
+	fs.StringVar(&s.Format, "format", "", fmt.Sprintf(
+		"output image format, one of %s. Inferred from -o if empty, p3 for stdout",
+		strings.Join(encode.Formats(), ", "),
+	))
+	s.Progress = "auto"
+	fs.Func("progress", fmt.Sprintf(
+		"progress reporting to stderr, one of %s. auto draws a bar on a terminal and writes json otherwise (default auto)",
+		strings.Join(progressModes, ", "),
+	), func(v string) error {
+		if !slices.Contains(progressModes, v) {
+			return fmt.Errorf("must be one of %s", strings.Join(progressModes, ", "))
+		}
+		s.Progress = v
+		return nil
+	})
+
+	fs.BoolVar(&s.Parallel, "parallel", false, "whether to use the parallelised rendering workflow")
+	fs.IntVar(&s.Workers, "workers", 0, "goroutines used by the parallel workflow, 0 for all but two CPUs")
+	fs.Uint64Var(&s.Seed, "seed", 0, "master random seed, the same seed renders the same image")
+	fs.IntVar(&s.TileSize, "tile-size", 32, "width and height of the tiles used by the parallel workflow")
+	fs.Func("tile-order", "order tiles are rendered in, one of scanline, spiral, hilbert (default scanline)",
+		func(v string) (err error) {
+			s.TileOrder, err = scheduler.ParseOrder(v)
+			return err
+		})
+
+	fs.BoolVar(&s.SampleLights, "sample-lights", true, "aim shadow rays at lights directly, reducing noise from small lights")
+
+	fs.Float64Var(&s.Exposure, "exposure", 0, "exposure adjustment in stops")
+	fs.Func("tonemap", fmt.Sprintf(
+		"tone mapping operator, one of %s (default clamp)", strings.Join(color.ToneMappers(), ", "),
+	), func(v string) (err error) {
+		s.ToneMap, err = color.ToneMapperByName(v)
+		return err
+	})
+
+	fs.IntVar(&s.ImageWidth, "width", 0, "rendered image width in pixels")
+	fs.Func("aspect-ratio", "ratio of image width over height, as a number or width:height", func(v string) (err error) {
+		s.AspectRatio, err = parseRatio(v)
+		return err
+	})
+	fs.IntVar(&s.SamplesPerPixel, "samples", 0, "random samples taken for each pixel")
+	fs.IntVar(&s.MaxDepth, "max-depth", 0, "maximum number of ray bounces into the scene")
+	fs.Float64Var(&s.VerticalFov, "fov", 0, "vertical field of view in degrees")
+	fs.Func("look-from", "point the camera looks from, as x,y,z", vectorFunc(&s.LookFrom))
+	fs.Func("look-at", "point the camera looks at, as x,y,z", vectorFunc(&s.LookAt))
+	fs.Func("vup", "camera-relative up direction, as x,y,z", vectorFunc(&s.VUp))
+	fs.Float64Var(&s.DefocusAngle, "defocus-angle", 0, "variation angle of rays through each pixel in degrees, 0 for no blur")
+	fs.Float64Var(&s.FocusDistance, "focus-distance", 0, "distance from the camera to the plane of perfect focus")
+	fs.Float64Var(&s.ShutterOpen, "shutter-open", 0, "time the shutter opens, for motion blur")
+	fs.Float64Var(&s.ShutterClose, "shutter-close", 0, "time the shutter closes, equal to -shutter-open for no motion blur")
+	fs.Func("background", "light from rays that hit nothing, sky, sun for a daylight sky, a constant colour as r,g,b, or an .hdr or .pfm environment map", func(v string) error {
+		s.Background, s.BackgroundPath, s.BackgroundSun = nil, "", false
+		switch v {
+		case "sky":
+			s.Background = background.NewSky()
+			return nil
+		case "sun":
+			s.BackgroundSun = true
+			return nil
+		}
+		if hdr.Supported(v) {
+			s.BackgroundPath = v
+			return nil
+		}
+		col, err := parseVector(v)
+		if err != nil {
+			return fmt.Errorf("must be sky, sun, a colour or an environment map: %w", err)
+		}
+		s.Background = background.NewConstant(color.Color(col))
+		return nil
+	})
+	fs.Float64Var(&s.BackgroundRotation, "background-rotation", 0, "turn of the environment map about the vertical axis in degrees, for -background with a map")
+	fs.Float64Var(&s.BackgroundIntensity, "background-intensity", 1, "brightness of the environment map or daylight sky, for -background with a map or sun")
+	fs.Float64Var(&s.SunElevation, "sun-elevation", 45, "angle of the sun above the horizon in degrees, for -background sun")
+	fs.Float64Var(&s.SunAzimuth, "sun-azimuth", 0, "angle of the sun anticlockwise from +X about the vertical axis in degrees, for -background sun")
+	fs.Float64Var(&s.Turbidity, "turbidity", 3, "haziness of the air from 1.7 for clear to 10 for hazy, for -background sun")
+
+	return s
+}
+
+// Parse parses the command line arguments, then fills every setting they didn't give from the
+// settings file, if one is named.
+func (s *Settings) Parse(args []string) error {
+	if err := s.fs.Parse(args); err != nil {
+		return err
+	}
+	s.fs.Visit(func(f *flag.Flag) { s.set[f.Name] = true })
+
+	if s.Config == "" {
+		return nil
+	}
+	f, err := os.Open(s.Config)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return s.Decode(f, s.Config)
+}
+
+// Decode reads a settings file from r, applying each value not already given on the command
+// line. The file is a JSON object keyed by flag name, vectors may be written as arrays. name
+// is used in error messages.
+func (s *Settings) Decode(r io.Reader, name string) error {
+	var values map[string]json.RawMessage
+	if err := json.NewDecoder(r).Decode(&values); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		raw := values[key]
+		if key == "config" || s.fs.Lookup(key) == nil {
+			return fmt.Errorf("%s: unknown setting %q", name, key)
+		}
+		if s.set[key] {
+			continue
+		}
+
+		v, err := flagString(raw)
+		if err != nil {
+			return fmt.Errorf("%s: %q: %w", name, key, err)
+		}
+		if err := s.fs.Set(key, v); err != nil {
+			return fmt.Errorf("%s: %q: %w", name, key, err)
+		}
+		s.set[key] = true
+	}
+	return nil
+}
+
+// IsSet reports whether the named setting was given on the command line or in the settings file
+func (s *Settings) IsSet(name string) bool {
+	return s.set[name]
+}
+
+// Build loads the chosen scene, applies every explicitly given setting over it and validates
+// the resulting camera.
+func (s *Settings) Build() (*scenefile.Scene, error) {
+	if s.Complex {
+		if s.IsSet("scene") && s.Scene != "complex" {
+			return nil, fmt.Errorf("-complex chooses the complex scene, it can't be given with -scene %s", s.Scene)
+		}
+		s.Scene = "complex"
+	}
+	if err := s.checkBackground(); err != nil {
+		return nil, err
+	}
+
+	var scene *scenefile.Scene
+	if slices.Contains(scenes.Names(), s.Scene) {
+		world, cam, err := scenes.ByName(s.Scene, s.Seed)
+		if err != nil {
+			return nil, err
+		}
+		scene = &scenefile.Scene{World: world, Camera: cam}
+	} else if gltf.IsGLTF(s.Scene) {
+		model, err := gltf.Load(s.Scene)
+		if err != nil {
+			return nil, err
+		}
+		scene = &scenefile.Scene{World: model.World, Camera: model.Camera}
+		for _, w := range model.Warnings {
+			scene.Warnings = append(scene.Warnings, fmt.Sprintf("%s: %s", filepath.Base(s.Scene), w))
+		}
+	} else {
+		f, err := scenefile.ParseFile(s.Scene)
+		if err != nil {
+			return nil, err
+		}
+		// The seed also seeds the file's procedural textures, not just the camera's sampling
+		setIf(s.IsSet("seed"), &f.Render.Seed, s.Seed)
+		if err := f.Validate(); err != nil {
+			return nil, err
+		}
+		if scene, err = f.Build(filepath.Dir(s.Scene)); err != nil {
+			return nil, err
+		}
+	}
+
+	if s.BackgroundPath != "" {
+		env, err := background.LoadEnvironment(s.BackgroundPath, utility.Deg2Rad(s.BackgroundRotation), s.BackgroundIntensity)
+		if err != nil {
+			return nil, fmt.Errorf("loading background: %w", err)
+		}
+		s.Background = env
+	}
+	if s.BackgroundSun {
+		s.Background = background.NewSunSky(
+			utility.Deg2Rad(s.SunElevation), utility.Deg2Rad(s.SunAzimuth), s.Turbidity, s.BackgroundIntensity,
+		)
+	}
+
+	if s.Complex {
+		scene.Warnings = append(scene.Warnings, "-complex is deprecated, use -scene complex")
+	}
+
+	s.Apply(scene.Camera)
+	setIf(s.IsSet("parallel"), &scene.Parallel, s.Parallel)
+	setIf(s.IsSet("exposure"), &scene.Display.Exposure, s.Exposure)
+	setIf(s.IsSet("tonemap"), &scene.Display.ToneMap, s.ToneMap)
+
+	if err := scene.Camera.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid camera settings:\n%w", err)
+	}
+	return scene, nil
+}
+
+// checkBackground returns an error for settings of the background given without the kind of
+// -background they apply to, which would otherwise go unused
+func (s *Settings) checkBackground() error {
+	env, sun := s.BackgroundPath != "", s.BackgroundSun
+	needs := []struct {
+		name string
+		ok   bool
+		want string
+	}{
+		{name: "background-rotation", ok: env, want: "an environment map"},
+		{name: "background-intensity", ok: env || sun, want: "an environment map or sun"},
+		{name: "sun-elevation", ok: sun, want: "sun"},
+		{name: "sun-azimuth", ok: sun, want: "sun"},
+		{name: "turbidity", ok: sun, want: "sun"},
+	}
+	for _, n := range needs {
+		if s.IsSet(n.name) && !n.ok {
+			return fmt.Errorf("-%s needs -background %s", n.name, n.want)
+		}
+	}
+	return nil
+}
+
+// Apply overrides the fields of cam with every camera setting that was given explicitly
+func (s *Settings) Apply(cam *camera.Camera) {
+	setIf(s.IsSet("width"), &cam.ImageWidth, s.ImageWidth)
+	setIf(s.IsSet("aspect-ratio"), &cam.AspectRatio, s.AspectRatio)
+	setIf(s.IsSet("samples"), &cam.SamplesPerPixel, s.SamplesPerPixel)
+	setIf(s.IsSet("max-depth"), &cam.MaxDepth, s.MaxDepth)
+	setIf(s.IsSet("fov"), &cam.VerticalFov, s.VerticalFov)
+	setIf(s.IsSet("look-from"), &cam.LookFrom, s.LookFrom)
+	setIf(s.IsSet("look-at"), &cam.LookAt, s.LookAt)
+	setIf(s.IsSet("vup"), &cam.VUp, s.VUp)
+	setIf(s.IsSet("defocus-angle"), &cam.DefocusAngle, s.DefocusAngle)
+	setIf(s.IsSet("focus-distance"), &cam.FocusDistance, s.FocusDistance)
+	setIf(s.IsSet("shutter-open"), &cam.ShutterOpen, s.ShutterOpen)
+	setIf(s.IsSet("shutter-close"), &cam.ShutterClose, s.ShutterClose)
+	setIf(s.IsSet("background"), &cam.Background, s.Background)
+
+	setIf(s.IsSet("seed"), &cam.Seed, s.Seed)
+	setIf(s.IsSet("workers"), &cam.Workers, s.Workers)
+	setIf(s.IsSet("tile-size"), &cam.TileSize, s.TileSize)
+	setIf(s.IsSet("tile-order"), &cam.TileOrder, s.TileOrder)
+	setIf(s.IsSet("sample-lights"), &cam.SampleLights, s.SampleLights)
+}
+
+// Observer returns the progress observer chosen by the progress setting, writing to w. The
+// bar redraws itself with carriage returns, which only make sense on a terminal, so unless it
+// is asked for by name it is only drawn when w is one.
+func (s *Settings) Observer(w io.Writer) progress.Observer {
+	switch s.Progress {
+	case "bar":
+		return progress.NewBar(w)
+	case "json":
+		return progress.NewJSONLines(w)
+	case "none":
+		return nil
+	}
+	if isTerminal(w) {
+		return progress.NewBar(w)
+	}
+	return progress.NewJSONLines(w)
+}
+
+// isTerminal reports whether w is a character device such as a terminal
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func setIf[T any](set bool, dst *T, v T) {
+	if set {
+		*dst = v
+	}
+}
+
+// flagString converts a JSON value from a settings file to the string form its flag parses
+func flagString(raw json.RawMessage) (string, error) {
+	raw = bytes.TrimSpace(raw)
+	switch {
+	case len(raw) > 0 && raw[0] == '"':
+		var v string
+		err := json.Unmarshal(raw, &v)
+		return v, err
+	case len(raw) > 0 && raw[0] == '[':
+		var v []float64
+		if err := json.Unmarshal(raw, &v); err != nil {
+			return "", err
+		}
+		parts := make([]string, len(v))
+		for i, f := range v {
+			parts[i] = strconv.FormatFloat(f, 'g', -1, 64)
+		}
+		return strings.Join(parts, ","), nil
+	}
+	// Numbers and booleans are written as the flag expects them already
+	return string(raw), nil
+}
+
+// vectorFunc returns a flag parsing function that stores a vector in dst
+func vectorFunc(dst *vec3.Vector3) func(string) error {
+	return func(v string) (err error) {
+		*dst, err = parseVector(v)
+		return err
+	}
+}
+
+// parseVector parses a vector written as x,y,z
+func parseVector(v string) (vec3.Vector3, error) {
+	parts := strings.Split(v, ",")
+	if len(parts) != 3 {
+		return vec3.Vector3{}, fmt.Errorf("want three comma separated numbers, got %q", v)
+	}
+
+	var xyz [3]float64
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return vec3.Vector3{}, fmt.Errorf("invalid number %q", p)
+		}
+		xyz[i] = f
+	}
+	return vec3.New(xyz[0], xyz[1], xyz[2]), nil
+}
+
+// parseRatio parses a ratio written either as a number or as width:height
+func parseRatio(v string) (float64, error) {
+	w, h, ok := strings.Cut(v, ":")
+	if !ok {
+		return strconv.ParseFloat(strings.TrimSpace(v), 64)
+	}
+
+	num, err := strconv.ParseFloat(strings.TrimSpace(w), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid width %q", w)
+	}
+	den, err := strconv.ParseFloat(strings.TrimSpace(h), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid height %q", h)
+	}
+	return num / den, nil
+}
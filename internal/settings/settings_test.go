@@ -0,0 +1,372 @@
+package settings_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sendelivery/go-trace-rays/internal/camera"
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/encode"
+	"github.com/sendelivery/go-trace-rays/internal/image"
+	"github.com/sendelivery/go-trace-rays/internal/interval"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/scheduler"
+	"github.com/sendelivery/go-trace-rays/internal/settings"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+func parse(t *testing.T, args []string, file string) (*settings.Settings, error) {
+	t.Helper()
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	s := settings.New(fs)
+	if err := s.Parse(args); err != nil {
+		return s, err
+	}
+	if file == "" {
+		return s, nil
+	}
+	return s, s.Decode(strings.NewReader(file), "settings.json")
+}
+
+// Flags given on the command line win over the settings file, which wins over the scene
+func TestPrecedence(t *testing.T) {
+	t.Parallel()
+
+	s, err := parse(t,
+		[]string{"-width", "20", "-look-at", "1,2,3"},
+		`{"width": 30, "samples": 7, "look-from": [4, 5, 6], "tile-order": "hilbert", "aspect-ratio": "4:3"}`,
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cam := camera.New()
+	cam.MaxDepth = 42
+	s.Apply(cam)
+
+	if cam.ImageWidth != 20 {
+		t.Errorf("unexpected width, got=%d. want=%d.", cam.ImageWidth, 20)
+	}
+	if cam.SamplesPerPixel != 7 {
+		t.Errorf("unexpected samples, got=%d. want=%d.", cam.SamplesPerPixel, 7)
+	}
+	if cam.MaxDepth != 42 {
+		t.Errorf("unexpected max depth, got=%d. want=%d.", cam.MaxDepth, 42)
+	}
+	if cam.AspectRatio != 4.0/3.0 {
+		t.Errorf("unexpected aspect ratio, got=%v. want=%v.", cam.AspectRatio, 4.0/3.0)
+	}
+	if cam.TileOrder != scheduler.Hilbert {
+		t.Errorf("unexpected tile order, got=%v. want=%v.", cam.TileOrder, scheduler.Hilbert)
+	}
+	if want := vec3.New(4, 5, 6); !vec3.Equal(cam.LookFrom, want) {
+		t.Errorf("unexpected look from, got=%q. want=%q.", &cam.LookFrom, &want)
+	}
+	if want := vec3.New(1, 2, 3); !vec3.Equal(cam.LookAt, want) {
+		t.Errorf("unexpected look at, got=%q. want=%q.", &cam.LookAt, &want)
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		args []string
+		file string
+		want string
+	}{
+		"bad vector":       {args: []string{"-look-at", "1,2"}, want: "three comma separated numbers"},
+		"bad tile order":   {args: []string{"-tile-order", "zigzag"}, want: "zigzag"},
+		"bad progress":     {args: []string{"-progress", "loud"}, want: "must be one of"},
+		"bad background":   {args: []string{"-background", "blue"}, want: "must be sky, sun, a colour or an environment map"},
+		"unknown setting":  {file: `{"widht": 10}`, want: `unknown setting "widht"`},
+		"wrong value type": {file: `{"width": "wide"}`, want: `"width"`},
+		"config in file":   {file: `{"config": "other.json"}`, want: `unknown setting "config"`},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := parse(t, tt.args, tt.file)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("unexpected error, got=%v. want it to contain %q.", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildRejectsInvalidCamera(t *testing.T) {
+	t.Parallel()
+
+	s, err := parse(t, []string{"-scene", "simple", "-samples", "-1", "-aspect-ratio", "0"}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = s.Build()
+	if err == nil {
+		t.Fatalf("expected an error, got none")
+	}
+	for _, want := range []string{"samples per pixel", "aspect ratio"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("unexpected error, got=%v. want it to contain %q.", err, want)
+		}
+	}
+}
+
+func TestEnvironmentBackground(t *testing.T) {
+	t.Parallel()
+
+	img := image.New(2, 1)
+	img.Add(image.NewPixelCoord(0, 0), color.New(1, 1, 1))
+	img.Add(image.NewPixelCoord(1, 0), color.New(1, 1, 1))
+	path := filepath.Join(t.TempDir(), "sky.pfm")
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := (encode.PFM{}).Encode(out, &img); err != nil {
+		t.Fatal(err)
+	}
+	out.Close()
+
+	s, err := parse(t, []string{"-scene", "simple", "-background", path, "-background-intensity", "3"}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	scene, err := s.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := scene.Camera.Background.Value(ray.New(vec3.New(0, 0, 0), vec3.New(0, 1, 0)))
+	if want := color.New(3, 3, 3); vec3.Sub(got, want).Length() > 1e-6 {
+		t.Errorf("unexpected background, got=%q. want=%q.", &got, &want)
+	}
+
+	s, err = parse(t, []string{"-scene", "simple", "-background", filepath.Join(t.TempDir(), "missing.hdr")}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := s.Build(); err == nil || !strings.Contains(err.Error(), "loading background") {
+		t.Errorf("unexpected error, got=%v. want it to contain %q.", err, "loading background")
+	}
+}
+
+// A glTF model renders from its own camera, with flags overriding it as for any other scene
+func TestGLTFScene(t *testing.T) {
+	t.Parallel()
+
+	var positions bytes.Buffer
+	binary.Write(&positions, binary.LittleEndian, []float32{0, 0, 0, 1, 0, 0, 0, 1, 0})
+	model := fmt.Sprintf(`{
+		"asset": {"version": "2.0"},
+		"animations": [{"channels": [], "samplers": []}],
+		"nodes": [{"mesh": 0}, {"camera": 0, "translation": [0, 0, 3]}],
+		"cameras": [{"type": "perspective", "perspective": {"yfov": 1, "znear": 0.1}}],
+		"meshes": [{"primitives": [{"attributes": {"POSITION": 0}}]}],
+		"accessors": [{"bufferView": 0, "componentType": 5126, "count": 3, "type": "VEC3"}],
+		"bufferViews": [{"buffer": 0, "byteLength": 36}],
+		"buffers": [{"byteLength": 36, "uri": "data:application/octet-stream;base64,%s"}]
+	}`, base64.StdEncoding.EncodeToString(positions.Bytes()))
+	path := filepath.Join(t.TempDir(), "triangle.gltf")
+	if err := os.WriteFile(path, []byte(model), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := parse(t, []string{"-scene", path, "-fov", "30"}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	scene, err := s.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if want := vec3.New(0, 0, 3); !vec3.Equal(scene.Camera.LookFrom, want) {
+		t.Errorf("unexpected camera position, got=%q. want=%q.", &scene.Camera.LookFrom, &want)
+	}
+	if scene.Camera.VerticalFov != 30 {
+		t.Errorf("unexpected fov, got=%v. want=%v.", scene.Camera.VerticalFov, 30)
+	}
+	want := "triangle.gltf: animations are not supported, the scene is shown in its rest pose"
+	if len(scene.Warnings) != 1 || scene.Warnings[0] != want {
+		t.Errorf("unexpected warnings, got=%q. want=%q.", scene.Warnings, []string{want})
+	}
+}
+
+// The seed flag seeds a scene file's procedural textures as well as its sampling, so the same
+// flag renders the same image whatever seed the file gives
+func TestSeedTextures(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for _, seed := range []int{1, 2} {
+		scene := fmt.Sprintf(`{
+			"render": {"seed": %d},
+			"textures": {"marble": {"type": "noise", "scale": 4}},
+			"materials": {"marble": {"type": "lambertian", "texture": "marble"}},
+			"objects": [{"type": "sphere", "centre": [0, 0, 0], "radius": 1, "material": "marble"}]
+		}`, seed)
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("seed%d.json", seed)), []byte(scene), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// albedo returns the colour of the sphere facing +Z in the scene built from args
+	albedo := func(args ...string) color.Color {
+		s, err := parse(t, args, "")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		scene, err := s.Build()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		r := ray.New(vec3.New(0.3, 0.2, 5), vec3.New(0, 0, -1))
+		hr, ok := scene.World.Hit(r, interval.New(1e-3, math.Inf(1)))
+		if !ok {
+			t.Fatal("expected to hit the sphere")
+		}
+		f, _ := hr.Material().Evaluate(r, hr, hr.Normal())
+		return f
+	}
+
+	file1 := albedo("-scene", filepath.Join(dir, "seed1.json"))
+	file2 := albedo("-scene", filepath.Join(dir, "seed2.json"))
+	flag2 := albedo("-scene", filepath.Join(dir, "seed1.json"), "-seed", "2")
+
+	if vec3.Equal(file1, file2) {
+		t.Fatalf("expected different seeds to give different textures, got=%q for both.", &file1)
+	}
+	if !vec3.Equal(flag2, file2) {
+		t.Errorf("unexpected texture with -seed 2, got=%q. want=%q.", &flag2, &file2)
+	}
+}
+
+func TestSunBackground(t *testing.T) {
+	t.Parallel()
+
+	s, err := parse(t, []string{"-scene", "simple", "-background", "sun", "-sun-elevation", "90", "-turbidity", "2"}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	scene, err := s.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Straight up is straight into the sun
+	sun := scene.Camera.Background.Value(ray.New(vec3.New(0, 0, 0), vec3.New(0, 1, 0)))
+	if sun.Y() < 1000 {
+		t.Errorf("unexpected colour of the sun, got=%q. want it brighter than 1000.", &sun)
+	}
+}
+
+// Settings of the background only make sense with the -background they apply to
+func TestBackgroundSettingsNeedBackground(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		args []string
+		want string
+	}{
+		"rotation alone":        {args: []string{"-background-rotation", "90"}, want: "-background-rotation needs -background an environment map"},
+		"rotation with sun":     {args: []string{"-background", "sun", "-background-rotation", "90"}, want: "-background-rotation"},
+		"intensity alone":       {args: []string{"-background-intensity", "2"}, want: "-background-intensity needs -background"},
+		"intensity with sky":    {args: []string{"-background", "sky", "-background-intensity", "2"}, want: "-background-intensity"},
+		"elevation alone":       {args: []string{"-sun-elevation", "10"}, want: "-sun-elevation needs -background sun"},
+		"azimuth alone":         {args: []string{"-sun-azimuth", "10"}, want: "-sun-azimuth needs -background sun"},
+		"turbidity with colour": {args: []string{"-background", "1,1,1", "-turbidity", "5"}, want: "-turbidity needs -background sun"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := parse(t, append([]string{"-scene", "simple"}, tt.args...), "")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if _, err := s.Build(); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("unexpected error, got=%v. want it to contain %q.", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestComplexFlag(t *testing.T) {
+	t.Parallel()
+
+	s, err := parse(t, []string{"-complex", "-width", "10"}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	scene, err := s.Build()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Scene != "complex" {
+		t.Errorf("unexpected scene, got=%q. want=%q.", s.Scene, "complex")
+	}
+	if len(scene.Warnings) != 1 || !strings.Contains(scene.Warnings[0], "deprecated") {
+		t.Errorf("unexpected warnings, got=%q. want a deprecation warning.", scene.Warnings)
+	}
+
+	s, err = parse(t, []string{"-complex", "-scene", "cornell"}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := s.Build(); err == nil || !strings.Contains(err.Error(), "-complex") {
+		t.Errorf("unexpected error, got=%v. want it to contain %q.", err, "-complex")
+	}
+}
+
+func TestObserver(t *testing.T) {
+	t.Parallel()
+
+	// A file is not a terminal, so the bar is only drawn to it when asked for
+	file, err := os.Create(filepath.Join(t.TempDir(), "progress.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	tests := map[string]struct {
+		args []string
+		w    io.Writer
+		want string
+	}{
+		"auto to a buffer": {w: &bytes.Buffer{}, want: "*progress.JSONLines"},
+		"auto to a file":   {w: file, want: "*progress.JSONLines"},
+		"bar to a buffer":  {args: []string{"-progress", "bar"}, w: &bytes.Buffer{}, want: "*progress.Bar"},
+		"json":             {args: []string{"-progress", "json"}, w: &bytes.Buffer{}, want: "*progress.JSONLines"},
+		"none":             {args: []string{"-progress", "none"}, w: &bytes.Buffer{}, want: "<nil>"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := parse(t, tt.args, "")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := fmt.Sprintf("%T", s.Observer(tt.w)); got != tt.want {
+				t.Errorf("unexpected observer, got=%s. want=%s.", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package texture
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg" // Register the JPEG decoder with image.Decode
+	_ "image/png"  // Register the PNG decoder with image.Decode
+	"math"
+	"os"
+
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/interval"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// Image is a texture backed by a bitmap, looked up by surface coordinates. Pixel values are
+// converted from sRGB to linear light when loaded.
+type Image struct {
+	width, height int
+	pixels        []color.Color
+}
+
+// LoadImage reads a PNG or JPEG file into an Image texture.
+func LoadImage(path string) (*Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("decoding texture %s: %w", path, err)
+	}
+	return NewImage(img), nil
+}
+
+// NewImage returns an Image texture holding a linear copy of img.
+func NewImage(img image.Image) *Image {
+	return newImage(img, srgbToLinear)
+}
+
+// NewImageLinear returns an Image texture holding img as it is, for images storing numbers,
+// such as roughness, rather than colours.
+func NewImageLinear(img image.Image) *Image {
+	return newImage(img, func(c float64) float64 { return c })
+}
+
+// newImage returns an Image texture holding a copy of img with each channel passed through
+// decode
+func newImage(img image.Image, decode func(float64) float64) *Image {
+	b := img.Bounds()
+	t := Image{
+		width:  b.Dx(),
+		height: b.Dy(),
+		pixels: make([]color.Color, 0, b.Dx()*b.Dy()),
+	}
+
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			t.pixels = append(t.pixels, color.New(
+				decode(float64(r)/0xffff),
+				decode(float64(g)/0xffff),
+				decode(float64(bl)/0xffff),
+			))
+		}
+	}
+	return &t
+}
+
+func (t *Image) Value(u, v float64, p vec3.Vector3) color.Color {
+	// Solid cyan is a debugging aid for missing texture data
+	if t.height <= 0 {
+		return color.New(0, 1, 1)
+	}
+
+	// Clamp input texture coordinates to [0,1] x [1,0], v is flipped to image coordinates
+	unit := interval.New(0, 1)
+	u = unit.Clamp(u)
+	v = 1 - unit.Clamp(v)
+
+	i := min(int(u*float64(t.width)), t.width-1)
+	j := min(int(v*float64(t.height)), t.height-1)
+
+	return t.pixels[j*t.width+i]
+}
+
+func srgbToLinear(c float64) float64 {
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
@@ -0,0 +1,117 @@
+package texture
+
+import (
+	"math"
+
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+const perlinPointCount = 256
+
+// Perlin generates smooth gradient noise in three dimensions.
+type Perlin struct {
+	randvec      [perlinPointCount]vec3.Vector3
+	permX, permY [perlinPointCount]int
+	permZ        [perlinPointCount]int
+}
+
+// NewPerlin returns a noise generator whose gradients and permutations are drawn from rng.
+func NewPerlin(rng *utility.Rand) *Perlin {
+	var p Perlin
+	for i := range p.randvec {
+		p.randvec[i] = vec3.UnitVector(vec3.NewRandomN(rng, -1, 1))
+	}
+	generatePerm(rng, &p.permX)
+	generatePerm(rng, &p.permY)
+	generatePerm(rng, &p.permZ)
+	return &p
+}
+
+// Noise returns the noise value at p, in the range [-1, 1].
+func (pn *Perlin) Noise(p vec3.Vector3) float64 {
+	u := p.X() - math.Floor(p.X())
+	v := p.Y() - math.Floor(p.Y())
+	w := p.Z() - math.Floor(p.Z())
+
+	i := int(math.Floor(p.X()))
+	j := int(math.Floor(p.Y()))
+	k := int(math.Floor(p.Z()))
+
+	var c [2][2][2]vec3.Vector3
+	for di := range 2 {
+		for dj := range 2 {
+			for dk := range 2 {
+				c[di][dj][dk] = pn.randvec[pn.permX[(i+di)&255]^
+					pn.permY[(j+dj)&255]^
+					pn.permZ[(k+dk)&255]]
+			}
+		}
+	}
+
+	return perlinInterp(&c, u, v, w)
+}
+
+// Turbulence sums depth octaves of noise, each at double the frequency and half the weight of
+// the last. The result is always positive.
+func (pn *Perlin) Turbulence(p vec3.Vector3, depth int) float64 {
+	accum := 0.0
+	weight := 1.0
+	for range depth {
+		accum += weight * pn.Noise(p)
+		weight *= 0.5
+		p = vec3.Mulf(p, 2)
+	}
+	return math.Abs(accum)
+}
+
+func generatePerm(rng *utility.Rand, p *[perlinPointCount]int) {
+	for i := range p {
+		p[i] = i
+	}
+	for i := len(p) - 1; i > 0; i-- {
+		target := rng.IntN(i + 1)
+		p[i], p[target] = p[target], p[i]
+	}
+}
+
+// perlinInterp trilinearly interpolates the gradient contributions of the cube corners c,
+// using Hermite smoothing to avoid grid artefacts.
+func perlinInterp(c *[2][2][2]vec3.Vector3, u, v, w float64) float64 {
+	uu := u * u * (3 - 2*u)
+	vv := v * v * (3 - 2*v)
+	ww := w * w * (3 - 2*w)
+
+	accum := 0.0
+	for i := range 2 {
+		for j := range 2 {
+			for k := range 2 {
+				fi, fj, fk := float64(i), float64(j), float64(k)
+				weight := vec3.New(u-fi, v-fj, w-fk)
+				accum += (fi*uu + (1-fi)*(1-uu)) *
+					(fj*vv + (1-fj)*(1-vv)) *
+					(fk*ww + (1-fk)*(1-ww)) *
+					vec3.Dot(c[i][j][k], weight)
+			}
+		}
+	}
+	return accum
+}
+
+// Noise is a marble-like texture, stripes along z disturbed by Perlin turbulence.
+type Noise struct {
+	noise *Perlin
+	scale float64
+}
+
+// NewNoise returns a marble texture, a larger scale gives more tightly packed veins. The noise
+// pattern is drawn from rng.
+func NewNoise(rng *utility.Rand, scale float64) Noise {
+	return Noise{NewPerlin(rng), scale}
+}
+
+func (n Noise) Value(u, v float64, p vec3.Vector3) color.Color {
+	s := 1 + math.Sin(n.scale*p.Z()+10*n.noise.Turbulence(p, 7))
+	return vec3.Mulf(color.New(0.5, 0.5, 0.5), s)
+}
@@ -0,0 +1,92 @@
+package texture
+
+import (
+	"math"
+
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// Texturer returns a colour for a surface point given its (u, v) surface coordinates and its
+// position p in the world.
+type Texturer interface {
+	Value(u, v float64, p vec3.Vector3) color.Color
+}
+
+// SolidColor is the same colour everywhere.
+type SolidColor struct {
+	albedo color.Color
+}
+
+func NewSolidColor(albedo color.Color) SolidColor {
+	return SolidColor{albedo}
+}
+
+func (sc SolidColor) Value(u, v float64, p vec3.Vector3) color.Color {
+	return sc.albedo
+}
+
+// Checker is a 3D checkerboard alternating between two textures in cubes of side scale. Being
+// solid, it needs no surface coordinates and wraps any shape without distortion.
+type Checker struct {
+	invScale  float64
+	even, odd Texturer
+}
+
+func NewChecker(scale float64, even, odd Texturer) Checker {
+	return Checker{1 / scale, even, odd}
+}
+
+// NewCheckerColors returns a Checker alternating between two solid colours.
+func NewCheckerColors(scale float64, even, odd color.Color) Checker {
+	return NewChecker(scale, NewSolidColor(even), NewSolidColor(odd))
+}
+
+func (c Checker) Value(u, v float64, p vec3.Vector3) color.Color {
+	x := int(math.Floor(c.invScale * p.X()))
+	y := int(math.Floor(c.invScale * p.Y()))
+	z := int(math.Floor(c.invScale * p.Z()))
+
+	if (x+y+z)%2 == 0 {
+		return c.even.Value(u, v, p)
+	}
+	return c.odd.Value(u, v, p)
+}
+
+// NewSolidValue returns a SolidColor with every channel set to x, for textures standing in for
+// a number rather than a colour.
+func NewSolidValue(x float64) SolidColor {
+	return NewSolidColor(color.New(x, x, x))
+}
+
+// Channel is one channel of another texture, copied into all three. It picks out the values
+// packed into a single image, such as the roughness and metalness of a glTF material.
+type Channel struct {
+	tex     Texturer
+	channel int
+}
+
+// NewChannel returns a Channel reading channel 0, 1 or 2, red, green or blue, of tex.
+func NewChannel(tex Texturer, channel int) Channel {
+	return Channel{tex, max(0, min(channel, 2))}
+}
+
+func (c Channel) Value(u, v float64, p vec3.Vector3) color.Color {
+	col := c.tex.Value(u, v, p)
+	x := [3]float64{col.X(), col.Y(), col.Z()}[c.channel]
+	return color.New(x, x, x)
+}
+
+// Scaled is another texture multiplied by a colour, such as an image tinted by a factor.
+type Scaled struct {
+	tex    Texturer
+	factor color.Color
+}
+
+func NewScaled(tex Texturer, factor color.Color) Scaled {
+	return Scaled{tex, factor}
+}
+
+func (s Scaled) Value(u, v float64, p vec3.Vector3) color.Color {
+	return vec3.Mulv(s.tex.Value(u, v, p), s.factor)
+}
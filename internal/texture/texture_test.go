@@ -0,0 +1,126 @@
+package texture_test
+
+import (
+	"image"
+	imagecolor "image/color"
+	"math"
+	"testing"
+
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/texture"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+func TestChecker(t *testing.T) {
+	even := color.New(1, 1, 1)
+	odd := color.New(0, 0, 0)
+	checker := texture.NewCheckerColors(1, even, odd)
+
+	tests := []struct {
+		name string
+		p    vec3.Vector3
+		want color.Color
+	}{
+		{name: "origin", p: vec3.New(0.5, 0.5, 0.5), want: even},
+		{name: "one step x", p: vec3.New(1.5, 0.5, 0.5), want: odd},
+		{name: "two steps", p: vec3.New(1.5, 1.5, 0.5), want: even},
+		{name: "negative", p: vec3.New(-0.5, 0.5, 0.5), want: odd},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := checker.Value(0, 0, tc.p); !vec3.Equal(got, tc.want) {
+				t.Errorf("unexpected colour, got=%q. want=%q.", &got, &tc.want)
+			}
+		})
+	}
+}
+
+func TestImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, imagecolor.RGBA{255, 0, 0, 255}) // top left
+	img.Set(1, 1, imagecolor.RGBA{0, 0, 255, 255}) // bottom right
+
+	tex := texture.NewImage(img)
+
+	tests := []struct {
+		name string
+		u, v float64
+		want color.Color
+	}{
+		{name: "top left", u: 0.1, v: 0.9, want: color.New(1, 0, 0)},
+		{name: "bottom right", u: 0.9, v: 0.1, want: color.New(0, 0, 1)},
+		{name: "clamped", u: 2, v: -1, want: color.New(0, 0, 1)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tex.Value(tc.u, tc.v, vec3.New(0, 0, 0)); !vec3.Equal(got, tc.want) {
+				t.Errorf("unexpected colour, got=%q. want=%q.", &got, &tc.want)
+			}
+		})
+	}
+}
+
+func TestChannelAndScaled(t *testing.T) {
+	packed := texture.NewSolidColor(color.New(0.1, 0.5, 0.9))
+
+	tests := []struct {
+		name string
+		tex  texture.Texturer
+		want color.Color
+	}{
+		{name: "red", tex: texture.NewChannel(packed, 0), want: color.New(0.1, 0.1, 0.1)},
+		{name: "blue", tex: texture.NewChannel(packed, 2), want: color.New(0.9, 0.9, 0.9)},
+		{name: "scaled", tex: texture.NewScaled(packed, color.New(2, 1, 0)), want: color.New(0.2, 0.5, 0)},
+		{name: "value", tex: texture.NewSolidValue(0.3), want: color.New(0.3, 0.3, 0.3)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tc.tex.Value(0, 0, vec3.New(0, 0, 0)); !vec3.Equal(got, tc.want) {
+				t.Errorf("unexpected colour, got=%q. want=%q.", &got, &tc.want)
+			}
+		})
+	}
+}
+
+func TestPerlin(t *testing.T) {
+	t.Parallel()
+
+	a := texture.NewPerlin(utility.NewRand(1, 0))
+	same := texture.NewPerlin(utility.NewRand(1, 0))
+	other := texture.NewPerlin(utility.NewRand(2, 0))
+
+	// Gradient noise passes through zero at every point of the integer lattice
+	for _, p := range []vec3.Vector3{vec3.New(0, 0, 0), vec3.New(3, -7, 12), vec3.New(-255, 256, 1)} {
+		if n := a.Noise(p); math.Abs(n) > 1e-12 {
+			t.Errorf("unexpected noise at lattice point %q, got=%v. want=0.", &p, n)
+		}
+	}
+
+	// In between it is drawn from the seed, the same seed giving the same pattern
+	rng := utility.NewRand(3, 0)
+	differs := false
+	for range 100 {
+		p := vec3.NewRandomN(rng, -10, 10)
+		n := a.Noise(p)
+		if n < -1 || n > 1 {
+			t.Fatalf("unexpected noise at %q, got=%v. want between -1 and 1.", &p, n)
+		}
+		if got := same.Noise(p); got != n {
+			t.Fatalf("unexpected noise at %q with the same seed, got=%v. want=%v.", &p, got, n)
+		}
+		differs = differs || other.Noise(p) != n
+	}
+	if !differs {
+		t.Error("expected a different seed to give different noise")
+	}
+}
@@ -1,6 +1,6 @@
 package utility
 
-import "math/rand"
+import "math/rand/v2"
 
 const (
 	PI = 3.1415926535897932385
@@ -10,10 +10,43 @@ func Deg2Rad(d float64) float64 {
 	return d * PI / 180
 }
 
-func Random() float64 {
-	return rand.Float64()
+// Rand is a fast PCG random number generator. A Rand must not be shared between goroutines,
+// each worker should own its own, which keeps them from contending and keeps their sequences
+// reproducible.
+type Rand struct {
+	pcg rand.PCG
 }
 
-func RandomN(min, max float64) float64 {
-	return min + (max-min)*Random()
+// NewRand returns a generator for the given stream of a master seed. Equal seeds and streams
+// always produce the same sequence, different streams produce independent sequences, so a
+// render can derive one generator per pixel or tile from a single seed.
+func NewRand(seed, stream uint64) *Rand {
+	s1 := splitmix64(seed)
+	s2 := splitmix64(s1 ^ splitmix64(stream))
+	r := Rand{}
+	r.pcg.Seed(s1, s2)
+	return &r
+}
+
+// Float64 returns a random number in [0,1)
+func (r *Rand) Float64() float64 {
+	return float64(r.pcg.Uint64()>>11) * 0x1p-53
+}
+
+// Range returns a random number in [min,max)
+func (r *Rand) Range(min, max float64) float64 {
+	return min + (max-min)*r.Float64()
+}
+
+// IntN returns a random integer in [0,n)
+func (r *Rand) IntN(n int) int {
+	return int(r.pcg.Uint64() % uint64(n))
+}
+
+// splitmix64 scrambles x, turning similar seeds into unrelated ones
+func splitmix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
 }
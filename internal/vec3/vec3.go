@@ -15,25 +15,25 @@ func New(x, y, z float64) Vector3 {
 	return Vector3{x, y, z}
 }
 
-func NewRandom() Vector3 {
+func NewRandom(rng *utility.Rand) Vector3 {
 	return Vector3{
-		utility.Random(),
-		utility.Random(),
-		utility.Random(),
+		rng.Float64(),
+		rng.Float64(),
+		rng.Float64(),
 	}
 }
 
-func NewRandomN(min, max float64) Vector3 {
+func NewRandomN(rng *utility.Rand, min, max float64) Vector3 {
 	return Vector3{
-		utility.RandomN(min, max),
-		utility.RandomN(min, max),
-		utility.RandomN(min, max),
+		rng.Range(min, max),
+		rng.Range(min, max),
+		rng.Range(min, max),
 	}
 }
 
-func NewRandomUnitVector() Vector3 {
+func NewRandomUnitVector(rng *utility.Rand) Vector3 {
 	for {
-		p := NewRandomN(-1, 1)
+		p := NewRandomN(rng, -1, 1)
 		lensq := p.LengthSquared()
 		if 1e-160 < lensq && lensq <= 1 {
 			return Div(p, math.Sqrt(lensq))
@@ -41,8 +41,8 @@ func NewRandomUnitVector() Vector3 {
 	}
 }
 
-func NewRandomOnHemisphere(normal Vector3) Vector3 {
-	u := NewRandomUnitVector()
+func NewRandomOnHemisphere(rng *utility.Rand, normal Vector3) Vector3 {
+	u := NewRandomUnitVector(rng)
 	if Dot(u, normal) > 0.0 {
 		// In the same hemisphere as the normal
 		return u
@@ -174,9 +174,9 @@ func UnitVector(v Vector3) Vector3 {
 	return v
 }
 
-func RandomInUnitDisk() Vector3 {
+func RandomInUnitDisk(rng *utility.Rand) Vector3 {
 	for {
-		p := New(utility.RandomN(-1, 1), utility.RandomN(-1, 1), 0)
+		p := New(rng.Range(-1, 1), rng.Range(-1, 1), 0)
 		if p.LengthSquared() < 1 {
 			return p
 		}
@@ -298,3 +298,14 @@ func lengthSquared(a *Vector3) float64 {
 func length(a *Vector3) float64 {
 	return math.Sqrt(lengthSquared(a))
 }
+
+// Basis returns two unit vectors that, together with the unit vector n, form a right-handed
+// orthonormal basis. The method is from Duff et al., "Building an Orthonormal Basis, Revisited".
+func Basis(n Vector3) (u, v Vector3) {
+	sign := math.Copysign(1, n.z)
+	a := -1 / (sign + n.z)
+	b := n.x * n.y * a
+	u = New(1+sign*n.x*n.x*a, sign*b, -sign*n.x)
+	v = New(b, sign+n.y*n.y*a, -n.y)
+	return u, v
+}
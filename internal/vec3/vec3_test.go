@@ -291,3 +291,26 @@ func TestCross(t *testing.T) {
 		t.Error("myVec2 was mutated")
 	}
 }
+
+func TestBasis(t *testing.T) {
+	normals := []vec3.Vector3{
+		vec3.New(0, 0, 1),
+		vec3.New(0, 0, -1),
+		vec3.New(1, 0, 0),
+		vec3.UnitVector(vec3.New(1, -2, 3)),
+	}
+
+	for _, n := range normals {
+		u, v := vec3.Basis(n)
+
+		if math.Abs(u.Length()-1) > 1e-12 || math.Abs(v.Length()-1) > 1e-12 {
+			t.Errorf("basis of %q is not unit length, got u=%q v=%q.", &n, &u, &v)
+		}
+		if math.Abs(vec3.Dot(u, v)) > 1e-12 || math.Abs(vec3.Dot(u, n)) > 1e-12 || math.Abs(vec3.Dot(v, n)) > 1e-12 {
+			t.Errorf("basis of %q is not orthogonal, got u=%q v=%q.", &n, &u, &v)
+		}
+		if cross := vec3.Cross(u, v); vec3.Dot(cross, n) < 0.999 {
+			t.Errorf("basis of %q is not right-handed, got u x v=%q.", &n, &cross)
+		}
+	}
+}
@@ -1,43 +1,93 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
+	"os"
+	"os/signal"
 
-	"github.com/sendelivery/go-trace-rays/internal/camera"
-	"github.com/sendelivery/go-trace-rays/internal/object/hittable"
-	"github.com/sendelivery/go-trace-rays/internal/scenes"
-	"github.com/sendelivery/go-trace-rays/internal/vec3"
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/encode"
+	"github.com/sendelivery/go-trace-rays/internal/image"
+	"github.com/sendelivery/go-trace-rays/internal/settings"
 )
 
 func main() {
-	complex := flag.Bool("complex", false, "whether to render a complex world")
-	parallel := flag.Bool("parallel", false, "whether to use the parllelised rendering workflow")
-	flag.Parse()
+	s := settings.New(flag.CommandLine)
+	if err := s.Parse(os.Args[1:]); err != nil {
+		fatal(err)
+	}
 
-	var world hittable.Hittabler
-	if *complex {
-		world = scenes.NewComplex()
+	scene, err := s.Build()
+	if err != nil {
+		fatal(err)
+	}
+	for _, w := range scene.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
+	cam := scene.Camera
+	cam.Progress = s.Observer(os.Stderr)
+
+	enc, err := chooseEncoder(s.Output, s.Format, scene.Display)
+	if err != nil {
+		fatal(err)
+	}
+
+	// An interrupt stops the render early, the partial image is still written
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var img *image.Image
+	if scene.Parallel {
+		img, err = cam.RenderParallel(ctx, scene.World)
 	} else {
-		world = scenes.NewSimple()
+		img, err = cam.Render(ctx, scene.World)
+	}
+	if err != nil {
+		if img == nil {
+			fatal(err)
+		}
+		fmt.Fprintf(os.Stderr, "render stopped early: %v\n", err)
+		img.FillEmpty(color.Black)
 	}
 
-	cam := camera.New()
-	cam.AspectRatio = 16.0 / 9.0
-	cam.ImageWidth = 1200
-	cam.SamplesPerPixel = 500
-	cam.MaxDepth = 50
+	if err := writeImage(s.Output, enc, img); err != nil {
+		fatal(err)
+	}
+}
 
-	cam.VerticalFov = 20
-	cam.LookFrom = vec3.New(13, 2, 3)
-	cam.LookAt = vec3.New(0, 0, 0)
-	cam.VUp = vec3.New(0, 1, 0)
+// chooseEncoder picks the encoder named by format, falling back to the output file extension
+// and then to ASCII PPM when writing to stdout.
+func chooseEncoder(output, format string, d color.Display) (encode.Encoder, error) {
+	switch {
+	case format != "":
+		return encode.ByName(format, d)
+	case output != "":
+		return encode.ForPath(output, d)
+	default:
+		return encode.ByName("p3", d)
+	}
+}
 
-	cam.DefocusAngle = 0.6
-	cam.FocusDistance = 10.0
+// writeImage encodes img to the file at path, or to stdout if path is empty
+func writeImage(path string, enc encode.Encoder, img *image.Image) error {
+	if path == "" {
+		return enc.Encode(os.Stdout, img)
+	}
 
-	if *parallel {
-		cam.RenderParallel(world)
-	} else {
-		cam.Render(world)
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := enc.Encode(f, img); err != nil {
+		f.Close()
+		return err
 	}
+	return f.Close()
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
 }
@@ -0,0 +1,93 @@
+// Package material provides the materials that decide how light scatters off shapes
+package material
+
+import (
+	"github.com/sendelivery/go-trace-rays/internal/object/material"
+	"github.com/sendelivery/go-trace-rays/tracer"
+)
+
+type (
+	Lambertian   = material.Lambertian
+	Metal        = material.Metal
+	Dielectric   = material.Dielectric
+	DiffuseLight = material.DiffuseLight
+
+	// Physically based microfacet materials
+	Conductor       = material.Conductor
+	RoughDielectric = material.RoughDielectric
+	ComplexIOR      = material.ComplexIOR
+
+	// An uber material for assets authored with metallic-roughness parameters
+	Principled       = material.Principled
+	PrincipledParams = material.PrincipledParams
+
+	// Phase functions, for filling a shape.ConstantMedium
+	Isotropic        = material.Isotropic
+	HenyeyGreenstein = material.HenyeyGreenstein
+)
+
+// NewLambertian returns a matte material
+func NewLambertian(albedo tracer.Color) *Lambertian { return material.NewLambertian(albedo) }
+
+func NewLambertianTexture(tex tracer.Texture) *Lambertian {
+	return material.NewLambertianTexture(tex)
+}
+
+// NewMetal returns a reflective material, blurred by fuzz from 0 for a perfect mirror to 1
+func NewMetal(albedo tracer.Color, fuzz float64) *Metal { return material.NewMetal(albedo, fuzz) }
+
+func NewMetalTexture(tex tracer.Texture, fuzz float64) *Metal {
+	return material.NewMetalTexture(tex, fuzz)
+}
+
+// NewDielectric returns a clear refractive material such as glass or water
+func NewDielectric(refractionIndex float64) *Dielectric {
+	return material.NewDielectric(refractionIndex)
+}
+
+// Refractive indices of common metals, for NewConductor
+var (
+	Gold      = material.Gold
+	Copper    = material.Copper
+	Aluminium = material.Aluminium
+	Silver    = material.Silver
+)
+
+// NewConductor returns a metal with the refractive index ior, from a mirror at roughness 0 to
+// a very rough surface at roughness 1
+func NewConductor(ior ComplexIOR, roughness float64) *Conductor {
+	return material.NewConductor(ior, roughness)
+}
+
+// NewRoughDielectric returns frosted glass, smooth at roughness 0 and very frosted at 1
+func NewRoughDielectric(refractionIndex, roughness float64) *RoughDielectric {
+	return material.NewRoughDielectric(refractionIndex, roughness)
+}
+
+// NewPrincipled returns a principled material, each of its parameters textured, with nil
+// parameters taking their defaults
+func NewPrincipled(p PrincipledParams) *Principled { return material.NewPrincipled(p) }
+
+// NewDiffuseLight returns a material emitting emit in every direction
+func NewDiffuseLight(emit tracer.Color) *DiffuseLight { return material.NewDiffuseLight(emit) }
+
+func NewDiffuseLightTexture(tex tracer.Texture) *DiffuseLight {
+	return material.NewDiffuseLightTexture(tex)
+}
+
+// NewIsotropic returns a phase function scattering light equally in every direction
+func NewIsotropic(albedo tracer.Color) *Isotropic { return material.NewIsotropic(albedo) }
+
+func NewIsotropicTexture(tex tracer.Texture) *Isotropic {
+	return material.NewIsotropicTexture(tex)
+}
+
+// NewHenyeyGreenstein returns a phase function favouring forward scattering for g > 0 and
+// backward scattering for g < 0
+func NewHenyeyGreenstein(albedo tracer.Color, g float64) *HenyeyGreenstein {
+	return material.NewHenyeyGreenstein(albedo, g)
+}
+
+func NewHenyeyGreensteinTexture(tex tracer.Texture, g float64) *HenyeyGreenstein {
+	return material.NewHenyeyGreensteinTexture(tex, g)
+}
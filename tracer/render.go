@@ -0,0 +1,89 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sendelivery/go-trace-rays/internal/gltf"
+	"github.com/sendelivery/go-trace-rays/internal/scenefile"
+	"github.com/sendelivery/go-trace-rays/internal/scenes"
+)
+
+// Scene is everything Render needs to know about what to draw
+type Scene struct {
+	World   Hittable
+	Camera  *Camera
+	Display Display // How the image should be displayed, for encoders that need it
+}
+
+// LoadScene reads, validates and builds the JSON scene file at path. The file's parallel render
+// setting is left to Options.
+func LoadScene(path string) (Scene, error) {
+	s, err := scenefile.Load(path)
+	if err != nil {
+		return Scene{}, err
+	}
+	return Scene{World: s.World, Camera: s.Camera, Display: s.Display}, nil
+}
+
+// LoadGLTF reads the glTF 2.0 model at path, a .gltf or .glb file, as a scene seen through its
+// first perspective camera, or framed from the front if it has none. The warnings list what
+// the file uses that had to be left out, such as unsupported extensions.
+func LoadGLTF(path string) (Scene, []string, error) {
+	s, err := gltf.Load(path)
+	if err != nil {
+		return Scene{}, nil, err
+	}
+	return Scene{World: s.World, Camera: s.Camera}, s.Warnings, nil
+}
+
+// SceneNames returns the names of the built-in scenes
+func SceneNames() []string { return scenes.Names() }
+
+// BuiltinScene returns the named built-in scene. Scenes with randomly generated content draw
+// it from seed.
+func BuiltinScene(name string, seed uint64) (Scene, error) {
+	world, cam, err := scenes.ByName(name, seed)
+	if err != nil {
+		return Scene{}, err
+	}
+	return Scene{World: world, Camera: cam}, nil
+}
+
+// Options controls how Render goes about rendering, as opposed to what it renders. They take
+// the place of the matching Camera fields.
+type Options struct {
+	Parallel bool     // Render in tiles across several goroutines rather than a scanline at a time
+	Workers  int      // Goroutines used when Parallel, 0 for all but two CPUs
+	Progress Observer // Receives progress events, nil for none
+}
+
+// Render renders scene and returns its framebuffer along with statistics about the render.
+// scene.Camera is not modified, so a scene may be rendered several times at once.
+//
+// If ctx is cancelled the render stops early and returns the partially rendered framebuffer,
+// in which unrendered pixels are empty, along with the context's error. An invalid scene
+// returns an empty framebuffer and an error describing every problem with it.
+func Render(ctx context.Context, scene Scene, opts Options) (Framebuffer, Stats, error) {
+	switch {
+	case scene.World == nil:
+		return Framebuffer{}, Stats{}, errors.New("scene has no world")
+	case scene.Camera == nil:
+		return Framebuffer{}, Stats{}, errors.New("scene has no camera")
+	}
+
+	cam := *scene.Camera
+	cam.Workers = opts.Workers
+	cam.Progress = opts.Progress
+
+	render := cam.Render
+	if opts.Parallel {
+		render = cam.RenderParallel
+	}
+
+	img, err := render(ctx, scene.World)
+	if img == nil {
+		return Framebuffer{}, Stats{}, err
+	}
+	return *img, cam.Stats(), err
+}
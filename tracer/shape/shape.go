@@ -0,0 +1,118 @@
+// Package shape provides the geometry that makes up a tracer.Scene's world
+package shape
+
+import (
+	"github.com/sendelivery/go-trace-rays/internal/obj"
+	"github.com/sendelivery/go-trace-rays/internal/object/bvh"
+	"github.com/sendelivery/go-trace-rays/internal/object/hittable"
+	"github.com/sendelivery/go-trace-rays/internal/object/medium"
+	"github.com/sendelivery/go-trace-rays/internal/object/mesh"
+	"github.com/sendelivery/go-trace-rays/internal/object/planar"
+	"github.com/sendelivery/go-trace-rays/internal/object/sphere"
+	"github.com/sendelivery/go-trace-rays/internal/object/transform"
+	"github.com/sendelivery/go-trace-rays/internal/object/triangle"
+	"github.com/sendelivery/go-trace-rays/tracer"
+)
+
+type (
+	Sphere   = sphere.Sphere
+	Triangle = triangle.Triangle
+	Quad     = planar.Quad
+	Disk     = planar.Disk
+	Plane    = planar.Plane
+
+	// Transform is a shape placed in the world by an affine transform
+	Transform = transform.Transform
+
+	// Animated is a shape moved by interpolating between keyframes over the shutter interval
+	Animated = transform.Animated
+	Keyframe = transform.Keyframe
+
+	// ConstantMedium is fog or smoke of uniform density filling a closed shape
+	ConstantMedium = medium.Constant
+
+	// Mesh is a set of triangles sharing one VertexBuffer
+	Mesh         = mesh.Mesh
+	VertexBuffer = mesh.VertexBuffer
+	UV           = mesh.UV
+
+	// List is a plain collection of shapes, each tested in turn. Prefer NewBVH for more than a
+	// handful of shapes.
+	List = hittable.HittableList
+)
+
+func NewSphere(centre tracer.Vec3, radius float64, mat tracer.Material) Sphere {
+	return sphere.New(centre, radius, mat)
+}
+
+// NewMovingSphere returns a sphere whose centre moves in a straight line from centre0 at time 0
+// to centre1 at time 1, blurred by the camera's shutter interval
+func NewMovingSphere(centre0, centre1 tracer.Vec3, radius float64, mat tracer.Material) Sphere {
+	return sphere.NewMoving(centre0, centre1, radius, mat)
+}
+
+func NewTriangle(a, b, c tracer.Vec3, mat tracer.Material) Triangle {
+	return triangle.New(a, b, c, mat)
+}
+
+// NewQuad returns the parallelogram with corner q and edges u and v, facing along u x v. u and
+// v must not be zero or parallel.
+func NewQuad(q, u, v tracer.Vec3, mat tracer.Material) Quad {
+	return planar.NewQuad(q, u, v, mat)
+}
+
+// NewBox returns the axis-aligned box with opposite corners a and b, made of six quads. a and b
+// must differ in every coordinate.
+func NewBox(a, b tracer.Vec3, mat tracer.Material) *List {
+	return planar.NewBox(a, b, mat)
+}
+
+func NewDisk(centre, normal tracer.Vec3, radius float64, mat tracer.Material) Disk {
+	return planar.NewDisk(centre, normal, radius, mat)
+}
+
+// NewPlane returns the infinite plane through point facing along normal. Being unbounded, it
+// is best kept out of a BVH.
+func NewPlane(point, normal tracer.Vec3, mat tracer.Material) Plane {
+	return planar.NewPlane(point, normal, mat)
+}
+
+// NewTransform returns object transformed by m, which must be invertible. The same object may
+// be transformed several times to place copies of it without duplicating its geometry.
+func NewTransform(object tracer.Hittable, m tracer.Matrix4) (*Transform, error) {
+	return transform.New(object, m)
+}
+
+// NewAnimated returns object posed by interpolating between keys by the time of each ray
+func NewAnimated(object tracer.Hittable, keys []Keyframe) (*Animated, error) {
+	return transform.NewAnimated(object, keys)
+}
+
+// NewConstantMedium returns a medium filling boundary, which must be a closed, convex shape,
+// scattering light density times per unit distance on average. density must be greater than 0.
+// phase is usually a material.Isotropic.
+func NewConstantMedium(boundary tracer.Hittable, density float64, phase tracer.Material) (*ConstantMedium, error) {
+	return medium.NewConstant(boundary, density, phase)
+}
+
+// NewMesh returns a mesh of the triangles whose vertices are listed, three per triangle, in
+// indices
+func NewMesh(vb *VertexBuffer, indices []int, mat tracer.Material) (*Mesh, error) {
+	return mesh.New(vb, indices, mat)
+}
+
+// NewBVH returns a bounding volume hierarchy over objects, which finds the nearest hit in
+// logarithmic rather than linear time
+func NewBVH(objects ...tracer.Hittable) tracer.Hittable {
+	return bvh.NewFromObjects(objects)
+}
+
+// LoadOBJ reads a Wavefront OBJ model along with its MTL material libraries. Faces without a
+// material of their own use defaultMat.
+func LoadOBJ(path string, defaultMat tracer.Material) (tracer.Hittable, error) {
+	model, err := obj.Load(path, defaultMat)
+	if err != nil {
+		return nil, err
+	}
+	return model.Hittable(), nil
+}
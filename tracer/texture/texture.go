@@ -0,0 +1,45 @@
+// Package texture provides textures, colours varying over a surface, for materials
+package texture
+
+import (
+	"github.com/sendelivery/go-trace-rays/internal/texture"
+	"github.com/sendelivery/go-trace-rays/tracer"
+)
+
+type (
+	Solid   = texture.SolidColor
+	Checker = texture.Checker
+	Noise   = texture.Noise
+	Image   = texture.Image
+	Channel = texture.Channel
+	Scaled  = texture.Scaled
+)
+
+func NewSolid(col tracer.Color) Solid { return texture.NewSolidColor(col) }
+
+// NewSolidValue returns a solid texture of the number x, for parameters such as roughness
+func NewSolidValue(x float64) Solid { return texture.NewSolidValue(x) }
+
+// NewChannel returns channel 0, 1 or 2, red, green or blue, of tex in all three channels
+func NewChannel(tex tracer.Texture, channel int) Channel { return texture.NewChannel(tex, channel) }
+
+// NewScaled returns tex multiplied by factor
+func NewScaled(tex tracer.Texture, factor tracer.Color) Scaled { return texture.NewScaled(tex, factor) }
+
+// NewChecker returns a 3D checker pattern alternating between even and odd, with cells scale
+// units wide
+func NewChecker(scale float64, even, odd tracer.Texture) Checker {
+	return texture.NewChecker(scale, even, odd)
+}
+
+func NewCheckerColors(scale float64, even, odd tracer.Color) Checker {
+	return texture.NewCheckerColors(scale, even, odd)
+}
+
+// NewNoise returns a Perlin turbulence marble pattern, its noise drawn from seed
+func NewNoise(seed uint64, scale float64) Noise {
+	return texture.NewNoise(tracer.NewRand(seed, 0), scale)
+}
+
+// LoadImage returns a texture of the PNG or JPEG image at path
+func LoadImage(path string) (*Image, error) { return texture.LoadImage(path) }
@@ -0,0 +1,186 @@
+// Package tracer is the public interface to the ray tracer, for embedding it in other Go
+// programs. A Scene pairs a world of shapes, built with the shape package and coloured with the
+// material and texture packages, with a Camera viewing it. Render turns it into a Framebuffer
+// of linear radiance, which an Encoder writes out as an image.
+//
+// Most types here are aliases of the renderer's own, so values pass between the packages
+// without conversion.
+package tracer
+
+import (
+	"io"
+
+	"github.com/sendelivery/go-trace-rays/internal/aabb"
+	"github.com/sendelivery/go-trace-rays/internal/background"
+	"github.com/sendelivery/go-trace-rays/internal/camera"
+	"github.com/sendelivery/go-trace-rays/internal/color"
+	"github.com/sendelivery/go-trace-rays/internal/encode"
+	"github.com/sendelivery/go-trace-rays/internal/hdr"
+	"github.com/sendelivery/go-trace-rays/internal/image"
+	"github.com/sendelivery/go-trace-rays/internal/interval"
+	"github.com/sendelivery/go-trace-rays/internal/mat4"
+	"github.com/sendelivery/go-trace-rays/internal/object/hitrecord"
+	"github.com/sendelivery/go-trace-rays/internal/object/hittable"
+	"github.com/sendelivery/go-trace-rays/internal/progress"
+	"github.com/sendelivery/go-trace-rays/internal/ray"
+	"github.com/sendelivery/go-trace-rays/internal/scheduler"
+	"github.com/sendelivery/go-trace-rays/internal/texture"
+	"github.com/sendelivery/go-trace-rays/internal/utility"
+	"github.com/sendelivery/go-trace-rays/internal/vec3"
+)
+
+// Geometry and colour
+type (
+	Vec3     = vec3.Vector3
+	Color    = color.Color
+	Ray      = ray.Ray
+	Interval = interval.Interval
+	AABB     = aabb.AABB
+	Rand     = utility.Rand
+)
+
+func NewVec3(x, y, z float64) Vec3      { return vec3.New(x, y, z) }
+func NewColor(r, g, b float64) Color    { return color.New(r, g, b) }
+func NewRay(origin, direction Vec3) Ray { return ray.New(origin, direction) }
+func NewRayWithTime(origin, direction Vec3, time float64) Ray {
+	return ray.NewWithTime(origin, direction, time)
+}
+func NewInterval(min, max float64) Interval { return interval.New(min, max) }
+func NewAABBFromPoints(a, b Vec3) AABB      { return aabb.NewFromPoints(a, b) }
+func NewRand(seed, stream uint64) *Rand     { return utility.NewRand(seed, stream) }
+func NewHitRecord(r Ray, t float64, outwardNormal Vec3, mat Material) HitRecord {
+	return hitrecord.New(r, t, outwardNormal, mat)
+}
+
+// Affine transforms, for placing shapes with shape.NewTransform. Angles are in radians.
+type (
+	Matrix4    = mat4.Matrix4
+	Quaternion = mat4.Quaternion
+)
+
+func IdentityMatrix() Matrix4                  { return mat4.Identity() }
+func Translate(v Vec3) Matrix4                 { return mat4.Translate(v) }
+func Scale(v Vec3) Matrix4                     { return mat4.Scale(v) }
+func Rotate(axis Vec3, angle float64) Matrix4  { return mat4.Rotate(axis, angle) }
+func TRS(t Vec3, r Quaternion, s Vec3) Matrix4 { return mat4.TRS(t, r, s) }
+func MulMatrix(a, b Matrix4) Matrix4           { return mat4.Mul(a, b) }
+func FromAxisAngle(axis Vec3, angle float64) Quaternion {
+	return mat4.FromAxisAngle(axis, angle)
+}
+func FromEuler(x, y, z float64) Quaternion { return mat4.FromEuler(x, y, z) }
+
+// Interfaces implemented by the shapes, materials, textures and backgrounds, and which custom
+// ones must implement too
+type (
+	Hittable   = hittable.Hittabler
+	HitRecord  = hitrecord.HitRecord
+	Material   = hitrecord.Scatterer
+	Emitter    = hitrecord.Emitter
+	Sampler    = hittable.Sampler
+	Texture    = texture.Texturer
+	Background = background.Backgrounder
+
+	// BackgroundSampler is implemented by backgrounds that are sampled directly as lights
+	BackgroundSampler = background.Sampler
+)
+
+func NewSkyBackground() Background { return background.NewSky() }
+func NewGradientBackground(bottom, top Color) Background {
+	return background.NewGradient(bottom, top)
+}
+func NewConstantBackground(col Color) Background { return background.NewConstant(col) }
+
+// HDRImage is a grid of linear radiance values, for use as an environment map
+type HDRImage = hdr.Image
+
+// Environment lights the scene with an equirectangular image of its surroundings, sampling its
+// bright parts directly
+type Environment = background.Environment
+
+func NewHDRImage(width, height int) *HDRImage     { return hdr.New(width, height) }
+func LoadHDRImage(path string) (*HDRImage, error) { return hdr.Load(path) }
+
+// NewEnvironmentBackground returns img wrapped around the scene, turned anticlockwise about the
+// Y axis by rotation radians as seen from above, with its radiance scaled by intensity
+func NewEnvironmentBackground(img *HDRImage, rotation, intensity float64) *Environment {
+	return background.NewEnvironment(img, rotation, intensity)
+}
+
+// SunSky is a clear daylight sky with the sun in it, both sampled directly as lights
+type SunSky = background.SunSky
+
+// NewSunSkyBackground returns a daylight sky with the sun elevation radians above the horizon,
+// turned anticlockwise from +X about the Y axis by azimuth radians, through air of the given
+// turbidity, from 2 for clear to 10 for hazy. Its radiance is scaled by intensity.
+func NewSunSkyBackground(elevation, azimuth, turbidity, intensity float64) *SunSky {
+	return background.NewSunSky(elevation, azimuth, turbidity, intensity)
+}
+
+// LoadEnvironmentBackground reads an .hdr or .pfm file, see NewEnvironmentBackground
+func LoadEnvironmentBackground(path string, rotation, intensity float64) (*Environment, error) {
+	return background.LoadEnvironment(path, rotation, intensity)
+}
+
+// Camera describes the view of the scene and the image to render. Use NewCamera for one with
+// sensible defaults.
+type Camera = camera.Camera
+
+func NewCamera() *Camera { return camera.New() }
+
+// TileOrder is the order tiles are rendered in by the parallel workflow
+type TileOrder = scheduler.Order
+
+const (
+	TileScanline = scheduler.Scanline
+	TileSpiral   = scheduler.Spiral
+	TileHilbert  = scheduler.Hilbert
+)
+
+// Framebuffer holds the linear radiance of each rendered pixel
+type (
+	Framebuffer = image.Image
+	PixelCoord  = image.PixelCoord
+)
+
+func NewPixelCoord(x, y int) PixelCoord { return image.NewPixelCoord(x, y) }
+
+// Progress reporting
+type (
+	Observer          = progress.Observer
+	Stats             = progress.Stats
+	StartEvent        = progress.Start
+	TileDoneEvent     = progress.TileDone
+	SamplesEvent      = progress.SamplesCompleted
+	FinishEvent       = progress.Finish
+	ProgressBar       = progress.Bar
+	ProgressJSONLines = progress.JSONLines
+)
+
+// NewProgressBar returns an Observer drawing a progress bar to an interactive terminal
+func NewProgressBar(w io.Writer) *ProgressBar { return progress.NewBar(w) }
+
+// NewProgressJSONLines returns an Observer writing each event to w as a line of JSON
+func NewProgressJSONLines(w io.Writer) *ProgressJSONLines { return progress.NewJSONLines(w) }
+
+// Display turns linear radiance into displayable colour, through exposure and a ToneMapper
+type (
+	Display    = color.Display
+	ToneMapper = color.ToneMapper
+)
+
+// ToneMappers returns the names of the available tone mapping operators
+func ToneMappers() []string { return color.ToneMappers() }
+
+func ToneMapperByName(name string) (ToneMapper, error) { return color.ToneMapperByName(name) }
+
+// Encoder writes a Framebuffer out in an image format
+type Encoder = encode.Encoder
+
+// Formats returns the names of the available image formats
+func Formats() []string { return encode.Formats() }
+
+// EncoderByName returns the encoder for the named format, using d for low dynamic range ones
+func EncoderByName(format string, d Display) (Encoder, error) { return encode.ByName(format, d) }
+
+// EncoderForPath returns the encoder for the format matching path's extension
+func EncoderForPath(path string, d Display) (Encoder, error) { return encode.ForPath(path, d) }
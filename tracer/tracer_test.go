@@ -0,0 +1,119 @@
+package tracer_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/sendelivery/go-trace-rays/tracer"
+	"github.com/sendelivery/go-trace-rays/tracer/material"
+	"github.com/sendelivery/go-trace-rays/tracer/shape"
+	"github.com/sendelivery/go-trace-rays/tracer/texture"
+)
+
+func newScene() tracer.Scene {
+	checker := texture.NewCheckerColors(0.5, tracer.NewColor(0.2, 0.3, 0.1), tracer.NewColor(0.9, 0.9, 0.9))
+	world := shape.NewBVH(
+		shape.NewSphere(tracer.NewVec3(0, -100.5, -1), 100, material.NewLambertianTexture(checker)),
+		shape.NewSphere(tracer.NewVec3(0, 0, -1), 0.5, material.NewMetal(tracer.NewColor(0.8, 0.6, 0.2), 0.1)),
+		shape.NewSphere(tracer.NewVec3(-1, 0, -1), 0.5, material.NewDielectric(1.5)),
+	)
+
+	cam := tracer.NewCamera()
+	cam.ImageWidth = 16
+	cam.AspectRatio = 2
+	cam.SamplesPerPixel = 2
+	cam.MaxDepth = 5
+	return tracer.Scene{World: world, Camera: cam}
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	for _, parallel := range []bool{false, true} {
+		t.Run(fmt.Sprintf("parallel=%v", parallel), func(t *testing.T) {
+			t.Parallel()
+
+			scene := newScene()
+			opts := tracer.Options{Parallel: parallel, Workers: 2, Progress: tracer.NewProgressJSONLines(io.Discard)}
+			fb, stats, err := tracer.Render(context.Background(), scene, opts)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if fb.Width() != 16 || fb.Height() != 8 {
+				t.Errorf("unexpected size, got=%dx%d. want=16x8.", fb.Width(), fb.Height())
+			}
+			if _, ok := fb.Get(tracer.NewPixelCoord(15, 7)); !ok {
+				t.Errorf("pixel 15, 7 was not rendered")
+			}
+			if stats.SamplesDone != 16*8*2 || stats.Rays < stats.SamplesDone {
+				t.Errorf("unexpected stats, got=%+v.", stats)
+			}
+			if scene.Camera.Workers != 0 || scene.Camera.Progress != nil {
+				t.Errorf("scene camera was modified")
+			}
+
+			var buf bytes.Buffer
+			enc, err := tracer.EncoderByName("png", tracer.Display{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err := enc.Encode(&buf, &fb); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestRenderInvalid(t *testing.T) {
+	t.Parallel()
+
+	noCamera := newScene()
+	noCamera.Camera = nil
+
+	badCamera := newScene()
+	badCamera.Camera.SamplesPerPixel = -1
+
+	tests := map[string]struct {
+		scene tracer.Scene
+		want  string
+	}{
+		"no world":   {scene: tracer.Scene{Camera: tracer.NewCamera()}, want: "no world"},
+		"no camera":  {scene: noCamera, want: "no camera"},
+		"bad camera": {scene: badCamera, want: "samples per pixel"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, _, err := tracer.Render(context.Background(), tt.scene, tracer.Options{})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("unexpected error, got=%v. want it to contain %q.", err, tt.want)
+			}
+		})
+	}
+}
+
+func ExampleRender() {
+	world := shape.NewBVH(
+		shape.NewSphere(tracer.NewVec3(0, -100.5, -1), 100, material.NewLambertian(tracer.NewColor(0.8, 0.8, 0))),
+		shape.NewSphere(tracer.NewVec3(0, 0, -1), 0.5, material.NewLambertian(tracer.NewColor(0.1, 0.2, 0.5))),
+	)
+
+	cam := tracer.NewCamera()
+	cam.ImageWidth = 64
+	cam.AspectRatio = 16.0 / 9.0
+
+	fb, stats, err := tracer.Render(context.Background(), tracer.Scene{World: world, Camera: cam}, tracer.Options{Parallel: true})
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("%dx%d, %d samples\n", fb.Width(), fb.Height(), stats.SamplesDone)
+	// Output: 64x36, 23040 samples
+}